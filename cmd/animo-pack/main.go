@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/resource"
+)
+
+// animo-pack собирает директорию ассетов в один pack-файл:
+//
+//	animo-pack -in assets -out game.pack
+//
+// Изображения конвертируются в RGBA8, OBJ-меши - в готовые буферы вершин,
+// одинаковые файлы хранятся один раз.
+
+func main() {
+	inDir := flag.String("in", "assets", "директория с ассетами")
+	outFile := flag.String("out", "assets.pack", "выходной pack-файл")
+	level := flag.Int("level", flate.BestCompression, "уровень сжатия (0 - без сжатия, 9 - максимальный)")
+	convert := flag.Bool("convert", true, "конвертировать изображения и меши в сырые форматы")
+	manifestFile := flag.String("manifest", "", "дополнительно записать манифест в JSON-файл")
+	flag.Parse()
+
+	if *level < flate.NoCompression || *level > flate.BestCompression {
+		log.Fatalf("invalid compression level %d", *level)
+	}
+
+	files, err := collectFiles(*inDir)
+	if err != nil {
+		log.Fatalf("failed to scan %s: %v", *inDir, err)
+	}
+
+	out, err := os.Create(*outFile)
+	if err != nil {
+		log.Fatalf("failed to create %s: %v", *outFile, err)
+	}
+	defer out.Close()
+
+	writer, err := resource.NewPackWriter(out, *level)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	var inputSize int64
+	duplicates := 0
+	for _, name := range files {
+		data, format, size, err := prepareFile(*inDir, name, *convert)
+		if err != nil {
+			log.Fatalf("failed to process %s: %v", name, err)
+		}
+		inputSize += size
+
+		dup, err := writer.Add(name, format, data)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if dup {
+			duplicates++
+		}
+		fmt.Printf("  %-8s %s\n", format, name)
+	}
+
+	manifest, err := writer.Close()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if *manifestFile != "" {
+		if err := writeManifest(*manifestFile, manifest); err != nil {
+			log.Fatalf("failed to write manifest: %v", err)
+		}
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	fmt.Printf("Упаковано файлов: %d (дубликатов: %d)\n", len(files), duplicates)
+	fmt.Printf("Размер: %d -> %d байт\n", inputSize, info.Size())
+}
+
+// collectFiles возвращает отсортированный список файлов относительно root
+func collectFiles(root string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// prepareFile читает файл и при необходимости конвертирует его
+func prepareFile(root, name string, convert bool) ([]byte, string, int64, error) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	size := int64(len(data))
+
+	if !convert {
+		return data, resource.PackFormatRaw, size, nil
+	}
+
+	var buf bytes.Buffer
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", 0, err
+		}
+		rgba := image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+		if err := resource.EncodeRawImage(&buf, rgba); err != nil {
+			return nil, "", 0, err
+		}
+		return buf.Bytes(), resource.PackFormatRGBA8, size, nil
+
+	case ".obj":
+		mesh, err := resource.ParseOBJ(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if err := resource.EncodeRawMesh(&buf, mesh); err != nil {
+			return nil, "", 0, err
+		}
+		return buf.Bytes(), resource.PackFormatMesh, size, nil
+	}
+
+	return data, resource.PackFormatRaw, size, nil
+}
+
+// writeManifest записывает манифест отдельным JSON-файлом для отладки
+func writeManifest(path string, manifest *resource.PackManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -4,14 +4,16 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"math/rand"
 	"runtime"
 
 	"github.com/Salamander5876/AnimoEngine/pkg/core"
+	"github.com/Salamander5876/AnimoEngine/pkg/core/resource"
 	"github.com/Salamander5876/AnimoEngine/pkg/graphics/camera"
 	"github.com/Salamander5876/AnimoEngine/pkg/graphics/model"
 	"github.com/Salamander5876/AnimoEngine/pkg/graphics/shader"
+	"github.com/Salamander5876/AnimoEngine/pkg/graphics/texture"
 	"github.com/Salamander5876/AnimoEngine/pkg/physics"
+	"github.com/Salamander5876/AnimoEngine/pkg/physics/softbody"
 	"github.com/Salamander5876/AnimoEngine/pkg/platform/input"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -31,6 +33,8 @@ type PhysicsTest struct {
 	// Физика
 	physicsWorld *physics.PhysicsWorld
 	fluidSystem  *physics.FluidSystem
+	softBodies   *softbody.System
+	cloth        *softbody.Body
 
 	// Рендеринг
 	cubeVAO        uint32
@@ -44,6 +48,10 @@ type PhysicsTest struct {
 	planeVBO       uint32
 	liquidVAO      uint32
 	liquidVBO      uint32
+	clothVAO       uint32
+	clothVBO       uint32
+	clothEBO       uint32
+	clothVertices  []float32
 
 	// UI состояние
 	selectedShape physics.CollisionShape
@@ -235,11 +243,17 @@ func (p *PhysicsTest) onInit(engine *core.Engine) error {
 	p.createPlane()
 	p.createLiquid()
 
-	// Загружаем 3D модель пистолета
-	pistolModel, err := model.LoadFBXSimple(
-		"../../other/Malorian/Malorian.fbx",
-		"../../other/Malorian/Textures/Malorian_Base.png",
-	)
+	// Загружаем 3D модель пистолета через менеджер ресурсов: текстура
+	// загружается как зависимость модели
+	rm := engine.GetResourceManager()
+	rm.RegisterLoader(texture.Loader{})
+	rm.RegisterLoader(model.Loader{Textures: map[string]string{
+		"models/Malorian/Malorian.fbx": "Textures/Malorian_Base.png",
+	}})
+	if err := rm.Mount("models", resource.NewDirFS("../../other"), 0); err != nil {
+		return err
+	}
+	pistolModel, err := p.loadModel("models/Malorian/Malorian.fbx")
 	if err != nil {
 		fmt.Printf("⚠️  Не удалось загрузить модель пистолета: %v\n", err)
 		fmt.Println("💡 Будет использоваться куб вместо модели")
@@ -250,19 +264,26 @@ func (p *PhysicsTest) onInit(engine *core.Engine) error {
 
 	// Создаем физический мир
 	p.physicsWorld = physics.NewPhysicsWorld()
-	p.physicsWorld.GroundPlaneY = 0.0
 
-	// Создаем систему жидкости
-	p.fluidSystem = physics.NewFluidSystem()
+	// Создаем систему жидкости PBF с обычной гравитацией; мир обновляет ее вместе с телами
+	p.fluidSystem = physics.NewPBFFluidSystem()
 	p.fluidSystem.Bounds = mgl32.Vec3{20, 20, 20}
+	p.physicsWorld.AddFluid(p.fluidSystem)
 
 	// Добавляем статичную плоскость земли
-	ground := physics.NewRigidBody(physics.Static, physics.PlaneShape)
-	ground.Position = mgl32.Vec3{0, 0, 0}
+	ground := physics.NewPlaneBody(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
 	ground.Dimensions = mgl32.Vec3{20, 0.1, 20}
 	ground.Name = "Ground"
 	p.physicsWorld.AddBody(ground)
 
+	// Ткань, закрепленная за два верхних угла; мягкие тела сталкиваются с телами мира
+	p.softBodies = softbody.NewSystem(p.physicsWorld)
+	p.cloth = softbody.NewCloth(mgl32.Vec3{-2, 4, -3}, mgl32.Vec3{4, 0, 0}, mgl32.Vec3{0, 0, 3}, 25, 33, 1)
+	p.cloth.Pin(0)
+	p.cloth.Pin(32)
+	p.softBodies.AddBody(p.cloth)
+	p.createCloth()
+
 	fmt.Println("\n=== Управление ===")
 	fmt.Println("WASD - Движение камеры")
 	fmt.Println("Мышь - Обзор")
@@ -281,6 +302,16 @@ func (p *PhysicsTest) onInit(engine *core.Engine) error {
 	return nil
 }
 
+// loadModel загружает модель через менеджер ресурсов. Ссылка держится
+// до конца работы программы
+func (p *PhysicsTest) loadModel(path string) (*model.Model, error) {
+	handle, err := resource.Load[*model.Model](p.engine.GetResourceManager(), path, resource.ResourceTypeMesh)
+	if err != nil {
+		return nil, err
+	}
+	return handle.Get()
+}
+
 func (p *PhysicsTest) onUpdate(engine *core.Engine, dt float32) {
 	inputMgr := engine.GetInputManager()
 
@@ -377,27 +408,23 @@ func (p *PhysicsTest) onUpdate(engine *core.Engine, dt float32) {
 
 	// Сброс всех объектов
 	if inputMgr.IsKeyJustPressed(input.KeyR) {
-		// Считаем сколько объектов удаляем
+		// Удаляем через RemoveBody: мир будит касавшиеся тела и обновляет широкую фазу.
+		// Обходим копию, потому что RemoveBody сдвигает Bodies
 		count := 0
-		newBodies := make([]*physics.RigidBody, 0)
-		for _, body := range p.physicsWorld.Bodies {
-			if body.Type == physics.Static {
-				newBodies = append(newBodies, body)
-			} else {
+		for _, body := range append([]*physics.RigidBody(nil), p.physicsWorld.Bodies...) {
+			if body.Type != physics.Static {
+				p.physicsWorld.RemoveBody(body)
 				count++
 			}
 		}
-		p.physicsWorld.Bodies = newBodies
 		if count > 0 {
 			fmt.Printf("🗑️  Удалено объектов: %d\n", count)
 		}
 	}
 
-	// Обновляем физику
+	// Обновляем физику вместе с жидкостью, затем ткань по новым положениям тел
 	p.physicsWorld.Step(dt)
-
-	// Обновляем жидкость
-	p.fluidSystem.Update(dt)
+	p.softBodies.Step(dt)
 }
 
 func (p *PhysicsTest) spawnObject() {
@@ -423,22 +450,21 @@ func (p *PhysicsTest) spawnObject() {
 		body.Name = "Capsule"
 		nameRu = "КАПСУЛА"
 	case physics.LiquidShape:
-		// Для жидкости создаём 100 частиц за раз
 		nameRu = "ЖИДКОСТЬ"
 		spawnPos := p.camera.Position.Add(p.camera.Front.Mul(2))
 
-		// Создаём 10 частиц с небольшим разбросом
+		// Бросаем кубик 3×3×3 частиц с шагом в половину радиуса сглаживания
+		spacing := p.fluidSystem.SmoothingRadius / 2
 		particleCount := 0
-		for i := 0; i < 10; i++ {
-			// Небольшой случайный разброс для естественности
-			randomOffset := mgl32.Vec3{
-				(rand.Float32() - 0.5) * 0.2,
-				(rand.Float32() - 0.5) * 0.2,
-				(rand.Float32() - 0.5) * 0.2,
+		for x := -1; x <= 1; x++ {
+			for y := -1; y <= 1; y++ {
+				for z := -1; z <= 1; z++ {
+					offset := mgl32.Vec3{float32(x), float32(y), float32(z)}.Mul(spacing)
+					particle := p.fluidSystem.AddParticle(spawnPos.Add(offset))
+					particle.Velocity = p.camera.Front.Mul(3)
+					particleCount++
+				}
 			}
-			particle := p.fluidSystem.AddParticle(spawnPos.Add(randomOffset))
-			particle.Velocity = p.camera.Front.Mul(0.0005) // В 100 раз медленнее (почти стоит)
-			particleCount++
 		}
 
 		fmt.Printf("💧 Создано частиц: %d (всего: %d)\n", particleCount, len(p.fluidSystem.Particles))
@@ -578,6 +604,18 @@ func (p *PhysicsTest) onRender(engine *core.Engine) {
 
 	gl.BindVertexArray(0)
 
+	// Рисуем ткань: вершины каждый кадр заново загружаются в буфер
+	p.clothVertices = p.clothVertices[:0]
+	for _, v := range p.cloth.Positions {
+		p.clothVertices = append(p.clothVertices, v.X(), v.Y(), v.Z(), 0.8, 0.2, 0.3)
+	}
+	p.shader.SetMat4("uModel", mgl32.Ident4())
+	gl.BindVertexArray(p.clothVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.clothVBO)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(p.clothVertices)*4, gl.Ptr(p.clothVertices))
+	gl.DrawElements(gl.TRIANGLES, int32(len(p.cloth.Indices)), gl.UNSIGNED_INT, gl.PtrOffset(0))
+	gl.BindVertexArray(0)
+
 	// ===== РЕНДЕРИМ ТЕНИ =====
 	// Собираем активные источники света для теней
 	var lightSources []mgl32.Vec3
@@ -878,6 +916,26 @@ func (p *PhysicsTest) createPlane() {
 
 	gl.BindVertexArray(0)
 }
+// createCloth создает буферы ткани: индексы постоянны, вершины обновляются каждый кадр
+func (p *PhysicsTest) createCloth() {
+	gl.GenVertexArrays(1, &p.clothVAO)
+	gl.GenBuffers(1, &p.clothVBO)
+	gl.GenBuffers(1, &p.clothEBO)
+
+	gl.BindVertexArray(p.clothVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.clothVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, len(p.cloth.Positions)*6*4, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, p.clothEBO)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(p.cloth.Indices)*4, gl.Ptr(p.cloth.Indices), gl.STATIC_DRAW)
+
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 6*4, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 6*4, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(1)
+
+	gl.BindVertexArray(0)
+}
+
 func (p *PhysicsTest) createLiquid() {
 	// Жидкость с голубым цветом (cyan/aqua)
 	vertices := []float32{
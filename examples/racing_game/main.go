@@ -5,14 +5,16 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"os"
 	"runtime"
 	"strings"
 
 	"github.com/Salamander5876/AnimoEngine/pkg/core"
+	"github.com/Salamander5876/AnimoEngine/pkg/core/resource"
 	"github.com/Salamander5876/AnimoEngine/pkg/graphics"
 	"github.com/Salamander5876/AnimoEngine/pkg/graphics/shader"
+	"github.com/Salamander5876/AnimoEngine/pkg/graphics/texture"
 	"github.com/Salamander5876/AnimoEngine/pkg/graphics/ui"
+	"github.com/Salamander5876/AnimoEngine/pkg/physics"
 	"github.com/Salamander5876/AnimoEngine/pkg/platform/input"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
@@ -22,16 +24,16 @@ func init() {
 	runtime.LockOSThread()
 }
 
-// Константы физики
+// Константы физики: машины - physics.Vehicle на плоскости мира,
+// экранные X и Y карты соответствуют мировым X и Z
 const (
-	MaxSpeed        = 12.0
-	Acceleration    = 0.15
-	Deceleration    = 0.015
-	RotationSpeed   = 2.5
-	ReverseSpeedMul = 0.5
-	GrassMaxSpeed   = 5.0
-	GrassDecel      = 3.0
-	CollisionTransfer = 0.7
+	PixelsPerMeter    = 10.0 // Машина 20x40 пикселей - 2x4 метра
+	MaxSpeed          = 40.0 // м/с, для полоски скорости
+	CarMass           = 1200.0
+	WallHeight        = 2.0
+	GrassFriction     = 0.5   // Сцепление шин на траве
+	AsphaltResistance = 0.015 // Сопротивление качению на асфальте
+	GrassResistance   = 0.15  // и на траве
 )
 
 // Тип тайла
@@ -59,13 +61,12 @@ type Car struct {
 	x, y          float32
 	angle         float32
 	speed         float32
-	maxSpeed      float32
+	vehicle       *physics.Vehicle
 	texture       *graphics.Texture
 	laps          int
 	lastLapTime   float64
 	controlType   ControlType
 	playerID      int
-	collisionBox  [4]mgl32.Vec2 // 4 точки для коллизии
 }
 
 // Map игровая карта
@@ -103,6 +104,9 @@ type RacingGame struct {
 	// Карта
 	gameMap     *Map
 
+	// Физика
+	world       *physics.PhysicsWorld
+
 	// Геометрия
 	quadVAO     uint32
 	quadVBO     uint32
@@ -114,6 +118,9 @@ type RacingGame struct {
 
 	// Время
 	gameTime    float64
+
+	// Ссылки на текстуры в менеджере ресурсов
+	textures    []resource.Handle[uint32]
 }
 
 func main() {
@@ -194,8 +201,15 @@ void main() {
 	// Создаем quad для отрисовки спрайтов
 	g.createQuad()
 
+	// Ассеты игры доступны менеджеру ресурсов под виртуальным путем race/
+	rm := engine.GetResourceManager()
+	rm.RegisterLoader(texture.Loader{})
+	if err := rm.Mount("race", resource.NewDirFS("otherGame/race/src"), 0); err != nil {
+		return err
+	}
+
 	// Загружаем карту
-	err = g.loadMap("otherGame/race/src/maps/map1.txt")
+	err = g.loadMap("race/maps/map1.txt")
 	if err != nil {
 		return fmt.Errorf("failed to load map: %v", err)
 	}
@@ -240,8 +254,24 @@ func (g *RacingGame) createQuad() {
 	gl.BindVertexArray(0)
 }
 
+// loadTexture загружает текстуру через менеджер ресурсов. Повторная загрузка
+// того же пути берет текстуру из кеша; ссылки освобождаются в onShutdown
+func (g *RacingGame) loadTexture(path string) (*graphics.Texture, error) {
+	handle, err := resource.Load[uint32](g.engine.GetResourceManager(), path, resource.ResourceTypeTexture)
+	if err != nil {
+		return nil, err
+	}
+	id, err := handle.Get()
+	if err != nil {
+		handle.Release()
+		return nil, err
+	}
+	g.textures = append(g.textures, handle)
+	return &graphics.Texture{ID: id, Path: path}, nil
+}
+
 func (g *RacingGame) loadMap(filename string) error {
-	file, err := os.Open(filename)
+	file, err := g.engine.GetResourceManager().GetVFS().Open(filename)
 	if err != nil {
 		return err
 	}
@@ -252,26 +282,26 @@ func (g *RacingGame) loadMap(filename string) error {
 	}
 
 	// Загружаем текстуры тайлов
-	asphaltTex, err := graphics.LoadTexture("otherGame/race/src/maps/asphalt.png")
+	asphaltTex, err := g.loadTexture("race/maps/asphalt.png")
 	if err != nil {
 		return err
 	}
 	g.gameMap.textures[TileAsphalt] = asphaltTex
 	g.gameMap.textures[TileSpawn] = asphaltTex // Spawn использует асфальт
 
-	grassTex, err := graphics.LoadTexture("otherGame/race/src/maps/grass.png")
+	grassTex, err := g.loadTexture("race/maps/grass.png")
 	if err != nil {
 		return err
 	}
 	g.gameMap.textures[TileGrass] = grassTex
 
-	wallTex, err := graphics.LoadTexture("otherGame/race/src/maps/wall.png")
+	wallTex, err := g.loadTexture("race/maps/wall.png")
 	if err != nil {
 		return err
 	}
 	g.gameMap.textures[TileWall] = wallTex
 
-	finishTex, err := graphics.LoadTexture("otherGame/race/src/maps/finish.png")
+	finishTex, err := g.loadTexture("race/maps/finish.png")
 	if err != nil {
 		return err
 	}
@@ -335,6 +365,7 @@ func (g *RacingGame) startGame() {
 	g.state = StateGame
 	g.gameTime = 0
 	g.cars = make([]*Car, 0)
+	g.createWorld()
 
 	// Находим spawn точки
 	spawnPoints := make([]mgl32.Vec2, 0)
@@ -348,13 +379,13 @@ func (g *RacingGame) startGame() {
 
 	// Создаем машины для игроков
 	carTextures := []string{
-		"otherGame/race/src/cars/porshe.png",
-		"otherGame/race/src/cars/green.png",
-		"otherGame/race/src/cars/Huracan.png",
+		"race/cars/porshe.png",
+		"race/cars/green.png",
+		"race/cars/Huracan.png",
 	}
 
 	for i := 0; i < g.numPlayers && i < len(spawnPoints); i++ {
-		texture, err := graphics.LoadTexture(carTextures[i%len(carTextures)])
+		texture, err := g.loadTexture(carTextures[i%len(carTextures)])
 		if err != nil {
 			log.Printf("Failed to load car texture: %v", err)
 			continue
@@ -366,18 +397,71 @@ func (g *RacingGame) startGame() {
 			y:           (spawn.Y() + 0.5) * g.gameMap.tileSize,
 			angle:       0,
 			speed:       0,
-			maxSpeed:    MaxSpeed,
 			texture:     texture,
 			laps:        0,
 			controlType: ControlType(i),
 			playerID:    i + 1,
 		}
+		car.vehicle = g.createVehicle(car.x/PixelsPerMeter, car.y/PixelsPerMeter, car.angle)
 		g.cars = append(g.cars, car)
 	}
 
 	fmt.Printf("Game started with %d players, racing to %d laps!\n", g.numPlayers, g.lapsToWin)
 }
 
+// createWorld создает физический мир: землю и статические блоки на месте стен и вокруг карты
+func (g *RacingGame) createWorld() {
+	g.world = physics.NewPhysicsWorld()
+	g.world.AddBody(physics.NewPlaneBody(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0}))
+
+	tile := g.gameMap.tileSize / PixelsPerMeter
+	addWall := func(x, z, width, depth float32) {
+		wall := physics.NewRigidBody(physics.Static, physics.BoxShape)
+		wall.Position = mgl32.Vec3{x, WallHeight / 2, z}
+		wall.Dimensions = mgl32.Vec3{width, WallHeight, depth}
+		wall.Restitution = 0.3
+		g.world.AddBody(wall)
+	}
+
+	for y := 0; y < g.gameMap.height; y++ {
+		for x := 0; x < g.gameMap.width; x++ {
+			if g.gameMap.tiles[y][x] == TileWall {
+				addWall((float32(x)+0.5)*tile, (float32(y)+0.5)*tile, tile, tile)
+			}
+		}
+	}
+
+	// Границы карты
+	width := float32(g.gameMap.width) * tile
+	height := float32(g.gameMap.height) * tile
+	addWall(width/2, -tile/2, width+2*tile, tile)
+	addWall(width/2, height+tile/2, width+2*tile, tile)
+	addWall(-tile/2, height/2, tile, height)
+	addWall(width+tile/2, height/2, tile, height)
+}
+
+// createVehicle создает машину с задним приводом в точке (x, z) мира.
+// angle - направление в градусах, как у Car
+func (g *RacingGame) createVehicle(x, z, angle float32) *physics.Vehicle {
+	chassis := physics.NewRigidBody(physics.Dynamic, physics.BoxShape)
+	chassis.Dimensions = mgl32.Vec3{2, 0.5, 4}
+	chassis.Mass = CarMass
+	chassis.Position = mgl32.Vec3{x, 0.8, z}
+	// Вперед у машины локальная -Z: поворачиваем ее к направлению (cos, sin) в плоскости XZ
+	chassis.Rotation = mgl32.QuatRotate(-mgl32.DegToRad(angle+90), mgl32.Vec3{0, 1, 0})
+	g.world.AddBody(chassis)
+
+	vehicle := physics.NewVehicle(chassis)
+	for _, position := range []mgl32.Vec3{{-0.85, -0.2, -1.3}, {0.85, -0.2, -1.3}, {-0.85, -0.2, 1.3}, {0.85, -0.2, 1.3}} {
+		wheel := vehicle.AddWheel(physics.NewWheel(position, 0.33))
+		front := position.Z() < 0
+		wheel.Steer = front
+		wheel.Drive = !front
+		wheel.Handbrake = !front
+	}
+	return g.world.AddVehicle(vehicle)
+}
+
 func (g *RacingGame) onUpdate(engine *core.Engine, dt float32) {
 	inputMgr := engine.GetInputManager()
 	g.gameTime += float64(dt)
@@ -397,16 +481,15 @@ func (g *RacingGame) onUpdate(engine *core.Engine, dt float32) {
 		}
 
 	case StateGame:
-		// Обновляем все машины
+		// Управление машинами, затем шаг физики: столкновения со стенами
+		// и между машинами решает физический мир
 		for _, car := range g.cars {
-			g.updateCar(car, dt, inputMgr)
+			g.updateCar(car, inputMgr)
 		}
-
-		// Проверка коллизий между машинами
-		for i := 0; i < len(g.cars); i++ {
-			for j := i + 1; j < len(g.cars); j++ {
-				g.checkCarCollision(g.cars[i], g.cars[j])
-			}
+		g.world.Step(dt)
+		for _, car := range g.cars {
+			g.syncCar(car)
+			g.checkSurface(car)
 		}
 
 		// Проверка победы
@@ -436,9 +519,9 @@ func (g *RacingGame) onUpdate(engine *core.Engine, dt float32) {
 	}
 }
 
-func (g *RacingGame) updateCar(car *Car, dt float32, inputMgr *input.InputManager) {
+func (g *RacingGame) updateCar(car *Car, inputMgr *input.InputManager) {
 	// Получаем input в зависимости от типа управления
-	forward, backward, left, right, reset := g.getInput(car.controlType, inputMgr)
+	forward, backward, left, right, handbrake, reset := g.getInput(car.controlType, inputMgr)
 
 	// Сброс позиции
 	if reset {
@@ -446,154 +529,88 @@ func (g *RacingGame) updateCar(car *Car, dt float32, inputMgr *input.InputManage
 		// TODO: implement
 	}
 
-	// Ускорение/торможение
+	// Педали и руль машины; автомат сам включает заднюю передачу на месте
+	vehicle := car.vehicle
+	vehicle.Throttle = 0
+	vehicle.Brake = 0
+	vehicle.Steering = 0
 	if forward {
-		car.speed += Acceleration
-		if car.speed > car.maxSpeed {
-			car.speed = car.maxSpeed
-		}
-	} else if backward {
-		car.speed -= Acceleration
-		if car.speed < -car.maxSpeed*ReverseSpeedMul {
-			car.speed = -car.maxSpeed * ReverseSpeedMul
-		}
-	} else {
-		// Естественное замедление
-		if car.speed > 0 {
-			car.speed -= Deceleration
-			if car.speed < 0 {
-				car.speed = 0
-			}
-		} else if car.speed < 0 {
-			car.speed += Deceleration
-			if car.speed > 0 {
-				car.speed = 0
-			}
-		}
+		vehicle.Throttle = 1
 	}
-
-	// Поворот
-	if left && car.speed != 0 {
-		car.angle -= RotationSpeed * float32(math.Abs(float64(car.speed))/MaxSpeed)
+	if backward {
+		vehicle.Brake = 1
 	}
-	if right && car.speed != 0 {
-		car.angle += RotationSpeed * float32(math.Abs(float64(car.speed))/MaxSpeed)
+	if left {
+		vehicle.Steering--
 	}
+	if right {
+		vehicle.Steering++
+	}
+	vehicle.Handbrake = handbrake
+}
 
-	// Движение
-	angleRad := car.angle * math.Pi / 180.0
-	car.x += float32(math.Cos(float64(angleRad))) * car.speed * dt * 60
-	car.y += float32(math.Sin(float64(angleRad))) * car.speed * dt * 60
-
-	// Проверка коллизии с картой
-	g.checkMapCollision(car)
-
-	// Обновление collision box
-	g.updateCollisionBox(car)
+// syncCar переносит положение кузова в экранные координаты машины
+func (g *RacingGame) syncCar(car *Car) {
+	position := car.vehicle.Chassis.Position
+	car.x = position.X() * PixelsPerMeter
+	car.y = position.Z() * PixelsPerMeter
+	car.angle = mgl32.RadToDeg(car.vehicle.GetHeading())
+	car.speed = car.vehicle.GetSpeed()
 }
 
-func (g *RacingGame) getInput(controlType ControlType, inputMgr *input.InputManager) (forward, backward, left, right, reset bool) {
+func (g *RacingGame) getInput(controlType ControlType, inputMgr *input.InputManager) (forward, backward, left, right, handbrake, reset bool) {
 	switch controlType {
 	case ControlWASD:
 		return inputMgr.IsKeyPressed(input.KeyW),
 			inputMgr.IsKeyPressed(input.KeyS),
 			inputMgr.IsKeyPressed(input.KeyA),
 			inputMgr.IsKeyPressed(input.KeyD),
+			inputMgr.IsKeyPressed(input.KeySpace),
 			inputMgr.IsKeyPressed(input.KeyR)
 	case ControlArrows:
 		return inputMgr.IsKeyPressed(input.KeyUp),
 			inputMgr.IsKeyPressed(input.KeyDown),
 			inputMgr.IsKeyPressed(input.KeyLeft),
 			inputMgr.IsKeyPressed(input.KeyRight),
+			inputMgr.IsKeyPressed(input.KeyLeftControl),
 			inputMgr.IsKeyPressed(input.KeyLeftShift)
 	}
-	return false, false, false, false, false
+	return false, false, false, false, false, false
 }
 
-func (g *RacingGame) checkMapCollision(car *Car) {
-	// Получаем тайл под машиной
-	tileX := int(car.x / g.gameMap.tileSize)
-	tileY := int(car.y / g.gameMap.tileSize)
+// tileAt возвращает тайл под точкой мира; за картой - стена
+func (g *RacingGame) tileAt(position mgl32.Vec3) TileType {
+	tileX := int(position.X() * PixelsPerMeter / g.gameMap.tileSize)
+	tileY := int(position.Z() * PixelsPerMeter / g.gameMap.tileSize)
 
-	if tileX < 0 || tileX >= g.gameMap.width || tileY < 0 || tileY >= g.gameMap.height {
-		// За границами карты - отталкиваем назад
-		car.speed *= -0.5
-		return
+	if position.X() < 0 || position.Z() < 0 || tileX >= g.gameMap.width || tileY >= g.gameMap.height {
+		return TileWall
 	}
+	return g.gameMap.tiles[tileY][tileX]
+}
 
-	tile := g.gameMap.tiles[tileY][tileX]
-
-	switch tile {
-	case TileWall:
-		// Стена - отскок
-		car.speed *= -0.5
-		angleRad := car.angle * math.Pi / 180.0
-		car.x -= float32(math.Cos(float64(angleRad))) * 5
-		car.y -= float32(math.Sin(float64(angleRad))) * 5
-
-	case TileGrass:
-		// Трава - замедление
-		car.maxSpeed = GrassMaxSpeed
-		if car.speed > 0 {
-			car.speed -= Deceleration * GrassDecel
+func (g *RacingGame) checkSurface(car *Car) {
+	// Сцепление каждого колеса по тайлу под ним
+	onGrass := 0
+	for _, wheel := range car.vehicle.Wheels {
+		wheel.Friction = 1
+		if wheel.Grounded && g.tileAt(wheel.ContactPoint) == TileGrass {
+			wheel.Friction = GrassFriction
+			onGrass++
 		}
+	}
 
-	case TileAsphalt, TileSpawn:
-		// Асфальт - нормальная скорость
-		car.maxSpeed = MaxSpeed
+	// Трава - замедление
+	share := float32(onGrass) / float32(len(car.vehicle.Wheels))
+	car.vehicle.RollingResistance = AsphaltResistance + (GrassResistance-AsphaltResistance)*share
 
-	case TileFinish:
-		// Финишная линия - засчитываем круг
+	// Финишная линия - засчитываем круг
+	if g.tileAt(car.vehicle.Chassis.Position) == TileFinish {
 		if g.gameTime-car.lastLapTime > 3.0 { // 3 секунды кулдаун
 			car.laps++
 			car.lastLapTime = g.gameTime
 			fmt.Printf("Player %d completed lap %d/%d\n", car.playerID, car.laps, g.lapsToWin)
 		}
-		car.maxSpeed = MaxSpeed
-	}
-}
-
-func (g *RacingGame) updateCollisionBox(car *Car) {
-	// 4 точки вокруг машины (20x40 пикселей)
-	w := float32(10.0)
-	h := float32(20.0)
-
-	angleRad := float64(car.angle * math.Pi / 180.0)
-	cos := float32(math.Cos(angleRad))
-	sin := float32(math.Sin(angleRad))
-
-	// Поворачиваем точки
-	points := []mgl32.Vec2{
-		{-w, -h}, {w, -h}, {w, h}, {-w, h},
-	}
-
-	for i, p := range points {
-		rx := p.X()*cos - p.Y()*sin
-		ry := p.X()*sin + p.Y()*cos
-		car.collisionBox[i] = mgl32.Vec2{car.x + rx, car.y + ry}
-	}
-}
-
-func (g *RacingGame) checkCarCollision(car1, car2 *Car) {
-	// Простая дистанционная коллизия
-	dx := car1.x - car2.x
-	dy := car1.y - car2.y
-	dist := float32(math.Sqrt(float64(dx*dx + dy*dy)))
-
-	if dist < 25.0 { // Радиус коллизии
-		// Отталкивание
-		angle := float32(math.Atan2(float64(dy), float64(dx)))
-		overlap := 25.0 - dist
-
-		car1.x += float32(math.Cos(float64(angle))) * overlap * 0.5
-		car1.y += float32(math.Sin(float64(angle))) * overlap * 0.5
-		car2.x -= float32(math.Cos(float64(angle))) * overlap * 0.5
-		car2.y -= float32(math.Sin(float64(angle))) * overlap * 0.5
-
-		// Передача скорости
-		speedDiff := car1.speed - car2.speed
-		car1.speed -= speedDiff * CollisionTransfer
-		car2.speed += speedDiff * CollisionTransfer
 	}
 }
 
@@ -728,4 +745,10 @@ func (g *RacingGame) onShutdown(engine *core.Engine) {
 	}
 	gl.DeleteVertexArrays(1, &g.quadVAO)
 	gl.DeleteBuffers(1, &g.quadVBO)
+
+	// Освобождаем текстуры; GL-выгрузка выполняется в ProcessUploads
+	for _, handle := range g.textures {
+		handle.Release()
+	}
+	engine.GetResourceManager().ProcessUploads(0)
 }
@@ -0,0 +1,278 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Ошибки конфигурации
+var (
+	ErrInvalidConfig = errors.New("invalid config")
+)
+
+// EnvPrefix префикс переменных окружения по умолчанию
+const EnvPrefix = "ANIMO_"
+
+// WindowSettings настройки окна
+type WindowSettings struct {
+	Title      string `json:"title"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Fullscreen bool   `json:"fullscreen"`
+	VSync      bool   `json:"vsync"`
+	Resizable  bool   `json:"resizable"`
+	MSAA       int    `json:"msaa"`
+}
+
+// EngineSettings настройки движка
+type EngineSettings struct {
+	TargetFPS      int `json:"target_fps"`
+	CacheSizeMB    int `json:"cache_size_mb"`
+	LoadWorkers    int `json:"load_workers"`
+	UploadBudgetMS int `json:"upload_budget_ms"`
+}
+
+// Config конфигурация движка из файла, окружения и командной строки
+type Config struct {
+	Window WindowSettings `json:"window"`
+	Engine EngineSettings `json:"engine"`
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() *Config {
+	return &Config{
+		Window: WindowSettings{
+			Title:     "AnimoEngine",
+			Width:     1280,
+			Height:    720,
+			VSync:     true,
+			Resizable: true,
+			MSAA:      4,
+		},
+		Engine: EngineSettings{
+			TargetFPS:      60,
+			CacheSizeMB:    512,
+			LoadWorkers:    4,
+			UploadBudgetMS: 4,
+		},
+	}
+}
+
+// option параметр конфигурации, доступный из окружения и командной строки
+type option struct {
+	key   string // Ключ в файле
+	env   string // Имя переменной окружения без префикса
+	flag  string // Имя флага
+	usage string
+	value func(c *Config) interface{} // Указатель на поле
+}
+
+// options все параметры, которые можно переопределить извне
+var options = []option{
+	{"window.title", "TITLE", "title", "window title", func(c *Config) interface{} { return &c.Window.Title }},
+	{"window.width", "WIDTH", "width", "window width in pixels", func(c *Config) interface{} { return &c.Window.Width }},
+	{"window.height", "HEIGHT", "height", "window height in pixels", func(c *Config) interface{} { return &c.Window.Height }},
+	{"window.fullscreen", "FULLSCREEN", "fullscreen", "fullscreen mode", func(c *Config) interface{} { return &c.Window.Fullscreen }},
+	{"window.vsync", "VSYNC", "vsync", "vertical sync", func(c *Config) interface{} { return &c.Window.VSync }},
+	{"window.resizable", "RESIZABLE", "resizable", "resizable window", func(c *Config) interface{} { return &c.Window.Resizable }},
+	{"window.msaa", "MSAA", "msaa", "MSAA samples (0 = off)", func(c *Config) interface{} { return &c.Window.MSAA }},
+	{"engine.target_fps", "TARGET_FPS", "fps", "target frames per second", func(c *Config) interface{} { return &c.Engine.TargetFPS }},
+	{"engine.cache_size_mb", "CACHE_SIZE_MB", "cache-size", "resource cache size in MB (0 = unlimited)", func(c *Config) interface{} { return &c.Engine.CacheSizeMB }},
+	{"engine.load_workers", "LOAD_WORKERS", "load-workers", "resource loading workers", func(c *Config) interface{} { return &c.Engine.LoadWorkers }},
+	{"engine.upload_budget_ms", "UPLOAD_BUDGET_MS", "upload-budget", "GPU upload budget per frame in ms", func(c *Config) interface{} { return &c.Engine.UploadBudgetMS }},
+}
+
+// LoadFile загружает конфигурацию из JSON-файла поверх текущих значений.
+// Отсутствующие в файле поля не меняются
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+	return nil
+}
+
+// SaveFile сохраняет конфигурацию в JSON-файл
+func (c *Config) SaveFile(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config %s: %w", path, err)
+	}
+	return nil
+}
+
+// ApplyEnv переопределяет значения из переменных окружения
+// (например, ANIMO_WIDTH=1920 при префиксе "ANIMO_")
+func (c *Config) ApplyEnv(prefix string) error {
+	for _, opt := range options {
+		name := prefix + opt.env
+		raw, exists := os.LookupEnv(name)
+		if !exists {
+			continue
+		}
+		if err := setValue(opt.value(c), raw); err != nil {
+			return fmt.Errorf("%w: %s (%s=%q): %v", ErrInvalidConfig, opt.key, name, raw, err)
+		}
+	}
+	return nil
+}
+
+// BindFlags регистрирует флаги командной строки. Значения по умолчанию берутся
+// из текущей конфигурации, поэтому незаданные флаги ничего не переопределяют
+func (c *Config) BindFlags(fs *flag.FlagSet) {
+	for _, opt := range options {
+		switch v := opt.value(c).(type) {
+		case *string:
+			fs.StringVar(v, opt.flag, *v, opt.usage)
+		case *int:
+			fs.IntVar(v, opt.flag, *v, opt.usage)
+		case *bool:
+			fs.BoolVar(v, opt.flag, *v, opt.usage)
+		}
+	}
+}
+
+// ApplyFlags разбирает аргументы командной строки и переопределяет значения.
+// Флаги, не относящиеся к конфигурации (например, флаги самой игры), и
+// позиционные аргументы пропускаются. Чтобы разбирать флаги игры вместе с
+// флагами движка, зарегистрируйте их в своем FlagSet через BindFlags
+func (c *Config) ApplyFlags(args []string) error {
+	fs := flag.NewFlagSet("animo", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c.BindFlags(fs)
+	if err := fs.Parse(knownFlags(fs, args)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return nil
+}
+
+// knownFlags оставляет в args только флаги, зарегистрированные в fs, вместе
+// с их значениями. Разбор останавливается на "--"
+func knownFlags(fs *flag.FlagSet, args []string) []string {
+	known := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+
+		name := strings.TrimPrefix(arg[1:], "-")
+		inline := false
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			name, inline = name[:eq], true
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+
+		known = append(known, arg)
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); inline || (ok && bf.IsBoolFlag()) {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			known = append(known, args[i])
+		}
+	}
+	return known
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, &FieldError{Key: key, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	check(c.Window.Width > 0, "window.width", "must be positive, got %d", c.Window.Width)
+	check(c.Window.Height > 0, "window.height", "must be positive, got %d", c.Window.Height)
+	check(c.Window.MSAA >= 0 && c.Window.MSAA <= 16, "window.msaa", "must be between 0 and 16, got %d", c.Window.MSAA)
+	check(c.Engine.TargetFPS > 0 && c.Engine.TargetFPS <= 1000, "engine.target_fps", "must be between 1 and 1000, got %d", c.Engine.TargetFPS)
+	check(c.Engine.CacheSizeMB >= 0, "engine.cache_size_mb", "must not be negative, got %d", c.Engine.CacheSizeMB)
+	check(c.Engine.LoadWorkers > 0, "engine.load_workers", "must be positive, got %d", c.Engine.LoadWorkers)
+	check(c.Engine.UploadBudgetMS >= 0, "engine.upload_budget_ms", "must not be negative, got %d", c.Engine.UploadBudgetMS)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+}
+
+// FieldError ошибка значения параметра конфигурации
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+// Error реализует интерфейс error
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// Load собирает конфигурацию: значения по умолчанию, затем файл (если path
+// не пустой и файл существует), переменные окружения и флаги командной строки.
+// Результат проверяется через Validate
+func Load(path string, args []string) (*Config, error) {
+	c := Default()
+
+	if path != "" {
+		if err := c.LoadFile(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err := c.ApplyEnv(EnvPrefix); err != nil {
+		return nil, err
+	}
+	if err := c.ApplyFlags(args); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// setValue записывает строковое значение в поле нужного типа
+func setValue(ptr interface{}, raw string) error {
+	switch v := ptr.(type) {
+	case *string:
+		*v = raw
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected integer")
+		}
+		*v = n
+	case *bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected boolean")
+		}
+		*v = b
+	default:
+		return fmt.Errorf("unsupported type %T", ptr)
+	}
+	return nil
+}
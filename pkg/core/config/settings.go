@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Ошибки хранилища настроек
+var (
+	ErrSettingNotFound = errors.New("setting not found")
+	ErrSettingType     = errors.New("setting has different type")
+)
+
+// Settings типизированное хранилище пользовательских настроек
+// (графика, звук, управление), которое игра может сохранять между запусками
+type Settings struct {
+	path   string
+	values map[string]interface{}
+	dirty  bool
+	mu     sync.RWMutex
+}
+
+// NewSettings создает пустое хранилище. path - файл для Load/Save ("" - только в памяти)
+func NewSettings(path string) *Settings {
+	return &Settings{
+		path:   path,
+		values: make(map[string]interface{}),
+	}
+}
+
+// Path возвращает путь к файлу настроек
+func (s *Settings) Path() string {
+	return s.path
+}
+
+// Load загружает настройки из файла. Отсутствие файла не считается ошибкой
+func (s *Settings) Load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read settings %s: %w", s.path, err)
+	}
+
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to decode settings %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = values
+	s.dirty = false
+	return nil
+}
+
+// Save сохраняет настройки в файл, если они менялись
+func (s *Settings) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create settings directory: %w", err)
+		}
+	}
+
+	// Пишем через временный файл, чтобы не потерять настройки при сбое
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write settings %s: %w", s.path, err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// set записывает значение
+func (s *Settings) set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.dirty = true
+}
+
+// get возвращает значение
+func (s *Settings) get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, exists := s.values[key]
+	return value, exists
+}
+
+// SetString устанавливает строковую настройку
+func (s *Settings) SetString(key, value string) {
+	s.set(key, value)
+}
+
+// SetInt устанавливает целочисленную настройку
+func (s *Settings) SetInt(key string, value int) {
+	s.set(key, float64(value))
+}
+
+// SetFloat устанавливает вещественную настройку
+func (s *Settings) SetFloat(key string, value float64) {
+	s.set(key, value)
+}
+
+// SetBool устанавливает логическую настройку
+func (s *Settings) SetBool(key string, value bool) {
+	s.set(key, value)
+}
+
+// String возвращает строковую настройку
+func (s *Settings) String(key string) (string, error) {
+	value, exists := s.get(key)
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrSettingNotFound, key)
+	}
+	v, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s is %T, not string", ErrSettingType, key, value)
+	}
+	return v, nil
+}
+
+// Int возвращает целочисленную настройку
+func (s *Settings) Int(key string) (int, error) {
+	value, exists := s.get(key)
+	if !exists {
+		return 0, fmt.Errorf("%w: %s", ErrSettingNotFound, key)
+	}
+	// JSON хранит все числа как float64
+	v, ok := value.(float64)
+	if !ok || v != float64(int(v)) {
+		return 0, fmt.Errorf("%w: %s is %v, not int", ErrSettingType, key, value)
+	}
+	return int(v), nil
+}
+
+// Float возвращает вещественную настройку
+func (s *Settings) Float(key string) (float64, error) {
+	value, exists := s.get(key)
+	if !exists {
+		return 0, fmt.Errorf("%w: %s", ErrSettingNotFound, key)
+	}
+	v, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s is %T, not float", ErrSettingType, key, value)
+	}
+	return v, nil
+}
+
+// Bool возвращает логическую настройку
+func (s *Settings) Bool(key string) (bool, error) {
+	value, exists := s.get(key)
+	if !exists {
+		return false, fmt.Errorf("%w: %s", ErrSettingNotFound, key)
+	}
+	v, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: %s is %T, not bool", ErrSettingType, key, value)
+	}
+	return v, nil
+}
+
+// GetString возвращает строковую настройку или значение по умолчанию
+func (s *Settings) GetString(key, def string) string {
+	if v, err := s.String(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetInt возвращает целочисленную настройку или значение по умолчанию
+func (s *Settings) GetInt(key string, def int) int {
+	if v, err := s.Int(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetFloat возвращает вещественную настройку или значение по умолчанию
+func (s *Settings) GetFloat(key string, def float64) float64 {
+	if v, err := s.Float(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetBool возвращает логическую настройку или значение по умолчанию
+func (s *Settings) GetBool(key string, def bool) bool {
+	if v, err := s.Bool(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// Has проверяет наличие настройки
+func (s *Settings) Has(key string) bool {
+	_, exists := s.get(key)
+	return exists
+}
+
+// Delete удаляет настройку
+func (s *Settings) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.values[key]; exists {
+		delete(s.values, key)
+		s.dirty = true
+	}
+}
+
+// Keys возвращает отсортированный список ключей
+func (s *Settings) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
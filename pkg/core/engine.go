@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Salamander5876/AnimoEngine/pkg/core/config"
 	"github.com/Salamander5876/AnimoEngine/pkg/core/ecs"
 	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
 	"github.com/Salamander5876/AnimoEngine/pkg/core/resource"
@@ -17,6 +18,8 @@ type EngineConfig struct {
 	TargetFPS           int
 	MaxResourceCacheSize int64
 	LoadWorkers         int
+	UploadBudget        time.Duration // Время на загрузку ресурсов в GPU за кадр
+	SettingsPath        string        // Файл пользовательских настроек ("" - не сохранять)
 }
 
 // DefaultEngineConfig возвращает конфигурацию движка по умолчанию
@@ -26,9 +29,41 @@ func DefaultEngineConfig() EngineConfig {
 		TargetFPS:           60,
 		MaxResourceCacheSize: 512 * 1024 * 1024, // 512MB
 		LoadWorkers:         4,
+		UploadBudget:        4 * time.Millisecond,
 	}
 }
 
+// EngineConfigFrom строит конфигурацию движка из загруженного файла настроек
+func EngineConfigFrom(c *config.Config) EngineConfig {
+	cfg := DefaultEngineConfig()
+
+	cfg.WindowConfig.Title = c.Window.Title
+	cfg.WindowConfig.Width = c.Window.Width
+	cfg.WindowConfig.Height = c.Window.Height
+	cfg.WindowConfig.Fullscreen = c.Window.Fullscreen
+	cfg.WindowConfig.VSync = c.Window.VSync
+	cfg.WindowConfig.Resizable = c.Window.Resizable
+	cfg.WindowConfig.MSAA = c.Window.MSAA
+
+	cfg.TargetFPS = c.Engine.TargetFPS
+	cfg.MaxResourceCacheSize = int64(c.Engine.CacheSizeMB) * 1024 * 1024
+	cfg.LoadWorkers = c.Engine.LoadWorkers
+	cfg.UploadBudget = time.Duration(c.Engine.UploadBudgetMS) * time.Millisecond
+
+	return cfg
+}
+
+// LoadEngineConfig загружает конфигурацию из JSON-файла, переменных окружения
+// ANIMO_* и аргументов командной строки (обычно os.Args[1:]). Флаги игры
+// в args пропускаются
+func LoadEngineConfig(path string, args []string) (EngineConfig, error) {
+	c, err := config.Load(path, args)
+	if err != nil {
+		return EngineConfig{}, err
+	}
+	return EngineConfigFrom(c), nil
+}
+
 // Engine главный класс игрового движка
 type Engine struct {
 	config EngineConfig
@@ -39,6 +74,7 @@ type Engine struct {
 	eventBus        *event.EventBus
 	resourceManager *resource.ResourceManager
 	inputManager    *input.InputManager
+	settings        *config.Settings
 
 	// Состояние
 	running    bool
@@ -61,20 +97,31 @@ func NewEngine() *Engine {
 }
 
 // NewEngineWithConfig создает движок с заданной конфигурацией
-func NewEngineWithConfig(config EngineConfig) *Engine {
-	return &Engine{
-		config:          config,
-		targetFPS:       config.TargetFPS,
-		frameTime:       time.Second / time.Duration(config.TargetFPS),
+func NewEngineWithConfig(cfg EngineConfig) *Engine {
+	e := &Engine{
+		config:          cfg,
+		targetFPS:       cfg.TargetFPS,
+		frameTime:       time.Second / time.Duration(cfg.TargetFPS),
 		world:           ecs.NewWorld(),
 		eventBus:        event.NewEventBus(1000, 4),
-		resourceManager: resource.NewResourceManager(config.LoadWorkers, config.MaxResourceCacheSize),
+		resourceManager: resource.NewResourceManager(cfg.LoadWorkers, cfg.MaxResourceCacheSize),
 		inputManager:    input.NewInputManager(),
+		settings:        config.NewSettings(cfg.SettingsPath),
 	}
+
+	// Менеджер ресурсов публикует события загрузки и вытеснения в общую шину
+	e.resourceManager.SetEventBus(e.eventBus)
+
+	return e
 }
 
 // Initialize инициализирует движок
 func (e *Engine) Initialize() error {
+	// Загружаем пользовательские настройки
+	if err := e.settings.Load(); err != nil {
+		return err
+	}
+
 	// Создаем окно
 	var err error
 	e.window, err = window.NewWindow(e.config.WindowConfig)
@@ -175,6 +222,9 @@ func (e *Engine) Run() error {
 		// Обрабатываем события окна
 		e.window.PollEvents()
 
+		// Завершаем асинхронные загрузки, которым нужен GL-контекст
+		e.resourceManager.ProcessUploads(e.config.UploadBudget)
+
 		// Обновляем ввод
 		e.inputManager.Update()
 
@@ -211,6 +261,11 @@ func (e *Engine) Run() error {
 	}
 
 	e.Shutdown()
+
+	// Сохраняем пользовательские настройки
+	if err := e.settings.Save(); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
 	return nil
 }
 
@@ -307,6 +362,12 @@ func (e *Engine) GetInputManager() *input.InputManager {
 	return e.inputManager
 }
 
+// GetSettings возвращает хранилище пользовательских настроек.
+// Настройки загружаются в Initialize и сохраняются по завершении Run
+func (e *Engine) GetSettings() *config.Settings {
+	return e.settings
+}
+
 // GetFPS возвращает текущий FPS
 func (e *Engine) GetFPS() float64 {
 	return e.fps
@@ -1,5 +1,7 @@
 package event
 
+import "github.com/go-gl/mathgl/mgl32"
+
 // Определение общих типов событий в движке
 
 const (
@@ -49,12 +51,20 @@ const (
 	EventResourceLoad   EventType = "resource.load"
 	EventResourceUnload EventType = "resource.unload"
 	EventResourceError  EventType = "resource.error"
+	EventResourceEvict  EventType = "resource.evict"
+
+	EventResourceBatchProgress EventType = "resource.batch.progress"
+	EventResourceBatchComplete EventType = "resource.batch.complete"
 
 	// События коллизий
 	EventCollisionEnter EventType = "collision.enter"
 	EventCollisionExit  EventType = "collision.exit"
 	EventCollisionStay  EventType = "collision.stay"
 
+	// События сна физических тел
+	EventBodySleep EventType = "physics.body.sleep"
+	EventBodyWake  EventType = "physics.body.wake"
+
 	// События UI
 	EventUIClick   EventType = "ui.click"
 	EventUIHover   EventType = "ui.hover"
@@ -114,8 +124,19 @@ type MouseScrollData struct {
 
 // CollisionData данные события коллизии
 type CollisionData struct {
-	EntityA uint64
-	EntityB uint64
+	EntityA   uint64
+	EntityB   uint64
+	BodyA     interface{} // Физическое тело A (*physics.RigidBody или *physics2d.Body)
+	BodyB     interface{} // Физическое тело B (*physics.RigidBody или *physics2d.Body)
+	Point     mgl32.Vec3  // Самая глубокая точка контакта
+	Normal    mgl32.Vec3  // Нормаль контакта от A к B
+	IsTrigger bool        // Одно из тел - датчик, физического отклика нет
+}
+
+// BodySleepData данные события засыпания или пробуждения тела
+type BodySleepData struct {
+	Entity uint64
+	Body   interface{} // Физическое тело (*physics.RigidBody)
 }
 
 // ResourceLoadData данные события загрузки ресурса
@@ -130,6 +151,23 @@ type ResourceErrorData struct {
 	Error error
 }
 
+// ResourceEvictData данные события вытеснения ресурса из кеша
+type ResourceEvictData struct {
+	Path   string
+	Type   string
+	Size   int64
+	Reason string
+}
+
+// ResourceBatchData данные событий пакетной загрузки ресурсов
+type ResourceBatchData struct {
+	Total       int
+	Completed   int
+	Failed      int
+	BytesLoaded int64
+	BytesTotal  int64
+}
+
 // DamageData данные события получения урона
 type DamageData struct {
 	EntityID   uint64
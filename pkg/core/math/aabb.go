@@ -38,6 +38,19 @@ func (a AABB) Contains(point mgl32.Vec3) bool {
 		point.Z() >= a.Min.Z() && point.Z() <= a.Max.Z()
 }
 
+// ContainsAABB проверяет, содержит ли AABB другой AABB целиком
+func (a AABB) ContainsAABB(other AABB) bool {
+	return other.Min.X() >= a.Min.X() && other.Max.X() <= a.Max.X() &&
+		other.Min.Y() >= a.Min.Y() && other.Max.Y() <= a.Max.Y() &&
+		other.Min.Z() >= a.Min.Z() && other.Max.Z() <= a.Max.Z()
+}
+
+// SurfaceArea возвращает площадь поверхности AABB (метрика для построения BVH)
+func (a AABB) SurfaceArea() float32 {
+	size := a.Size()
+	return 2 * (size.X()*size.Y() + size.Y()*size.Z() + size.Z()*size.X())
+}
+
 // Center возвращает центр AABB
 func (a AABB) Center() mgl32.Vec3 {
 	return a.Min.Add(a.Max).Mul(0.5)
@@ -0,0 +1,321 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
+)
+
+// Request запрос на загрузку ресурса в пакете
+type Request struct {
+	Path string
+	Type ResourceType
+}
+
+// RequestError ошибка загрузки одного ресурса пакета
+type RequestError struct {
+	Request Request
+	Err     error
+}
+
+// Error реализует интерфейс error
+func (e RequestError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Request.Path, e.Err)
+}
+
+// Unwrap возвращает исходную ошибку
+func (e RequestError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError ошибки загрузки пакета ресурсов
+type BatchError struct {
+	Errors []RequestError
+}
+
+// Error реализует интерфейс error
+func (e *BatchError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("failed to load %d resources: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// BatchProgress прогресс загрузки пакета
+type BatchProgress struct {
+	Total       int   // Всего запросов
+	Completed   int   // Загружено успешно
+	Failed      int   // Завершилось ошибкой (включая отмененные)
+	BytesLoaded int64 // Загружено байт
+	BytesTotal  int64 // Всего байт (по размерам файлов в VFS)
+}
+
+// Fraction возвращает долю обработанных запросов от 0 до 1
+func (p BatchProgress) Fraction() float32 {
+	if p.Total == 0 {
+		return 1
+	}
+	return float32(p.Completed+p.Failed) / float32(p.Total)
+}
+
+// ByteFraction возвращает долю загруженных байт от 0 до 1
+func (p BatchProgress) ByteFraction() float32 {
+	if p.BytesTotal == 0 {
+		return p.Fraction()
+	}
+	return float32(p.BytesLoaded) / float32(p.BytesTotal)
+}
+
+// Batch пакетная загрузка ресурсов. Успешно загруженные ресурсы принадлежат
+// пакету до вызова Release
+type Batch struct {
+	rm       *ResourceManager
+	ctx      context.Context
+	cancel   context.CancelFunc
+	requests []Request
+	sizes    []int64
+
+	progress BatchProgress
+	recorded []bool // Запросы, результат которых учтен
+	ids      []ResourceID
+	errors   []RequestError
+	finished bool
+	released bool
+	done     chan struct{}
+	mu       sync.Mutex
+}
+
+// LoadBatch начинает пакетную загрузку. Загрузки идут параллельно, не больше
+// loadWorkers одновременно. Отмена ctx (в том числе по таймауту) завершает пакет:
+// все неучтенные запросы получают ошибку ctx.Err(), а ресурсы, загрузившиеся
+// после отмены, освобождаются автоматически. Отмена только прекращает ожидание:
+// уже начатые загрузки могут разделяться с другими вызовами и доходят до конца.
+// Прогресс публикуется в EventBus
+func (rm *ResourceManager) LoadBatch(ctx context.Context, requests []Request) *Batch {
+	ctx, cancel := context.WithCancel(ctx)
+
+	b := &Batch{
+		rm:       rm,
+		ctx:      ctx,
+		cancel:   cancel,
+		requests: requests,
+		sizes:    make([]int64, len(requests)),
+		recorded: make([]bool, len(requests)),
+		ids:      make([]ResourceID, 0, len(requests)),
+		done:     make(chan struct{}),
+	}
+	b.progress.Total = len(requests)
+
+	for i, req := range requests {
+		if size, err := rm.vfs.Size(req.Path); err == nil {
+			b.sizes[i] = size
+			b.progress.BytesTotal += size
+		}
+	}
+
+	if len(requests) == 0 {
+		b.finish()
+		return b
+	}
+
+	go b.run()
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.finish()
+		case <-b.done:
+		}
+	}()
+
+	return b
+}
+
+// run запускает загрузки, ограничивая их количество числом воркеров
+func (b *Batch) run() {
+	slots := make(chan struct{}, b.rm.loadWorkers)
+
+	for i, req := range b.requests {
+		select {
+		case slots <- struct{}{}:
+		case <-b.ctx.Done():
+			// Оставшиеся запросы отменены
+			for rest := i; rest < len(b.requests); rest++ {
+				b.record(rest, "", b.ctx.Err())
+			}
+			return
+		}
+
+		go func(i int, req Request) {
+			id, call, err := b.rm.startLoad(req.Path, req.Type, nil, true)
+			if err != nil {
+				<-slots
+				b.record(i, id, err)
+				return
+			}
+
+			call.onComplete(func(id ResourceID, err error) {
+				<-slots
+				b.record(i, id, err)
+			})
+		}(i, req)
+	}
+}
+
+// record учитывает результат запроса с индексом i
+func (b *Batch) record(i int, id ResourceID, err error) {
+	b.mu.Lock()
+
+	// Пакет уже завершен (отменен) - результат не нужен
+	if b.finished || b.released {
+		b.mu.Unlock()
+		if err == nil {
+			b.rm.Unload(id)
+		}
+		return
+	}
+
+	b.recorded[i] = true
+	if err != nil {
+		b.progress.Failed++
+		b.errors = append(b.errors, RequestError{Request: b.requests[i], Err: err})
+	} else {
+		b.progress.Completed++
+		b.progress.BytesLoaded += b.sizes[i]
+		b.ids = append(b.ids, id)
+	}
+	progress := b.progress
+	b.mu.Unlock()
+
+	b.rm.emit(event.EventResourceBatchProgress, &event.ResourceBatchData{
+		Total:       progress.Total,
+		Completed:   progress.Completed,
+		Failed:      progress.Failed,
+		BytesLoaded: progress.BytesLoaded,
+		BytesTotal:  progress.BytesTotal,
+	})
+
+	if progress.Completed+progress.Failed >= progress.Total {
+		b.finish()
+	}
+}
+
+// finish завершает пакет
+func (b *Batch) finish() {
+	b.mu.Lock()
+	if b.finished {
+		b.mu.Unlock()
+		return
+	}
+	b.finished = true
+
+	// Отмененные и еще не учтенные запросы считаются неудачными
+	for i, recorded := range b.recorded {
+		if !recorded {
+			b.recorded[i] = true
+			b.progress.Failed++
+			b.errors = append(b.errors, RequestError{Request: b.requests[i], Err: b.ctx.Err()})
+		}
+	}
+	progress := b.progress
+	close(b.done)
+	b.mu.Unlock()
+
+	b.cancel()
+
+	b.rm.emit(event.EventResourceBatchComplete, &event.ResourceBatchData{
+		Total:       progress.Total,
+		Completed:   progress.Completed,
+		Failed:      progress.Failed,
+		BytesLoaded: progress.BytesLoaded,
+		BytesTotal:  progress.BytesTotal,
+	})
+}
+
+// Progress возвращает текущий прогресс пакета
+func (b *Batch) Progress() BatchProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.progress
+}
+
+// Done возвращает канал, который закрывается по завершении пакета
+func (b *Batch) Done() <-chan struct{} {
+	return b.done
+}
+
+// IsDone проверяет, завершен ли пакет
+func (b *Batch) IsDone() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait ждет завершения пакета и возвращает *BatchError, если часть ресурсов
+// не загрузилась. Пока ждет, обрабатывает очередь Upload, поэтому вызывать
+// его нужно из главного потока. Из других горутин ждите канал Done
+func (b *Batch) Wait() error {
+	b.rm.wait(b.done, true)
+	return b.Err()
+}
+
+// Cancel отменяет пакет: он сразу завершается, а начатые загрузки доходят до
+// конца в фоне, и их ресурсы освобождаются
+func (b *Batch) Cancel() {
+	b.cancel()
+}
+
+// Err возвращает ошибки пакета или nil, если все ресурсы загружены
+func (b *Batch) Err() error {
+	errs := b.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &BatchError{Errors: errs}
+}
+
+// Errors возвращает ошибки отдельных запросов
+func (b *Batch) Errors() []RequestError {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	errs := make([]RequestError, len(b.errors))
+	copy(errs, b.errors)
+	return errs
+}
+
+// IDs возвращает идентификаторы успешно загруженных ресурсов
+func (b *Batch) IDs() []ResourceID {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ids := make([]ResourceID, len(b.ids))
+	copy(ids, b.ids)
+	return ids
+}
+
+// Release освобождает ссылки пакета на загруженные ресурсы
+func (b *Batch) Release() error {
+	b.mu.Lock()
+	if b.released {
+		b.mu.Unlock()
+		return errors.New("batch already released")
+	}
+	b.released = true
+	ids := b.ids
+	b.ids = nil
+	b.mu.Unlock()
+
+	b.cancel()
+	for _, id := range ids {
+		b.rm.Unload(id)
+	}
+	return nil
+}
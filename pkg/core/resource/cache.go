@@ -0,0 +1,308 @@
+package resource
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
+)
+
+// EvictionPolicy политика вытеснения ресурсов из кеша
+type EvictionPolicy int
+
+const (
+	EvictionLRU EvictionPolicy = iota // Сначала давно неиспользуемые
+	EvictionLFU                       // Сначала редко используемые
+)
+
+// Причины вытеснения ресурса
+const (
+	EvictReasonTotalBudget = "total_budget"
+	EvictReasonTypeBudget  = "type_budget"
+	EvictReasonManual      = "manual"
+)
+
+// SizedLoader загрузчик, который сам оценивает занимаемую ресурсом память
+// (например, размер текстуры в видеопамяти вместо размера файла)
+type SizedLoader interface {
+	SizeOf(data interface{}) int64
+}
+
+// CacheStats статистика кеша ресурсов
+type CacheStats struct {
+	Hits         uint64 // Загрузки, обслуженные из кеша
+	Misses       uint64 // Загрузки с диска
+	Evictions    uint64 // Количество вытесненных ресурсов
+	EvictedBytes int64  // Суммарный размер вытесненных ресурсов
+	TotalSize    int64  // Текущий размер кеша
+	MaxSize      int64  // Общий бюджет (0 = без ограничения)
+	Resident     int    // Ресурсов в памяти
+	Unreferenced int    // Ресурсов в памяти без ссылок (кандидаты на вытеснение)
+	Pinned       int    // Закрепленных ресурсов
+	TypeSizes    map[ResourceType]int64
+	TypeBudgets  map[ResourceType]int64
+}
+
+// touch отмечает обращение к ресурсу для политики вытеснения
+func (rm *ResourceManager) touch(res *Resource) {
+	atomic.StoreUint64(&res.lastAccess, atomic.AddUint64(&rm.accessClock, 1))
+	atomic.AddUint64(&res.accessCount, 1)
+}
+
+// SetEvictionPolicy устанавливает политику вытеснения
+func (rm *ResourceManager) SetEvictionPolicy(policy EvictionPolicy) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.evictionPolicy = policy
+}
+
+// SetAutoUnload задает, выгружать ли ресурс сразу, когда на него не осталось ссылок.
+// Если выключено, ресурс остается в кеше и вытесняется политикой при превышении бюджета
+func (rm *ResourceManager) SetAutoUnload(enabled bool) {
+	rm.mu.Lock()
+	rm.autoUnload = enabled
+	rm.mu.Unlock()
+
+	if enabled {
+		rm.unloadUnreferenced()
+	}
+}
+
+// SetMaxCacheSize устанавливает общий бюджет памяти (0 = без ограничения)
+func (rm *ResourceManager) SetMaxCacheSize(size int64) {
+	rm.mu.Lock()
+	rm.maxCacheSize = size
+	rm.mu.Unlock()
+
+	rm.checkCacheSize()
+}
+
+// SetTypeBudget устанавливает бюджет памяти для типа ресурсов (0 = без ограничения)
+func (rm *ResourceManager) SetTypeBudget(resType ResourceType, size int64) {
+	rm.mu.Lock()
+	if size <= 0 {
+		delete(rm.typeBudgets, resType)
+	} else {
+		rm.typeBudgets[resType] = size
+	}
+	rm.mu.Unlock()
+
+	rm.checkCacheSize()
+}
+
+// Pin закрепляет ресурс: он никогда не вытесняется из кеша
+func (rm *ResourceManager) Pin(id ResourceID) error {
+	return rm.setPinned(id, true)
+}
+
+// Unpin снимает закрепление ресурса
+func (rm *ResourceManager) Unpin(id ResourceID) error {
+	if err := rm.setPinned(id, false); err != nil {
+		return err
+	}
+	rm.checkCacheSize()
+	return nil
+}
+
+// setPinned меняет флаг закрепления
+func (rm *ResourceManager) setPinned(id ResourceID, pinned bool) error {
+	res, err := rm.Get(id)
+	if err != nil {
+		return err
+	}
+
+	res.mu.Lock()
+	res.Pinned = pinned
+	res.mu.Unlock()
+	return nil
+}
+
+// Evict вытесняет ресурсы без ссылок до тех пор, пока кеш не уменьшится
+// до target байт. Возвращает количество вытесненных ресурсов
+func (rm *ResourceManager) Evict(target int64) int {
+	rm.mu.Lock()
+	evicted := rm.collectVictimsLocked("", rm.currentCacheSize-target, EvictReasonManual)
+	rm.mu.Unlock()
+
+	rm.destroyEvicted(evicted)
+	return len(evicted)
+}
+
+// GetCacheStats возвращает статистику кеша
+func (rm *ResourceManager) GetCacheStats() CacheStats {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	stats := CacheStats{
+		Hits:         atomic.LoadUint64(&rm.stats.Hits),
+		Misses:       atomic.LoadUint64(&rm.stats.Misses),
+		Evictions:    rm.stats.Evictions,
+		EvictedBytes: rm.stats.EvictedBytes,
+		TotalSize:    rm.currentCacheSize,
+		MaxSize:      rm.maxCacheSize,
+		Resident:     len(rm.resources),
+		TypeSizes:    make(map[ResourceType]int64, len(rm.typeSizes)),
+		TypeBudgets:  make(map[ResourceType]int64, len(rm.typeBudgets)),
+	}
+	for t, size := range rm.typeSizes {
+		stats.TypeSizes[t] = size
+	}
+	for t, budget := range rm.typeBudgets {
+		stats.TypeBudgets[t] = budget
+	}
+	for _, res := range rm.resources {
+		res.mu.RLock()
+		if res.Pinned {
+			stats.Pinned++
+		}
+		if res.RefCount == 0 && res.State == ResourceStateLoaded {
+			stats.Unreferenced++
+		}
+		res.mu.RUnlock()
+	}
+	return stats
+}
+
+// checkCacheSize проверяет бюджеты и вытесняет ресурсы без ссылок
+func (rm *ResourceManager) checkCacheSize() {
+	rm.mu.Lock()
+
+	evicted := make([]evictedResource, 0)
+
+	// Сначала бюджеты по типам
+	for resType, budget := range rm.typeBudgets {
+		if over := rm.typeSizes[resType] - budget; over > 0 {
+			evicted = append(evicted, rm.collectVictimsLocked(resType, over, EvictReasonTypeBudget)...)
+		}
+	}
+
+	// Затем общий бюджет
+	if rm.maxCacheSize > 0 {
+		if over := rm.currentCacheSize - rm.maxCacheSize; over > 0 {
+			evicted = append(evicted, rm.collectVictimsLocked("", over, EvictReasonTotalBudget)...)
+		}
+	}
+	rm.mu.Unlock()
+
+	rm.destroyEvicted(evicted)
+}
+
+// evictedResource вытесненный ресурс и причина вытеснения
+type evictedResource struct {
+	res    *Resource
+	reason string
+}
+
+// collectVictimsLocked удаляет из кеша ресурсы без ссылок, пока не освободится
+// amount байт. resType "" - ресурсы любого типа. Требует блокировки rm.mu
+func (rm *ResourceManager) collectVictimsLocked(resType ResourceType, amount int64, reason string) []evictedResource {
+	if amount <= 0 {
+		return nil
+	}
+
+	// Кандидаты: загружены, без ссылок и не закреплены
+	candidates := make([]*Resource, 0)
+	for _, res := range rm.resources {
+		if resType != "" && res.Type != resType {
+			continue
+		}
+
+		res.mu.RLock()
+		evictable := res.State == ResourceStateLoaded && res.RefCount == 0 && !res.Pinned
+		res.mu.RUnlock()
+
+		if evictable {
+			candidates = append(candidates, res)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if rm.evictionPolicy == EvictionLFU {
+			ca, cb := atomic.LoadUint64(&a.accessCount), atomic.LoadUint64(&b.accessCount)
+			if ca != cb {
+				return ca < cb
+			}
+		}
+		return atomic.LoadUint64(&a.lastAccess) < atomic.LoadUint64(&b.lastAccess)
+	})
+
+	evicted := make([]evictedResource, 0)
+	for _, res := range candidates {
+		if amount <= 0 {
+			break
+		}
+
+		rm.removeLocked(res)
+		amount -= res.Size
+		rm.stats.Evictions++
+		rm.stats.EvictedBytes += res.Size
+		evicted = append(evicted, evictedResource{res: res, reason: reason})
+	}
+	return evicted
+}
+
+// destroyEvicted выгружает вытесненные ресурсы и сообщает о них
+func (rm *ResourceManager) destroyEvicted(evicted []evictedResource) {
+	for _, e := range evicted {
+		rm.destroyResource(e.res)
+		rm.emit(event.EventResourceEvict, &event.ResourceEvictData{
+			Path:   e.res.Path,
+			Type:   string(e.res.Type),
+			Size:   e.res.Size,
+			Reason: e.reason,
+		})
+	}
+}
+
+// unloadUnreferenced выгружает все ресурсы без ссылок, кроме закрепленных
+func (rm *ResourceManager) unloadUnreferenced() {
+	rm.mu.Lock()
+	unreferenced := make([]*Resource, 0)
+	for _, res := range rm.resources {
+		res.mu.RLock()
+		unused := res.State == ResourceStateLoaded && res.RefCount == 0 && !res.Pinned
+		res.mu.RUnlock()
+
+		if unused {
+			rm.removeLocked(res)
+			unreferenced = append(unreferenced, res)
+		}
+	}
+	rm.mu.Unlock()
+
+	for _, res := range unreferenced {
+		rm.destroyResource(res)
+	}
+}
+
+// removeLocked удаляет ресурс из таблиц менеджера и учета памяти. Требует блокировки rm.mu
+func (rm *ResourceManager) removeLocked(res *Resource) {
+	if current, exists := rm.resources[res.ID]; !exists || current != res {
+		return
+	}
+
+	delete(rm.cache, res.Path)
+	delete(rm.resources, res.ID)
+	rm.currentCacheSize -= res.Size
+	rm.typeSizes[res.Type] -= res.Size
+}
+
+// SetEventBus задает шину, в которую менеджер публикует события загрузки,
+// выгрузки и вытеснения ресурсов
+func (rm *ResourceManager) SetEventBus(bus *event.EventBus) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.eventBus = bus
+}
+
+// emit публикует событие, если шина задана
+func (rm *ResourceManager) emit(eventType event.EventType, data interface{}) {
+	rm.mu.RLock()
+	bus := rm.eventBus
+	rm.mu.RUnlock()
+
+	if bus != nil {
+		bus.Emit(event.NewEvent(eventType, data))
+	}
+}
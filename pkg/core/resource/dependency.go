@@ -0,0 +1,193 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+)
+
+// Ошибки графа зависимостей
+var (
+	ErrDependencyCycle = errors.New("resource dependency cycle")
+)
+
+// Dependency зависимость ресурса от другого ресурса
+type Dependency struct {
+	Path string
+	Type ResourceType
+}
+
+// DependencyLoader загрузчик ресурсов, которые зависят от других ресурсов
+// (модель от текстур, материал от шейдеров). Зависимости загружаются
+// до основного ресурса и выгружаются вместе с ним
+type DependencyLoader interface {
+	ResourceLoader
+
+	// Dependencies читает из потока список зависимостей ресурса.
+	// После вызова поток перематывается в начало
+	Dependencies(reader io.ReadSeeker, path string) ([]Dependency, error)
+
+	// LoadWithDependencies загружает ресурс, когда все зависимости уже загружены.
+	// deps ключуются по пути зависимости после CleanPath
+	LoadWithDependencies(reader io.ReadSeeker, path string, deps map[string]*Resource) (interface{}, error)
+}
+
+// ResolvePath разрешает путь зависимости относительно пути ресурса
+func ResolvePath(base, rel string) string {
+	if path.IsAbs(rel) {
+		return rel
+	}
+	return path.Join(path.Dir(base), rel)
+}
+
+// loadDependencies загружает зависимости параллельно. При ошибке уже
+// загруженные зависимости освобождаются. mainThread - вызов из главного
+// потока: пока ждем, обрабатываем очередь Upload
+func (rm *ResourceManager) loadDependencies(loader DependencyLoader, file io.ReadSeeker, resPath string, stack []ResourceID, mainThread bool) (map[string]*Resource, error) {
+	deps, err := loader.Dependencies(file, resPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dependencies of %s: %w", resPath, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek %s: %w", resPath, err)
+	}
+
+	// Отмечаем, кого ждем: загрузка из другого потока, которая сама ждет этот
+	// ресурс, не присоединится к нам, а получит ErrDependencyCycle
+	id := stack[len(stack)-1]
+	rm.beginWait(id, deps)
+	defer rm.endWait(id)
+
+	type depResult struct {
+		path string
+		id   ResourceID
+		err  error
+	}
+
+	results := make([]depResult, len(deps))
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func(i int, dep Dependency) {
+			defer wg.Done()
+			// Каждой горутине своя копия стека
+			depStack := make([]ResourceID, len(stack))
+			copy(depStack, stack)
+
+			// Горутины не владеют GL-контекстом, поэтому Upload всегда откладываем
+			id, err := rm.load(dep.Path, dep.Type, depStack, true)
+			results[i] = depResult{path: string(id), id: id, err: err}
+		}(i, dep)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	rm.wait(done, mainThread)
+
+	loaded := make(map[string]*Resource, len(results))
+	var firstErr error
+	for i, result := range results {
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("dependency %s of %s: %w", deps[i].Path, resPath, result.err)
+			}
+			continue
+		}
+
+		res, err := rm.Get(result.id)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("dependency %s of %s: %w", deps[i].Path, resPath, err)
+			}
+			continue
+		}
+
+		// Одна и та же зависимость может быть указана дважды - держим одну ссылку
+		if _, exists := loaded[result.path]; exists {
+			rm.Unload(result.id)
+			continue
+		}
+		loaded[result.path] = res
+	}
+
+	if firstErr != nil {
+		rm.releaseDependencies(loaded)
+		return nil, firstErr
+	}
+	return loaded, nil
+}
+
+// beginWait отмечает, что загрузка id ждет зависимости deps
+func (rm *ResourceManager) beginWait(id ResourceID, deps []Dependency) {
+	ids := make([]ResourceID, 0, len(deps))
+	for _, dep := range deps {
+		if clean, err := CleanPath(dep.Path); err == nil {
+			ids = append(ids, ResourceID(clean))
+		}
+	}
+
+	rm.mu.Lock()
+	rm.waiting[id] = ids
+	rm.mu.Unlock()
+}
+
+// endWait снимает отметку ожидания зависимостей
+func (rm *ResourceManager) endWait(id ResourceID) {
+	rm.mu.Lock()
+	delete(rm.waiting, id)
+	rm.mu.Unlock()
+}
+
+// waitsForLocked проверяет, ждет ли загрузка id (прямо или через свои
+// зависимости) один из ресурсов targets. Требует блокировки rm.mu
+func (rm *ResourceManager) waitsForLocked(id ResourceID, targets []ResourceID) bool {
+	if len(targets) == 0 {
+		return false
+	}
+
+	visited := make(map[ResourceID]bool)
+	pending := []ResourceID{id}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		for _, target := range targets {
+			if current == target {
+				return true
+			}
+		}
+		pending = append(pending, rm.waiting[current]...)
+	}
+	return false
+}
+
+// releaseDependencies освобождает ссылки на зависимости
+func (rm *ResourceManager) releaseDependencies(deps map[string]*Resource) {
+	for _, dep := range deps {
+		rm.Unload(dep.ID)
+	}
+}
+
+// GetDependencies возвращает зависимости загруженного ресурса
+func (rm *ResourceManager) GetDependencies(id ResourceID) ([]ResourceID, error) {
+	res, err := rm.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res.mu.RLock()
+	defer res.mu.RUnlock()
+
+	deps := make([]ResourceID, len(res.Dependencies))
+	copy(deps, res.Dependencies)
+	return deps, nil
+}
@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"fmt"
+)
+
+// Handle типизированная ссылка на загруженный ресурс.
+// Каждый Handle держит одну ссылку, которую нужно вернуть через Release
+type Handle[T any] struct {
+	id ResourceID
+	rm *ResourceManager
+}
+
+// Load синхронно загружает ресурс и возвращает типизированный Handle.
+// Если данные ресурса не приводятся к T, ссылка освобождается и
+// возвращается ErrResourceTypeMismatch
+func Load[T any](rm *ResourceManager, path string, resType ResourceType) (Handle[T], error) {
+	id, err := rm.LoadSync(path, resType)
+	if err != nil {
+		return Handle[T]{}, err
+	}
+
+	handle := Handle[T]{id: id, rm: rm}
+	if _, err := handle.Get(); err != nil {
+		rm.Unload(id)
+		return Handle[T]{}, err
+	}
+	return handle, nil
+}
+
+// ID возвращает идентификатор ресурса
+func (h Handle[T]) ID() ResourceID {
+	return h.id
+}
+
+// IsValid проверяет, указывает ли Handle на ресурс
+func (h Handle[T]) IsValid() bool {
+	return h.rm != nil && h.id != ""
+}
+
+// Get возвращает данные ресурса
+func (h Handle[T]) Get() (T, error) {
+	var zero T
+	if !h.IsValid() {
+		return zero, ErrInvalidResourceID
+	}
+
+	res, err := h.rm.Get(h.id)
+	if err != nil {
+		return zero, err
+	}
+
+	res.mu.RLock()
+	defer res.mu.RUnlock()
+
+	if res.State != ResourceStateLoaded {
+		if res.Error != nil {
+			return zero, res.Error
+		}
+		return zero, ErrResourceLoading
+	}
+
+	data, ok := res.Data.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is %T, not %T", ErrResourceTypeMismatch, h.id, res.Data, zero)
+	}
+	return data, nil
+}
+
+// Clone возвращает новый Handle на тот же ресурс со своей ссылкой
+func (h Handle[T]) Clone() (Handle[T], error) {
+	if !h.IsValid() {
+		return Handle[T]{}, ErrInvalidResourceID
+	}
+
+	res, err := h.rm.Get(h.id)
+	if err != nil {
+		return Handle[T]{}, err
+	}
+	res.AddRef()
+	return h, nil
+}
+
+// Release освобождает ссылку на ресурс. После вызова Handle использовать нельзя
+func (h Handle[T]) Release() error {
+	if !h.IsValid() {
+		return ErrInvalidResourceID
+	}
+	return h.rm.Unload(h.id)
+}
@@ -0,0 +1,157 @@
+package resource
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// objVertexKey уникальная комбинация индексов v/vt/vn
+type objVertexKey struct {
+	position, texCoord, normal int
+}
+
+// ParseOBJ конвертирует OBJ в меш с вершинами position(3) + normal(3) + uv(2),
+// как у model.Vertex. Многоугольники триангулируются веером
+func ParseOBJ(r io.Reader) (*RawMesh, error) {
+	positions := make([][3]float32, 0)
+	normals := make([][3]float32, 0)
+	texCoords := make([][2]float32, 0)
+
+	mesh := &RawMesh{Stride: 8}
+	vertexIndex := make(map[objVertexKey]uint32)
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "v":
+			v, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			positions = append(positions, [3]float32{v[0], v[1], v[2]})
+		case "vn":
+			v, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			normals = append(normals, [3]float32{v[0], v[1], v[2]})
+		case "vt":
+			v, err := parseFloats(fields[1:], 2)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			texCoords = append(texCoords, [2]float32{v[0], v[1]})
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: face needs at least 3 vertices", lineNum)
+			}
+
+			face := make([]uint32, 0, len(fields)-1)
+			for _, ref := range fields[1:] {
+				key, err := parseFaceVertex(ref, len(positions), len(texCoords), len(normals))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", lineNum, err)
+				}
+
+				index, exists := vertexIndex[key]
+				if !exists {
+					index = uint32(len(mesh.Vertices) / mesh.Stride)
+					vertexIndex[key] = index
+
+					p := positions[key.position]
+					var n [3]float32
+					var uv [2]float32
+					if key.normal >= 0 {
+						n = normals[key.normal]
+					}
+					if key.texCoord >= 0 {
+						uv = texCoords[key.texCoord]
+					}
+					mesh.Vertices = append(mesh.Vertices, p[0], p[1], p[2], n[0], n[1], n[2], uv[0], uv[1])
+				}
+				face = append(face, index)
+			}
+
+			for i := 1; i+1 < len(face); i++ {
+				mesh.Indices = append(mesh.Indices, face[0], face[i], face[i+1])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return mesh, nil
+}
+
+// parseFloats разбирает не меньше count чисел
+func parseFloats(fields []string, count int) ([]float32, error) {
+	if len(fields) < count {
+		return nil, fmt.Errorf("expected %d values, got %d", count, len(fields))
+	}
+
+	values := make([]float32, count)
+	for i := 0; i < count; i++ {
+		v, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = float32(v)
+	}
+	return values, nil
+}
+
+// parseFaceVertex разбирает ссылку вида v, v/vt, v//vn или v/vt/vn
+func parseFaceVertex(ref string, numPositions, numTexCoords, numNormals int) (objVertexKey, error) {
+	parts := strings.Split(ref, "/")
+	key := objVertexKey{position: -1, texCoord: -1, normal: -1}
+
+	resolve := func(s string, count int) (int, error) {
+		if s == "" {
+			return -1, nil
+		}
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, err
+		}
+		// Отрицательные индексы считаются с конца
+		if i < 0 {
+			i = count + i
+		} else {
+			i--
+		}
+		if i < 0 || i >= count {
+			return 0, fmt.Errorf("index %s out of range", s)
+		}
+		return i, nil
+	}
+
+	var err error
+	if key.position, err = resolve(parts[0], numPositions); err != nil {
+		return key, err
+	}
+	if key.position < 0 {
+		return key, fmt.Errorf("face vertex %q has no position", ref)
+	}
+	if len(parts) > 1 {
+		if key.texCoord, err = resolve(parts[1], numTexCoords); err != nil {
+			return key, err
+		}
+	}
+	if len(parts) > 2 {
+		if key.normal, err = resolve(parts[2], numNormals); err != nil {
+			return key, err
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,382 @@
+package resource
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Формат pack-файла:
+//
+//	[заголовок 24 байта][сжатые блоки данных][манифест JSON]
+//
+// Заголовок: magic "ANPK", версия (uint32), смещение и длина манифеста (uint64).
+// Одинаковое содержимое хранится один раз: записи с одинаковым хешем
+// ссылаются на один и тот же блок.
+
+const (
+	packMagic      = "ANPK"
+	packVersion    = 1
+	packHeaderSize = 24
+
+	// Deflate сжимает не сильнее чем в 1032 раза: больший RawSize в манифесте -
+	// признак поврежденного файла, и память под него не выделяется
+	packMaxDeflateRatio = 1032
+)
+
+// Форматы содержимого записей pack-файла
+const (
+	PackFormatRaw   = "raw"   // Файл как есть
+	PackFormatRGBA8 = "rgba8" // Изображение, сконвертированное в RawImage
+	PackFormatMesh  = "mesh"  // Меш, сконвертированный в RawMesh
+)
+
+// Способы сжатия записей
+const (
+	PackCompressionNone    = "none"
+	PackCompressionDeflate = "deflate"
+)
+
+// Ошибки pack-файлов
+var (
+	ErrInvalidPack = errors.New("invalid pack file")
+)
+
+// PackEntry запись манифеста pack-файла
+type PackEntry struct {
+	Path        string `json:"path"`        // Виртуальный путь
+	Format      string `json:"format"`      // Формат содержимого
+	Hash        string `json:"hash"`        // SHA-256 несжатого содержимого
+	Offset      int64  `json:"offset"`      // Смещение блока в файле
+	Size        int64  `json:"size"`        // Размер блока в файле
+	RawSize     int64  `json:"raw_size"`    // Размер после распаковки
+	Compression string `json:"compression"` // Способ сжатия
+}
+
+// PackManifest манифест pack-файла
+type PackManifest struct {
+	Version int         `json:"version"`
+	Entries []PackEntry `json:"entries"`
+}
+
+// PackWriter записывает pack-файл
+type PackWriter struct {
+	w       io.WriteSeeker
+	offset  int64
+	level   int
+	entries map[string]PackEntry
+	blobs   map[string]PackEntry // hash -> первый блок с таким содержимым
+	closed  bool
+}
+
+// NewPackWriter создает writer поверх файла. level - уровень сжатия flate
+// (flate.NoCompression отключает сжатие)
+func NewPackWriter(w io.WriteSeeker, level int) (*PackWriter, error) {
+	// Резервируем место под заголовок
+	if _, err := w.Write(make([]byte, packHeaderSize)); err != nil {
+		return nil, fmt.Errorf("failed to write pack header: %w", err)
+	}
+
+	return &PackWriter{
+		w:       w,
+		offset:  packHeaderSize,
+		level:   level,
+		entries: make(map[string]PackEntry),
+		blobs:   make(map[string]PackEntry),
+	}, nil
+}
+
+// Add добавляет файл в pack. Возвращает true, если содержимое
+// уже было в pack и блок переиспользован
+func (pw *PackWriter) Add(name, format string, data []byte) (bool, error) {
+	if pw.closed {
+		return false, errors.New("pack writer is closed")
+	}
+
+	clean, err := CleanPath(name)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", err, name)
+	}
+	if _, exists := pw.entries[clean]; exists {
+		return false, fmt.Errorf("%w: %s", ErrResourceExists, clean)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	// Дедупликация по хешу содержимого
+	if blob, exists := pw.blobs[hash]; exists {
+		blob.Path = clean
+		blob.Format = format
+		pw.entries[clean] = blob
+		return true, nil
+	}
+
+	stored, compression, err := pw.compress(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to compress %s: %w", clean, err)
+	}
+
+	if _, err := pw.w.Write(stored); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", clean, err)
+	}
+
+	entry := PackEntry{
+		Path:        clean,
+		Format:      format,
+		Hash:        hash,
+		Offset:      pw.offset,
+		Size:        int64(len(stored)),
+		RawSize:     int64(len(data)),
+		Compression: compression,
+	}
+	pw.offset += entry.Size
+	pw.entries[clean] = entry
+	pw.blobs[hash] = entry
+
+	return false, nil
+}
+
+// compress сжимает данные, если это дает выигрыш
+func (pw *PackWriter) compress(data []byte) ([]byte, string, error) {
+	if pw.level == flate.NoCompression {
+		return data, PackCompressionNone, nil
+	}
+
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, pw.level)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, "", err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	// Уже сжатые данные храним как есть
+	if buf.Len() >= len(data) {
+		return data, PackCompressionNone, nil
+	}
+	return buf.Bytes(), PackCompressionDeflate, nil
+}
+
+// Close записывает манифест и заголовок
+func (pw *PackWriter) Close() (*PackManifest, error) {
+	if pw.closed {
+		return nil, errors.New("pack writer is closed")
+	}
+	pw.closed = true
+
+	manifest := &PackManifest{
+		Version: packVersion,
+		Entries: make([]PackEntry, 0, len(pw.entries)),
+	}
+	for _, entry := range pw.entries {
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	sort.Slice(manifest.Entries, func(i, j int) bool {
+		return manifest.Entries[i].Path < manifest.Entries[j].Path
+	})
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode pack manifest: %w", err)
+	}
+	if _, err := pw.w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write pack manifest: %w", err)
+	}
+
+	header := make([]byte, packHeaderSize)
+	copy(header, packMagic)
+	binary.LittleEndian.PutUint32(header[4:], packVersion)
+	binary.LittleEndian.PutUint64(header[8:], uint64(pw.offset))
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(data)))
+
+	if _, err := pw.w.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to write pack header: %w", err)
+	}
+	if _, err := pw.w.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write pack header: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// PackFS файловая система поверх pack-файла для монтирования в VFS
+type PackFS struct {
+	reader   io.ReaderAt
+	closer   io.Closer
+	manifest *PackManifest
+	entries  map[string]PackEntry
+	mu       sync.Mutex
+}
+
+// OpenPack открывает pack-файл с диска
+func OpenPack(packPath string) (*PackFS, error) {
+	file, err := os.Open(packPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack %s: %w", packPath, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open pack %s: %w", packPath, err)
+	}
+
+	pack, err := NewPackFS(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open pack %s: %w", packPath, err)
+	}
+	pack.closer = file
+	return pack, nil
+}
+
+// NewPackFS читает манифест pack-файла из источника размером size байт.
+// Смещения и размеры из заголовка и манифеста проверяются до выделения памяти
+func NewPackFS(r io.ReaderAt, size int64) (*PackFS, error) {
+	header := make([]byte, packHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPack, err)
+	}
+	if string(header[:4]) != packMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidPack)
+	}
+	if version := binary.LittleEndian.Uint32(header[4:]); version != packVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidPack, version)
+	}
+
+	offset := int64(binary.LittleEndian.Uint64(header[8:]))
+	length := int64(binary.LittleEndian.Uint64(header[16:]))
+	if !inRange(offset, length, packHeaderSize, size) {
+		return nil, fmt.Errorf("%w: manifest out of bounds", ErrInvalidPack)
+	}
+	data := make([]byte, length)
+	if _, err := r.ReadAt(data, offset); err != nil {
+		return nil, fmt.Errorf("%w: failed to read manifest: %v", ErrInvalidPack, err)
+	}
+
+	manifest := &PackManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode manifest: %v", ErrInvalidPack, err)
+	}
+
+	pack := &PackFS{
+		reader:   r,
+		manifest: manifest,
+		entries:  make(map[string]PackEntry, len(manifest.Entries)),
+	}
+	for _, entry := range manifest.Entries {
+		if err := validateEntry(entry, offset); err != nil {
+			return nil, err
+		}
+		pack.entries[entry.Path] = entry
+	}
+	return pack, nil
+}
+
+// validateEntry проверяет, что блок записи лежит между заголовком и манифестом,
+// а распакованный размер достижим при ее сжатии
+func validateEntry(entry PackEntry, dataEnd int64) error {
+	if !inRange(entry.Offset, entry.Size, packHeaderSize, dataEnd) {
+		return fmt.Errorf("%w: entry %s out of bounds", ErrInvalidPack, entry.Path)
+	}
+
+	valid := false
+	switch entry.Compression {
+	case PackCompressionNone:
+		valid = entry.RawSize == entry.Size
+	case PackCompressionDeflate:
+		valid = entry.RawSize >= 0 && entry.RawSize/packMaxDeflateRatio <= entry.Size
+	default:
+		return fmt.Errorf("%w: unknown compression %q", ErrInvalidPack, entry.Compression)
+	}
+	if !valid {
+		return fmt.Errorf("%w: entry %s has invalid size", ErrInvalidPack, entry.Path)
+	}
+	return nil
+}
+
+// inRange проверяет, что блок [offset, offset+length) лежит внутри [lo, hi)
+// (без переполнения при сложении)
+func inRange(offset, length, lo, hi int64) bool {
+	return offset >= lo && length >= 0 && offset <= hi && length <= hi-offset
+}
+
+// Manifest возвращает манифест pack-файла
+func (p *PackFS) Manifest() *PackManifest {
+	return p.manifest
+}
+
+// Entry возвращает запись манифеста по пути
+func (p *PackFS) Entry(name string) (PackEntry, bool) {
+	entry, exists := p.entries[name]
+	return entry, exists
+}
+
+// Open распаковывает запись в память
+func (p *PackFS) Open(name string) (File, error) {
+	entry, exists := p.entries[name]
+	if !exists {
+		return nil, ErrFileNotFound
+	}
+
+	stored := make([]byte, entry.Size)
+	p.mu.Lock()
+	_, err := p.reader.ReadAt(stored, entry.Offset)
+	p.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from pack: %w", name, err)
+	}
+
+	switch entry.Compression {
+	case PackCompressionNone:
+		return newMemoryFile(stored), nil
+	case PackCompressionDeflate:
+		fr := flate.NewReader(bytes.NewReader(stored))
+		defer fr.Close()
+
+		data := make([]byte, entry.RawSize)
+		if _, err := io.ReadFull(fr, data); err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %w", name, err)
+		}
+		return newMemoryFile(data), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown compression %q", ErrInvalidPack, entry.Compression)
+	}
+}
+
+// Exists проверяет наличие записи в pack-файле
+func (p *PackFS) Exists(name string) bool {
+	_, exists := p.entries[name]
+	return exists
+}
+
+// Size возвращает распакованный размер записи
+func (p *PackFS) Size(name string) (int64, error) {
+	entry, exists := p.entries[name]
+	if !exists {
+		return 0, ErrFileNotFound
+	}
+	return entry.RawSize, nil
+}
+
+// Close закрывает pack-файл
+func (p *PackFS) Close() error {
+	if p.closer != nil {
+		return p.closer.Close()
+	}
+	return nil
+}
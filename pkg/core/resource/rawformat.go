@@ -0,0 +1,213 @@
+package resource
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// Сырые форматы, в которые animo-pack конвертирует ассеты.
+// Данные лежат в том виде, в котором их ждет GPU, и не требуют декодирования.
+
+const (
+	rawImageMagic = "ANRI"
+	rawMeshMagic  = "ANRM"
+
+	// maxRawImageSize наибольшая сторона сырого изображения в пикселях
+	maxRawImageSize = 16384
+)
+
+// Ошибки сырых форматов
+var (
+	ErrInvalidRawData = errors.New("invalid raw asset data")
+)
+
+// IsRawImage проверяет, начинаются ли данные с заголовка RawImage
+func IsRawImage(prefix []byte) bool {
+	return len(prefix) >= 4 && string(prefix[:4]) == rawImageMagic
+}
+
+// IsRawMesh проверяет, начинаются ли данные с заголовка RawMesh
+func IsRawMesh(prefix []byte) bool {
+	return len(prefix) >= 4 && string(prefix[:4]) == rawMeshMagic
+}
+
+// PeekMagic читает первые четыре байта и возвращает позицию чтения назад.
+// В отличие от bufio.Reader.Peek декодер после этого получает исходный
+// io.ReadSeeker и может проверить размеры заголовка по длине данных
+func PeekMagic(r io.ReadSeeker) ([]byte, error) {
+	prefix := make([]byte, 4)
+	n, err := io.ReadFull(r, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if _, err := r.Seek(int64(-n), io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	return prefix[:n], nil
+}
+
+// EncodeRawImage записывает изображение в формате RGBA8:
+// magic, ширина и высота (uint32), затем пиксели построчно без паддинга
+func EncodeRawImage(w io.Writer, img *image.RGBA) error {
+	width := img.Rect.Dx()
+	height := img.Rect.Dy()
+
+	header := make([]byte, 12)
+	copy(header, rawImageMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(width))
+	binary.LittleEndian.PutUint32(header[8:], uint32(height))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	for y := 0; y < height; y++ {
+		start := y * img.Stride
+		if _, err := w.Write(img.Pix[start : start+width*4]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeRawImage читает изображение в формате RGBA8
+func DecodeRawImage(r io.Reader) (*image.RGBA, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRawData, err)
+	}
+	if !IsRawImage(header) {
+		return nil, fmt.Errorf("%w: bad image magic", ErrInvalidRawData)
+	}
+
+	width := int(binary.LittleEndian.Uint32(header[4:]))
+	height := int(binary.LittleEndian.Uint32(header[8:]))
+	if width == 0 || height == 0 || width > maxRawImageSize || height > maxRawImageSize {
+		return nil, fmt.Errorf("%w: bad image size %dx%d", ErrInvalidRawData, width, height)
+	}
+
+	pix, err := readPayload(r, int64(width)*int64(height)*4)
+	if err != nil {
+		return nil, err
+	}
+	return &image.RGBA{Pix: pix, Stride: width * 4, Rect: image.Rect(0, 0, width, height)}, nil
+}
+
+// RawMesh меш в виде готовых вершинного и индексного буферов
+type RawMesh struct {
+	Stride   int       // Количество float32 на вершину
+	Vertices []float32 // Вершины подряд, по Stride значений
+	Indices  []uint32
+}
+
+// EncodeRawMesh записывает меш: magic, stride, количество вершин и индексов
+// (uint32), затем вершины (float32) и индексы (uint32)
+func EncodeRawMesh(w io.Writer, mesh *RawMesh) error {
+	if mesh.Stride <= 0 || len(mesh.Vertices)%mesh.Stride != 0 {
+		return fmt.Errorf("%w: vertex count is not a multiple of stride", ErrInvalidRawData)
+	}
+
+	buf := make([]byte, 16+4*len(mesh.Vertices)+4*len(mesh.Indices))
+	copy(buf, rawMeshMagic)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(mesh.Stride))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(len(mesh.Vertices)/mesh.Stride))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(len(mesh.Indices)))
+
+	offset := 16
+	for _, v := range mesh.Vertices {
+		binary.LittleEndian.PutUint32(buf[offset:], math.Float32bits(v))
+		offset += 4
+	}
+	for _, i := range mesh.Indices {
+		binary.LittleEndian.PutUint32(buf[offset:], i)
+		offset += 4
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// DecodeRawMesh читает меш, записанный EncodeRawMesh
+func DecodeRawMesh(r io.Reader) (*RawMesh, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRawData, err)
+	}
+	if !IsRawMesh(header) {
+		return nil, fmt.Errorf("%w: bad mesh magic", ErrInvalidRawData)
+	}
+
+	stride := int(binary.LittleEndian.Uint32(header[4:]))
+	vertexCount := int(binary.LittleEndian.Uint32(header[8:]))
+	indexCount := int(binary.LittleEndian.Uint32(header[12:]))
+
+	// Счетчики из заголовка не должны требовать больше 2 ГБ
+	values := uint64(stride)*uint64(vertexCount) + uint64(indexCount)
+	if stride == 0 || values > math.MaxInt32/4 {
+		return nil, fmt.Errorf("%w: bad mesh header", ErrInvalidRawData)
+	}
+
+	buf, err := readPayload(r, int64(4*values))
+	if err != nil {
+		return nil, err
+	}
+
+	mesh := &RawMesh{
+		Stride:   stride,
+		Vertices: make([]float32, stride*vertexCount),
+		Indices:  make([]uint32, indexCount),
+	}
+	offset := 0
+	for i := range mesh.Vertices {
+		mesh.Vertices[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[offset:]))
+		offset += 4
+	}
+	for i := range mesh.Indices {
+		mesh.Indices[i] = binary.LittleEndian.Uint32(buf[offset:])
+		offset += 4
+	}
+	return mesh, nil
+}
+
+// readPayload читает ровно n байт данных после заголовка. Если r умеет Seek,
+// n сначала сверяется с оставшейся длиной; в остальных случаях буфер растет
+// по мере чтения, поэтому завышенный заголовок не выделяет память заранее
+func readPayload(r io.Reader, n int64) ([]byte, error) {
+	if seeker, ok := r.(io.Seeker); ok {
+		left, err := remainingBytes(seeker)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRawData, err)
+		}
+		if left < n {
+			return nil, fmt.Errorf("%w: header needs %d bytes, %d left", ErrInvalidRawData, n, left)
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, n))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRawData, err)
+	}
+	if int64(len(data)) != n {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRawData, io.ErrUnexpectedEOF)
+	}
+	return data, nil
+}
+
+// remainingBytes возвращает число байт от текущей позиции до конца
+func remainingBytes(seeker io.Seeker) (int64, error) {
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - current, nil
+}
@@ -3,7 +3,11 @@ package resource
 import (
 	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"sync/atomic"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
 )
 
 // ResourceID представляет уникальный идентификатор ресурса
@@ -39,10 +43,14 @@ const (
 	ResourceStateLoading
 	ResourceStateLoaded
 	ResourceStateError
+	ResourceStateUploading // Ждет Upload в главном потоке
 )
 
 // Resource представляет загруженный ресурс
 type Resource struct {
+	lastAccess  uint64 // Логическое время последнего обращения (атомарно)
+	accessCount uint64 // Количество обращений (атомарно)
+
 	ID           ResourceID
 	Path         string
 	Type         ResourceType
@@ -51,6 +59,8 @@ type Resource struct {
 	RefCount     int
 	Size         int64  // Размер в байтах
 	Error        error  // Ошибка загрузки, если есть
+	Dependencies []ResourceID // Ресурсы, от которых зависит этот ресурс
+	Pinned       bool         // Закрепленный ресурс никогда не вытесняется
 	mu           sync.RWMutex
 }
 
@@ -88,8 +98,9 @@ func (r *Resource) IsLoaded() bool {
 
 // ResourceLoader интерфейс для загрузчиков ресурсов
 type ResourceLoader interface {
-	// Load загружает ресурс из файла
-	Load(path string) (interface{}, error)
+	// Load загружает ресурс из потока. path - виртуальный путь ресурса,
+	// нужен для сообщений об ошибках и разрешения относительных путей
+	Load(reader io.ReadSeeker, path string) (interface{}, error)
 
 	// Unload выгружает ресурс
 	Unload(data interface{}) error
@@ -103,18 +114,34 @@ type ResourceManager struct {
 	resources map[ResourceID]*Resource
 	loaders   map[ResourceType]ResourceLoader
 	cache     map[string]ResourceID // Кеш path -> ResourceID
+	loading   map[ResourceID]*loadCall // Ресурсы в процессе загрузки
+	waiting   map[ResourceID][]ResourceID // Загрузки, ждущие свои зависимости
+	vfs       *VFS
 	mu        sync.RWMutex
 
 	// Опции
 	autoUnload      bool // Автоматически выгружать ресурсы с RefCount = 0
 	maxCacheSize    int64 // Максимальный размер кеша в байтах
 	currentCacheSize int64
+	evictionPolicy   EvictionPolicy
+	typeBudgets      map[ResourceType]int64 // Бюджеты памяти по типам
+	typeSizes        map[ResourceType]int64 // Занятая память по типам
+	accessClock      uint64
+	stats            CacheStats
+	progress         LoadProgress
+	eventBus         *event.EventBus
 
 	// Асинхронная загрузка
 	loadQueue   chan *loadRequest
 	loadWorkers int
 	wg          sync.WaitGroup
 	running     bool
+
+	// Очередь Upload и выгрузок GL-ресурсов для главного потока
+	uploads      []*uploadTask
+	unloads      []*unloadTask
+	uploadMu     sync.Mutex
+	uploadSignal chan struct{}
 }
 
 // loadRequest запрос на загрузку ресурса
@@ -134,15 +161,54 @@ func NewResourceManager(loadWorkers int, maxCacheSize int64) *ResourceManager {
 		resources:       make(map[ResourceID]*Resource),
 		loaders:         make(map[ResourceType]ResourceLoader),
 		cache:           make(map[string]ResourceID),
+		loading:         make(map[ResourceID]*loadCall),
+		waiting:         make(map[ResourceID][]ResourceID),
+		vfs:             newDefaultVFS(),
 		autoUnload:      true,
 		maxCacheSize:    maxCacheSize,
 		currentCacheSize: 0,
+		evictionPolicy:   EvictionLRU,
+		typeBudgets:      make(map[ResourceType]int64),
+		typeSizes:        make(map[ResourceType]int64),
 		loadQueue:       make(chan *loadRequest, 100),
 		loadWorkers:     loadWorkers,
+		uploadSignal:    make(chan struct{}, 1),
 		running:         false,
 	}
 }
 
+// newDefaultVFS создает VFS с текущей директорией в корне,
+// чтобы обычные относительные пути продолжали работать
+func newDefaultVFS() *VFS {
+	vfs := NewVFS()
+	vfs.Mount("", NewDirFS("."), 0)
+	return vfs
+}
+
+// GetVFS возвращает виртуальную файловую систему менеджера
+func (rm *ResourceManager) GetVFS() *VFS {
+	return rm.vfs
+}
+
+// Mount монтирует файловую систему в VFS менеджера
+func (rm *ResourceManager) Mount(point string, fsys FileSystem, priority int) error {
+	return rm.vfs.Mount(point, fsys, priority)
+}
+
+// MountPack открывает pack-файл, собранный animo-pack, и монтирует его в VFS
+func (rm *ResourceManager) MountPack(point string, packPath string, priority int) (*PackFS, error) {
+	pack, err := OpenPack(packPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rm.vfs.Mount(point, pack, priority); err != nil {
+		pack.Close()
+		return nil, err
+	}
+	return pack, nil
+}
+
 // Start запускает воркеры для асинхронной загрузки
 func (rm *ResourceManager) Start() {
 	rm.mu.Lock()
@@ -180,10 +246,16 @@ func (rm *ResourceManager) loadWorker() {
 	defer rm.wg.Done()
 
 	for req := range rm.loadQueue {
-		id, err := rm.LoadSync(req.path, req.resType)
-		if req.callback != nil {
+		// Воркер не ждет Upload: колбэк вызовется, когда главный поток завершит загрузку
+		id, call, err := rm.startLoad(req.path, req.resType, nil, true)
+		if req.callback == nil {
+			continue
+		}
+		if err != nil {
 			req.callback(id, err)
+			continue
 		}
+		call.onComplete(req.callback)
 	}
 }
 
@@ -195,30 +267,78 @@ func (rm *ResourceManager) RegisterLoader(loader ResourceLoader) {
 	rm.loaders[loader.GetType()] = loader
 }
 
-// LoadSync синхронно загружает ресурс
+// LoadSync синхронно загружает ресурс вместе с его зависимостями.
+// Загрузчики TwoPhaseLoader выполняют Upload прямо в вызывающем потоке,
+// поэтому LoadSync для них нужно вызывать из главного потока
 func (rm *ResourceManager) LoadSync(path string, resType ResourceType) (ResourceID, error) {
+	return rm.load(path, resType, nil, false)
+}
+
+// load загружает ресурс и ждет завершения. stack - цепочка ресурсов, которые
+// ждут этот ресурс как зависимость (для обнаружения циклов). deferUpload -
+// отправлять Upload в очередь главного потока, а не выполнять на месте
+func (rm *ResourceManager) load(path string, resType ResourceType, stack []ResourceID, deferUpload bool) (ResourceID, error) {
+	id, call, err := rm.startLoad(path, resType, stack, deferUpload)
+	if err != nil {
+		return id, err
+	}
+
+	// В главном потоке обрабатываем очередь Upload, пока ждем
+	rm.wait(call.done, !deferUpload)
+	return id, call.err
+}
+
+// startLoad начинает загрузку ресурса и возвращает операцию, завершение
+// которой можно ждать. Ссылка на ресурс добавляется сразу
+func (rm *ResourceManager) startLoad(path string, resType ResourceType, stack []ResourceID, deferUpload bool) (ResourceID, *loadCall, error) {
+	path, err := CleanPath(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidResourceID, err)
+	}
+	id := ResourceID(path) // Используем path как ID
+
+	for _, parent := range stack {
+		if parent == id {
+			return id, nil, fmt.Errorf("%w: %s", ErrDependencyCycle, path)
+		}
+	}
+
+	rm.mu.Lock()
 	// Проверяем кеш
-	rm.mu.RLock()
 	if cachedID, exists := rm.cache[path]; exists {
-		if res, exists := rm.resources[cachedID]; exists && res.IsLoaded() {
-			res.AddRef()
-			rm.mu.RUnlock()
-			return cachedID, nil
+		if res, exists := rm.resources[cachedID]; exists {
+			if res.IsLoaded() {
+				res.AddRef()
+				rm.mu.Unlock()
+				rm.touch(res)
+				atomic.AddUint64(&rm.stats.Hits, 1)
+				return cachedID, completedCall(cachedID, nil), nil
+			}
+
+			// Ресурс уже грузится другим вызовом - присоединяемся к нему,
+			// если только он сам не ждет кого-то из цепочки (цикл между потоками)
+			if call, loading := rm.loading[cachedID]; loading {
+				if rm.waitsForLocked(cachedID, stack) {
+					rm.mu.Unlock()
+					return id, nil, fmt.Errorf("%w: %s", ErrDependencyCycle, path)
+				}
+				res.AddRef()
+				rm.mu.Unlock()
+				rm.touch(res)
+				atomic.AddUint64(&rm.stats.Hits, 1)
+				return cachedID, call, nil
+			}
 		}
 	}
-	rm.mu.RUnlock()
 
 	// Получаем загрузчик
-	rm.mu.RLock()
 	loader, exists := rm.loaders[resType]
-	rm.mu.RUnlock()
-
 	if !exists {
-		return "", fmt.Errorf("no loader registered for type %s", resType)
+		rm.mu.Unlock()
+		return "", nil, fmt.Errorf("no loader registered for type %s", resType)
 	}
 
 	// Создаем ресурс
-	id := ResourceID(path) // Используем path как ID
 	resource := &Resource{
 		ID:       id,
 		Path:     path,
@@ -226,39 +346,148 @@ func (rm *ResourceManager) LoadSync(path string, resType ResourceType) (Resource
 		State:    ResourceStateLoading,
 		RefCount: 1,
 	}
+	call := newLoadCall()
 
-	rm.mu.Lock()
 	rm.resources[id] = resource
 	rm.cache[path] = id
+	rm.loading[id] = call
+	rm.progress.Total++
+	rm.mu.Unlock()
+	atomic.AddUint64(&rm.stats.Misses, 1)
+
+	// Загружаем данные через VFS
+	data, size, deps, err := rm.loadFromVFS(loader, path, append(stack, id), deferUpload)
+	if err != nil {
+		rm.finishLoad(resource, call, nil, 0, nil, err)
+		return id, call, nil
+	}
+
+	rm.mu.Lock()
+	rm.progress.Decoded++
+	rm.mu.Unlock()
+
+	twoPhase, ok := loader.(TwoPhaseLoader)
+	if !ok {
+		rm.finishLoad(resource, call, data, size, deps, nil)
+		return id, call, nil
+	}
+
+	task := &uploadTask{
+		resource: resource,
+		call:     call,
+		loader:   twoPhase,
+		decoded:  data,
+		size:     size,
+		deps:     deps,
+	}
+	if deferUpload {
+		resource.mu.Lock()
+		resource.State = ResourceStateUploading
+		resource.mu.Unlock()
+		rm.enqueueUpload(task)
+	} else {
+		rm.runUpload(task)
+	}
+
+	return id, call, nil
+}
+
+// finishLoad завершает загрузку ресурса: успешно или с ошибкой
+func (rm *ResourceManager) finishLoad(resource *Resource, call *loadCall, data interface{}, size int64, deps []ResourceID, err error) {
+	rm.mu.Lock()
+	delete(rm.loading, resource.ID)
+	if err != nil {
+		rm.progress.Failed++
+	} else {
+		rm.progress.Completed++
+	}
 	rm.mu.Unlock()
 
-	// Загружаем данные
-	data, err := loader.Load(path)
 	if err != nil {
 		resource.mu.Lock()
 		resource.State = ResourceStateError
 		resource.Error = err
 		resource.mu.Unlock()
-		return id, err
+
+		rm.emit(event.EventResourceError, &event.ResourceErrorData{Path: resource.Path, Error: err})
+		call.complete(resource.ID, err)
+		return
+	}
+
+	rm.mu.RLock()
+	loader := rm.loaders[resource.Type]
+	rm.mu.RUnlock()
+	if sized, ok := loader.(SizedLoader); ok {
+		size = sized.SizeOf(data)
 	}
 
 	// Обновляем ресурс
 	resource.mu.Lock()
 	resource.Data = data
+	resource.Size = size
+	resource.Dependencies = deps
 	resource.State = ResourceStateLoaded
 	resource.mu.Unlock()
+	rm.touch(resource)
 
 	rm.mu.Lock()
 	rm.currentCacheSize += resource.Size
+	rm.typeSizes[resource.Type] += resource.Size
 	rm.mu.Unlock()
 
+	rm.emit(event.EventResourceLoad, &event.ResourceLoadData{Path: resource.Path, Type: string(resource.Type)})
+	call.complete(resource.ID, nil)
+
 	// Проверяем лимит кеша
 	rm.checkCacheSize()
+}
+
+// loadFromVFS открывает файл в VFS, загружает зависимости и передает поток загрузчику
+func (rm *ResourceManager) loadFromVFS(loader ResourceLoader, path string, stack []ResourceID, deferUpload bool) (interface{}, int64, []ResourceID, error) {
+	file, err := rm.vfs.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer file.Close()
 
-	return id, nil
+	// Размер файла используем как оценку занимаемой памяти
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to seek %s: %w", path, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to seek %s: %w", path, err)
+	}
+
+	depLoader, hasDeps := loader.(DependencyLoader)
+	if !hasDeps {
+		data, err := loader.Load(file, path)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		return data, size, nil, nil
+	}
+
+	deps, err := rm.loadDependencies(depLoader, file, path, stack, !deferUpload)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	data, err := depLoader.LoadWithDependencies(file, path, deps)
+	if err != nil {
+		rm.releaseDependencies(deps)
+		return nil, 0, nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	ids := make([]ResourceID, 0, len(deps))
+	for _, dep := range deps {
+		ids = append(ids, dep.ID)
+	}
+	return data, size, ids, nil
 }
 
-// LoadAsync асинхронно загружает ресурс
+// LoadAsync асинхронно загружает ресурс. Для TwoPhaseLoader колбэк вызывается
+// из ProcessUploads в главном потоке, для остальных - из воркера
 func (rm *ResourceManager) LoadAsync(path string, resType ResourceType, callback func(ResourceID, error)) {
 	rm.mu.RLock()
 	running := rm.running
@@ -303,7 +532,8 @@ func (rm *ResourceManager) Get(id ResourceID) (*Resource, error) {
 	return resource, nil
 }
 
-// Unload выгружает ресурс
+// Unload выгружает ресурс. Данные TwoPhaseLoader освобождаются позже,
+// в ProcessUploads главного потока
 func (rm *ResourceManager) Unload(id ResourceID) error {
 	rm.mu.Lock()
 	resource, exists := rm.resources[id]
@@ -321,60 +551,58 @@ func (rm *ResourceManager) Unload(id ResourceID) error {
 		return nil
 	}
 
-	// Удаляем из кеша
-	delete(rm.cache, resource.Path)
-	delete(rm.resources, id)
+	// Без автовыгрузки ресурс остается в кеше до вытеснения политикой
+	resource.mu.RLock()
+	keep := !rm.autoUnload || resource.Pinned
+	resource.mu.RUnlock()
+	if keep {
+		rm.mu.Unlock()
+		rm.checkCacheSize()
+		return nil
+	}
 
-	rm.currentCacheSize -= resource.Size
+	// Удаляем из кеша
+	rm.removeLocked(resource)
 	rm.mu.Unlock()
 
-	// Выгружаем данные
-	loader, exists := rm.loaders[resource.Type]
-	if exists && resource.Data != nil {
-		return loader.Unload(resource.Data)
-	}
-
-	return nil
+	return rm.destroyResource(resource)
 }
 
-// checkCacheSize проверяет размер кеша и выгружает неиспользуемые ресурсы
-func (rm *ResourceManager) checkCacheSize() {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-
-	if rm.maxCacheSize <= 0 || rm.currentCacheSize <= rm.maxCacheSize {
-		return
-	}
+// destroyResource выгружает данные ресурса и освобождает его зависимости.
+// Выгрузка может начаться в любом потоке (вытеснение после загрузки на воркере),
+// поэтому Unload загрузчиков TwoPhaseLoader уходит в очередь главного потока.
+// Вызывается без блокировки rm.mu
+func (rm *ResourceManager) destroyResource(resource *Resource) error {
+	rm.mu.RLock()
+	loader, exists := rm.loaders[resource.Type]
+	rm.mu.RUnlock()
 
-	// Собираем ресурсы с нулевым RefCount
-	toUnload := make([]ResourceID, 0)
-	for id, res := range rm.resources {
-		if res.GetRefCount() == 0 {
-			toUnload = append(toUnload, id)
+	var err error
+	if exists && resource.Data != nil {
+		if twoPhase, ok := loader.(TwoPhaseLoader); ok {
+			rm.enqueueUnload(&unloadTask{path: resource.Path, loader: twoPhase, data: resource.Data})
+		} else {
+			err = loader.Unload(resource.Data)
 		}
 	}
 
-	// Выгружаем до достижения лимита
-	for _, id := range toUnload {
-		if rm.currentCacheSize <= rm.maxCacheSize {
-			break
-		}
-
-		resource := rm.resources[id]
-		delete(rm.cache, resource.Path)
-		delete(rm.resources, id)
-		rm.currentCacheSize -= resource.Size
-
-		// Выгружаем данные
-		loader, exists := rm.loaders[resource.Type]
-		if exists && resource.Data != nil {
-			loader.Unload(resource.Data)
-		}
+	// Каскадно освобождаем зависимости
+	resource.mu.RLock()
+	deps := resource.Dependencies
+	resource.mu.RUnlock()
+	for _, dep := range deps {
+		rm.Unload(dep)
 	}
+
+	rm.emit(event.EventResourceUnload, &event.ResourceLoadData{Path: resource.Path, Type: string(resource.Type)})
+	return err
 }
 
-// Clear очищает все ресурсы
+// Clear очищает все ресурсы. Выгружает данные на месте, в том числе GL-ресурсы
+// и отложенные выгрузки, поэтому вызывается из главного потока
 func (rm *ResourceManager) Clear() {
+	rm.processUnloads()
+
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
@@ -387,6 +615,7 @@ func (rm *ResourceManager) Clear() {
 	}
 
 	rm.cache = make(map[string]ResourceID)
+	rm.typeSizes = make(map[ResourceType]int64)
 	rm.currentCacheSize = 0
 }
 
@@ -0,0 +1,248 @@
+package resource
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
+)
+
+// TwoPhaseLoader загрузчик, которому нужен GL-контекст (текстуры, меши, шейдеры).
+// Load выполняет CPU-часть (чтение и декодирование) и может работать на воркере,
+// Upload получает результат Load и выполняется только в главном потоке
+type TwoPhaseLoader interface {
+	ResourceLoader
+
+	// Upload загружает декодированные данные в GPU и возвращает итоговые данные ресурса
+	Upload(decoded interface{}, path string) (interface{}, error)
+}
+
+// LoadProgress прогресс загрузки ресурсов для экранов загрузки
+type LoadProgress struct {
+	Total          int // Начато загрузок с момента сброса
+	Decoded        int // Прошли CPU-фазу
+	Completed      int // Полностью загружены
+	Failed         int // Завершились ошибкой
+	PendingUploads int // Ждут Upload в главном потоке
+}
+
+// Fraction возвращает долю завершенных загрузок от 0 до 1
+func (p LoadProgress) Fraction() float32 {
+	if p.Total == 0 {
+		return 1
+	}
+	return float32(p.Completed+p.Failed) / float32(p.Total)
+}
+
+// IsDone проверяет, завершены ли все начатые загрузки
+func (p LoadProgress) IsDone() bool {
+	return p.Completed+p.Failed >= p.Total
+}
+
+// loadCall загрузка ресурса, которую могут ждать другие вызовы
+type loadCall struct {
+	done      chan struct{}
+	err       error
+	callbacks []func(ResourceID, error)
+	completed bool
+	id        ResourceID
+	mu        sync.Mutex
+}
+
+// newLoadCall создает незавершенную операцию загрузки
+func newLoadCall() *loadCall {
+	return &loadCall{done: make(chan struct{})}
+}
+
+// completedCall создает уже завершенную операцию (ресурс был в кеше)
+func completedCall(id ResourceID, err error) *loadCall {
+	call := newLoadCall()
+	call.id = id
+	call.err = err
+	call.completed = true
+	close(call.done)
+	return call
+}
+
+// complete завершает операцию и вызывает колбэки
+func (c *loadCall) complete(id ResourceID, err error) {
+	c.mu.Lock()
+	c.id = id
+	c.err = err
+	c.completed = true
+	callbacks := c.callbacks
+	c.callbacks = nil
+	close(c.done)
+	c.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback(id, err)
+	}
+}
+
+// onComplete вызывает колбэк по завершении операции (сразу, если она уже завершена)
+func (c *loadCall) onComplete(callback func(ResourceID, error)) {
+	c.mu.Lock()
+	if !c.completed {
+		c.callbacks = append(c.callbacks, callback)
+		c.mu.Unlock()
+		return
+	}
+	id, err := c.id, c.err
+	c.mu.Unlock()
+
+	callback(id, err)
+}
+
+// uploadTask отложенный Upload для главного потока
+type uploadTask struct {
+	resource *Resource
+	call     *loadCall
+	loader   TwoPhaseLoader
+	decoded  interface{}
+	size     int64
+	deps     []ResourceID
+}
+
+// enqueueUpload ставит Upload в очередь главного потока
+func (rm *ResourceManager) enqueueUpload(task *uploadTask) {
+	rm.uploadMu.Lock()
+	rm.uploads = append(rm.uploads, task)
+	rm.uploadMu.Unlock()
+
+	// Будим главный поток, если он ждет загрузку в LoadSync
+	select {
+	case rm.uploadSignal <- struct{}{}:
+	default:
+	}
+}
+
+// unloadTask отложенный Unload ресурса TwoPhaseLoader для главного потока
+type unloadTask struct {
+	path   string
+	loader TwoPhaseLoader
+	data   interface{}
+}
+
+// enqueueUnload ставит выгрузку GL-ресурса в очередь главного потока
+func (rm *ResourceManager) enqueueUnload(task *unloadTask) {
+	rm.uploadMu.Lock()
+	rm.unloads = append(rm.unloads, task)
+	rm.uploadMu.Unlock()
+}
+
+// processUnloads выполняет все отложенные выгрузки. Ошибки выгрузки
+// публикуются событием EventResourceError
+func (rm *ResourceManager) processUnloads() int {
+	rm.uploadMu.Lock()
+	unloads := rm.unloads
+	rm.unloads = nil
+	rm.uploadMu.Unlock()
+
+	for _, task := range unloads {
+		if err := task.loader.Unload(task.data); err != nil {
+			rm.emit(event.EventResourceError, &event.ResourceErrorData{Path: task.path, Error: err})
+		}
+	}
+	return len(unloads)
+}
+
+// runUpload выполняет Upload и завершает загрузку ресурса
+func (rm *ResourceManager) runUpload(task *uploadTask) {
+	data, err := task.loader.Upload(task.decoded, task.resource.Path)
+	if err != nil {
+		rm.releaseDependencyIDs(task.deps)
+		rm.finishLoad(task.resource, task.call, nil, 0, nil, err)
+		return
+	}
+	rm.finishLoad(task.resource, task.call, data, task.size, task.deps, nil)
+}
+
+// releaseDependencyIDs освобождает ссылки на зависимости по ID
+func (rm *ResourceManager) releaseDependencyIDs(deps []ResourceID) {
+	for _, dep := range deps {
+		rm.Unload(dep)
+	}
+}
+
+// ProcessUploads выполняет в главном потоке отложенные выгрузки GL-ресурсов
+// (всегда все, они дешевые) и Upload, пока не истечет бюджет времени
+// (0 = обработать всю очередь). Хотя бы один Upload выполняется всегда, чтобы
+// загрузка продвигалась. Возвращает количество обработанных задач
+func (rm *ResourceManager) ProcessUploads(budget time.Duration) int {
+	start := time.Now()
+	processed := rm.processUnloads()
+
+	for {
+		rm.uploadMu.Lock()
+		if len(rm.uploads) == 0 {
+			rm.uploadMu.Unlock()
+			break
+		}
+		task := rm.uploads[0]
+		rm.uploads[0] = nil
+		rm.uploads = rm.uploads[1:]
+		rm.uploadMu.Unlock()
+
+		rm.runUpload(task)
+		processed++
+
+		// Upload мог вытеснить ресурсы из кеша
+		processed += rm.processUnloads()
+
+		if budget > 0 && time.Since(start) >= budget {
+			break
+		}
+	}
+
+	return processed
+}
+
+// PendingUploads возвращает количество Upload, ожидающих главного потока
+func (rm *ResourceManager) PendingUploads() int {
+	rm.uploadMu.Lock()
+	defer rm.uploadMu.Unlock()
+	return len(rm.uploads)
+}
+
+// PendingUnloads возвращает количество выгрузок GL-ресурсов, ожидающих главного потока
+func (rm *ResourceManager) PendingUnloads() int {
+	rm.uploadMu.Lock()
+	defer rm.uploadMu.Unlock()
+	return len(rm.unloads)
+}
+
+// wait ждет закрытия done. В главном потоке (pump) параллельно обрабатывает
+// очередь Upload, иначе загрузка, ждущая главный поток, никогда не завершится
+func (rm *ResourceManager) wait(done <-chan struct{}, pump bool) {
+	if !pump {
+		<-done
+		return
+	}
+
+	for {
+		rm.ProcessUploads(0)
+		select {
+		case <-done:
+			return
+		case <-rm.uploadSignal:
+		}
+	}
+}
+
+// GetLoadProgress возвращает прогресс загрузки с момента последнего сброса
+func (rm *ResourceManager) GetLoadProgress() LoadProgress {
+	rm.mu.RLock()
+	progress := rm.progress
+	rm.mu.RUnlock()
+
+	progress.PendingUploads = rm.PendingUploads()
+	return progress
+}
+
+// ResetLoadProgress сбрасывает счетчики прогресса (например, перед загрузкой уровня)
+func (rm *ResourceManager) ResetLoadProgress() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.progress = LoadProgress{}
+}
@@ -0,0 +1,479 @@
+package resource
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Ошибки виртуальной файловой системы
+var (
+	ErrFileNotFound = errors.New("file not found")
+	ErrInvalidPath  = errors.New("invalid virtual path")
+)
+
+// File открытый файл виртуальной файловой системы
+type File interface {
+	io.ReadSeekCloser
+}
+
+// FileSystem источник файлов, который можно смонтировать в VFS
+type FileSystem interface {
+	// Open открывает файл по относительному пути (разделитель "/")
+	Open(name string) (File, error)
+
+	// Exists проверяет наличие файла
+	Exists(name string) bool
+}
+
+// FileSizer файловая система, которая знает размер файла без его открытия
+type FileSizer interface {
+	Size(name string) (int64, error)
+}
+
+// CleanPath приводит виртуальный путь к каноническому виду:
+// разделитель "/", без ведущего слеша и без выхода за корень через ".."
+func CleanPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = path.Clean("/" + name)
+	name = strings.TrimPrefix(name, "/")
+	if name == "" || name == "." {
+		return "", ErrInvalidPath
+	}
+	return name, nil
+}
+
+// mountPoint точка монтирования
+type mountPoint struct {
+	prefix   string
+	fs       FileSystem
+	priority int
+	order    int
+}
+
+// match возвращает путь относительно точки монтирования
+func (m *mountPoint) match(name string) (string, bool) {
+	if m.prefix == "" {
+		return name, true
+	}
+	if name == m.prefix {
+		return "", false
+	}
+	if strings.HasPrefix(name, m.prefix+"/") {
+		return name[len(m.prefix)+1:], true
+	}
+	return "", false
+}
+
+// VFS виртуальная файловая система с точками монтирования.
+// При совпадении путей побеждает точка с большим приоритетом,
+// при равном приоритете - смонтированная позже (оверлей для модов)
+type VFS struct {
+	mounts    []*mountPoint
+	nextOrder int
+	mu        sync.RWMutex
+}
+
+// NewVFS создает пустую виртуальную файловую систему
+func NewVFS() *VFS {
+	return &VFS{
+		mounts: make([]*mountPoint, 0),
+	}
+}
+
+// Mount монтирует файловую систему в точку point ("" - корень)
+func (v *VFS) Mount(point string, fsys FileSystem, priority int) error {
+	prefix := ""
+	if strings.Trim(point, "/\\") != "" {
+		var err error
+		prefix, err = CleanPath(point)
+		if err != nil {
+			return err
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.mounts = append(v.mounts, &mountPoint{
+		prefix:   prefix,
+		fs:       fsys,
+		priority: priority,
+		order:    v.nextOrder,
+	})
+	v.nextOrder++
+
+	// Сортируем: выше приоритет и более поздние монтирования - первыми
+	sort.SliceStable(v.mounts, func(i, j int) bool {
+		if v.mounts[i].priority != v.mounts[j].priority {
+			return v.mounts[i].priority > v.mounts[j].priority
+		}
+		return v.mounts[i].order > v.mounts[j].order
+	})
+
+	return nil
+}
+
+// Unmount отмонтирует файловую систему. Возвращает false, если она не была смонтирована
+func (v *VFS) Unmount(fsys FileSystem) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for i, m := range v.mounts {
+		if m.fs == fsys {
+			v.mounts = append(v.mounts[:i], v.mounts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Open открывает файл из смонтированной файловой системы с наивысшим приоритетом
+func (v *VFS) Open(name string) (File, error) {
+	clean, err := CleanPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, name)
+	}
+
+	v.mu.RLock()
+	mounts := make([]*mountPoint, len(v.mounts))
+	copy(mounts, v.mounts)
+	v.mu.RUnlock()
+
+	for _, m := range mounts {
+		rel, ok := m.match(clean)
+		if !ok {
+			continue
+		}
+
+		file, err := m.fs.Open(rel)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, ErrFileNotFound) && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrFileNotFound, clean)
+}
+
+// Size возвращает размер файла (после распаковки) без чтения содержимого,
+// если смонтированная файловая система это поддерживает
+func (v *VFS) Size(name string) (int64, error) {
+	clean, err := CleanPath(name)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, name)
+	}
+
+	v.mu.RLock()
+	mounts := make([]*mountPoint, len(v.mounts))
+	copy(mounts, v.mounts)
+	v.mu.RUnlock()
+
+	for _, m := range mounts {
+		rel, ok := m.match(clean)
+		if !ok || !m.fs.Exists(rel) {
+			continue
+		}
+
+		if sizer, ok := m.fs.(FileSizer); ok {
+			return sizer.Size(rel)
+		}
+
+		file, err := m.fs.Open(rel)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		return file.Seek(0, io.SeekEnd)
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrFileNotFound, clean)
+}
+
+// Exists проверяет, есть ли файл хотя бы в одной точке монтирования
+func (v *VFS) Exists(name string) bool {
+	clean, err := CleanPath(name)
+	if err != nil {
+		return false
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for _, m := range v.mounts {
+		if rel, ok := m.match(clean); ok && m.fs.Exists(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// MountCount возвращает количество точек монтирования
+func (v *VFS) MountCount() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.mounts)
+}
+
+// memoryFile файл в памяти
+type memoryFile struct {
+	*bytes.Reader
+}
+
+// Close ничего не делает для файлов в памяти
+func (f memoryFile) Close() error {
+	return nil
+}
+
+// newMemoryFile создает файл поверх среза байт
+func newMemoryFile(data []byte) File {
+	return memoryFile{Reader: bytes.NewReader(data)}
+}
+
+// DirFS файловая система поверх директории ОС
+type DirFS struct {
+	Root string
+}
+
+// NewDirFS создает файловую систему поверх директории
+func NewDirFS(root string) *DirFS {
+	return &DirFS{Root: root}
+}
+
+// Open открывает файл из директории
+func (d *DirFS) Open(name string) (File, error) {
+	file, err := os.Open(filepath.Join(d.Root, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Exists проверяет наличие файла в директории
+func (d *DirFS) Exists(name string) bool {
+	info, err := os.Stat(filepath.Join(d.Root, filepath.FromSlash(name)))
+	return err == nil && !info.IsDir()
+}
+
+// Size возвращает размер файла в директории
+func (d *DirFS) Size(name string) (int64, error) {
+	info, err := os.Stat(filepath.Join(d.Root, filepath.FromSlash(name)))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// ZipFS файловая система поверх zip-архива
+type ZipFS struct {
+	reader *zip.Reader
+	closer io.Closer
+	files  map[string]*zip.File
+}
+
+// OpenZipFS открывает zip-архив с диска
+func OpenZipFS(archivePath string) (*ZipFS, error) {
+	rc, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive %s: %w", archivePath, err)
+	}
+
+	z := newZipFS(&rc.Reader)
+	z.closer = rc
+	return z, nil
+}
+
+// NewZipFS создает файловую систему поверх zip-архива в памяти или другом источнике
+func NewZipFS(r io.ReaderAt, size int64) (*ZipFS, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zip archive: %w", err)
+	}
+	return newZipFS(reader), nil
+}
+
+// newZipFS индексирует файлы архива
+func newZipFS(reader *zip.Reader) *ZipFS {
+	z := &ZipFS{
+		reader: reader,
+		files:  make(map[string]*zip.File),
+	}
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if name, err := CleanPath(f.Name); err == nil {
+			z.files[name] = f
+		}
+	}
+	return z
+}
+
+// Open распаковывает файл из архива в память (записи zip не поддерживают Seek)
+func (z *ZipFS) Open(name string) (File, error) {
+	f, exists := z.files[name]
+	if !exists {
+		return nil, ErrFileNotFound
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", name, err)
+	}
+	return newMemoryFile(data), nil
+}
+
+// Exists проверяет наличие файла в архиве
+func (z *ZipFS) Exists(name string) bool {
+	_, exists := z.files[name]
+	return exists
+}
+
+// Size возвращает распакованный размер файла в архиве
+func (z *ZipFS) Size(name string) (int64, error) {
+	f, exists := z.files[name]
+	if !exists {
+		return 0, ErrFileNotFound
+	}
+	return int64(f.UncompressedSize64), nil
+}
+
+// Close закрывает архив, если он был открыт с диска
+func (z *ZipFS) Close() error {
+	if z.closer != nil {
+		return z.closer.Close()
+	}
+	return nil
+}
+
+// IOFS файловая система поверх fs.FS (например, embed.FS)
+type IOFS struct {
+	FS fs.FS
+}
+
+// NewIOFS создает файловую систему поверх fs.FS
+func NewIOFS(fsys fs.FS) *IOFS {
+	return &IOFS{FS: fsys}
+}
+
+// Open открывает файл из fs.FS
+func (f *IOFS) Open(name string) (File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Файлы embed.FS поддерживают Seek, остальные читаем в память
+	if rsc, ok := file.(File); ok {
+		return rsc, nil
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return newMemoryFile(data), nil
+}
+
+// Exists проверяет наличие файла в fs.FS
+func (f *IOFS) Exists(name string) bool {
+	info, err := fs.Stat(f.FS, name)
+	return err == nil && !info.IsDir()
+}
+
+// Size возвращает размер файла в fs.FS
+func (f *IOFS) Size(name string) (int64, error) {
+	info, err := fs.Stat(f.FS, name)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// MemFS файловая система в памяти
+type MemFS struct {
+	files map[string][]byte
+	mu    sync.RWMutex
+}
+
+// NewMemFS создает пустую файловую систему в памяти
+func NewMemFS() *MemFS {
+	return &MemFS{
+		files: make(map[string][]byte),
+	}
+}
+
+// Add добавляет или заменяет файл
+func (m *MemFS) Add(name string, data []byte) error {
+	clean, err := CleanPath(name)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[clean] = data
+	return nil
+}
+
+// Remove удаляет файл
+func (m *MemFS) Remove(name string) {
+	clean, err := CleanPath(name)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, clean)
+}
+
+// Open открывает файл из памяти
+func (m *MemFS) Open(name string) (File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, exists := m.files[name]
+	if !exists {
+		return nil, ErrFileNotFound
+	}
+	return newMemoryFile(data), nil
+}
+
+// Exists проверяет наличие файла в памяти
+func (m *MemFS) Exists(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists := m.files[name]
+	return exists
+}
+
+// Size возвращает размер файла в памяти
+func (m *MemFS) Size(name string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, exists := m.files[name]
+	if !exists {
+		return 0, ErrFileNotFound
+	}
+	return int64(len(data)), nil
+}
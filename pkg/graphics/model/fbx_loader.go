@@ -33,6 +33,20 @@ func LoadFBXSimple(fbxPath string, texturePath string) (*Model, error) {
 
 	// Создаём простую геометрию (куб с текстурными координатами)
 	// В реальности здесь должен быть парсинг FBX, но для простоты используем куб
+	mesh := cubeMesh()
+	mesh.Texture = textureID
+
+	// Создаём VAO, VBO, EBO
+	setupMesh(&mesh)
+
+	model.AddMesh(mesh)
+
+	return model, nil
+}
+
+// cubeMesh создает единичный куб с нормалями и текстурными координатами -
+// упрощенную замену геометрии FBX
+func cubeMesh() Mesh {
 	vertices := []Vertex{
 		// Front face
 		{Position: mgl32.Vec3{-0.5, -0.5, 0.5}, Normal: mgl32.Vec3{0, 0, 1}, TexCoords: mgl32.Vec2{0, 0}},
@@ -81,19 +95,10 @@ func LoadFBXSimple(fbxPath string, texturePath string) (*Model, error) {
 		20, 21, 22, 22, 23, 20, // Bottom
 	}
 
-	// Создаём меш
-	mesh := Mesh{
+	return Mesh{
 		Vertices: vertices,
 		Indices:  indices,
-		Texture:  textureID,
 	}
-
-	// Создаём VAO, VBO, EBO
-	setupMesh(&mesh)
-
-	model.AddMesh(mesh)
-
-	return model, nil
 }
 
 // setupMesh настраивает OpenGL буферы для меша
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/resource"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Loader загрузчик мешей для resource.ResourceManager. Читает меши, собранные
+// animo-pack (RawMesh), OBJ из обычных директорий и FBX (упрощенно, как
+// LoadFBXSimple). Разбор идет на воркерах, создание буферов OpenGL - в главном
+// потоке через ProcessUploads. Данные ресурса - *Model
+type Loader struct {
+	// Textures текстура модели по ее виртуальному пути. Путь текстуры задается
+	// относительно модели; текстура загружается как зависимость (texture.Loader)
+	// и выгружается вместе с моделью
+	Textures map[string]string
+}
+
+// rawMeshStride количество float32 на вершину RawMesh: position, normal, uv
+const rawMeshStride = 8
+
+// Load разбирает меш в вершины модели без текстуры (CPU-фаза)
+func (l Loader) Load(reader io.ReadSeeker, path string) (interface{}, error) {
+	return l.LoadWithDependencies(reader, path, nil)
+}
+
+// Dependencies возвращает текстуру модели из Textures
+func (l Loader) Dependencies(reader io.ReadSeeker, modelPath string) ([]resource.Dependency, error) {
+	texturePath, ok := l.Textures[modelPath]
+	if !ok {
+		return nil, nil
+	}
+	return []resource.Dependency{{
+		Path: resource.ResolvePath(modelPath, texturePath),
+		Type: resource.ResourceTypeTexture,
+	}}, nil
+}
+
+// LoadWithDependencies разбирает меш и назначает ему загруженную текстуру
+func (l Loader) LoadWithDependencies(reader io.ReadSeeker, modelPath string, deps map[string]*resource.Resource) (interface{}, error) {
+	var mesh Mesh
+	if strings.EqualFold(path.Ext(modelPath), ".fbx") {
+		// Парсинга FBX нет, как и в LoadFBXSimple
+		mesh = cubeMesh()
+	} else {
+		var err error
+		if mesh, err = loadMesh(reader, modelPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, dep := range deps {
+		if dep.Type != resource.ResourceTypeTexture {
+			continue
+		}
+		textureID, ok := dep.Data.(uint32)
+		if !ok {
+			return nil, fmt.Errorf("unexpected texture data %T for %s", dep.Data, modelPath)
+		}
+		mesh.Texture = textureID
+	}
+
+	model := NewModel()
+	model.FilePath = modelPath
+	model.AddMesh(mesh)
+	return model, nil
+}
+
+// loadMesh разбирает RawMesh или OBJ в вершины меша
+func loadMesh(reader io.Reader, path string) (Mesh, error) {
+	raw, err := decodeMesh(reader)
+	if err != nil {
+		return Mesh{}, err
+	}
+	if raw.Stride != rawMeshStride {
+		return Mesh{}, fmt.Errorf("unsupported mesh stride %d in %s", raw.Stride, path)
+	}
+
+	mesh := Mesh{
+		Vertices: make([]Vertex, 0, len(raw.Vertices)/raw.Stride),
+		Indices:  raw.Indices,
+	}
+	for i := 0; i+raw.Stride <= len(raw.Vertices); i += raw.Stride {
+		v := raw.Vertices[i : i+raw.Stride]
+		mesh.Vertices = append(mesh.Vertices, Vertex{
+			Position:  mgl32.Vec3{v[0], v[1], v[2]},
+			Normal:    mgl32.Vec3{v[3], v[4], v[5]},
+			TexCoords: mgl32.Vec2{v[6], v[7]},
+		})
+	}
+	for _, index := range mesh.Indices {
+		if int(index) >= len(mesh.Vertices) {
+			return Mesh{}, fmt.Errorf("%w: index %d out of range in %s", resource.ErrInvalidRawData, index, path)
+		}
+	}
+	return mesh, nil
+}
+
+// decodeMesh читает RawMesh, а если заголовка нет - разбирает OBJ
+func decodeMesh(reader io.Reader) (*resource.RawMesh, error) {
+	// Сырые данные читаем из исходного потока: декодер сверяет размеры с его длиной
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		if prefix, err := resource.PeekMagic(seeker); err == nil && resource.IsRawMesh(prefix) {
+			return resource.DecodeRawMesh(seeker)
+		}
+	}
+
+	buffered := bufio.NewReader(reader)
+	if prefix, err := buffered.Peek(4); err == nil && resource.IsRawMesh(prefix) {
+		return resource.DecodeRawMesh(buffered)
+	}
+	return resource.ParseOBJ(buffered)
+}
+
+// Upload создает буферы OpenGL для мешей модели (требует GL-контекст)
+func (Loader) Upload(decoded interface{}, path string) (interface{}, error) {
+	model, ok := decoded.(*Model)
+	if !ok {
+		return nil, fmt.Errorf("unexpected decoded model data %T", decoded)
+	}
+	for i := range model.Meshes {
+		setupMesh(&model.Meshes[i])
+	}
+	return model, nil
+}
+
+// Unload удаляет буферы OpenGL модели. Текстуру освобождает менеджер ресурсов
+func (Loader) Unload(data interface{}) error {
+	if model, ok := data.(*Model); ok {
+		model.Delete()
+	}
+	return nil
+}
+
+// SizeOf оценивает память модели по размеру вершинных и индексных буферов
+func (Loader) SizeOf(data interface{}) int64 {
+	model, ok := data.(*Model)
+	if !ok {
+		return 0
+	}
+	var size int64
+	for _, mesh := range model.Meshes {
+		size += int64(len(mesh.Vertices))*rawMeshStride*4 + int64(len(mesh.Indices))*4
+	}
+	return size
+}
+
+// GetType возвращает тип ресурсов загрузчика
+func (Loader) GetType() resource.ResourceType {
+	return resource.ResourceTypeMesh
+}
+
+// Delete удаляет буферы OpenGL всех мешей модели
+func (m *Model) Delete() {
+	for i := range m.Meshes {
+		mesh := &m.Meshes[i]
+		gl.DeleteVertexArrays(1, &mesh.VAO)
+		gl.DeleteBuffers(1, &mesh.VBO)
+		gl.DeleteBuffers(1, &mesh.EBO)
+		mesh.VAO, mesh.VBO, mesh.EBO = 0, 0, 0
+	}
+}
@@ -1,12 +1,15 @@
 package texture
 
 import (
+	"bufio"
 	"fmt"
 	"image"
 	"image/draw"
 	_ "image/png"
+	"io"
 	"os"
 
+	"github.com/Salamander5876/AnimoEngine/pkg/core/resource"
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
@@ -19,16 +22,25 @@ func LoadTexture(filepath string) (uint32, error) {
 	}
 	defer imgFile.Close()
 
-	// Декодируем изображение
-	img, _, err := image.Decode(imgFile)
+	texture, err := LoadTextureFromReader(imgFile)
 	if err != nil {
-		return 0, fmt.Errorf("failed to decode texture %s: %v", filepath, err)
+		return 0, fmt.Errorf("failed to load texture %s: %v", filepath, err)
 	}
+	return texture, nil
+}
 
-	// Конвертируем в RGBA
-	rgba := image.NewRGBA(img.Bounds())
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+// LoadTextureFromReader загружает текстуру из потока (например, файла VFS).
+// Поддерживает как обычные изображения, так и RGBA8 из pack-файлов
+func LoadTextureFromReader(reader io.Reader) (uint32, error) {
+	rgba, err := decodeRGBA(reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode texture: %v", err)
+	}
+	return uploadRGBA(rgba), nil
+}
 
+// uploadRGBA создает OpenGL текстуру из RGBA изображения
+func uploadRGBA(rgba *image.RGBA) uint32 {
 	// Создаём текстуру в OpenGL
 	var texture uint32
 	gl.GenTextures(1, &texture)
@@ -60,7 +72,63 @@ func LoadTexture(filepath string) (uint32, error) {
 
 	gl.BindTexture(gl.TEXTURE_2D, 0)
 
-	return texture, nil
+	return texture
+}
+
+// decodeRGBA декодирует изображение в RGBA, сырые данные берутся без конвертации
+func decodeRGBA(reader io.Reader) (*image.RGBA, error) {
+	// Сырые данные читаем из исходного потока: декодер сверяет размеры с его длиной
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		if prefix, err := resource.PeekMagic(seeker); err == nil && resource.IsRawImage(prefix) {
+			return resource.DecodeRawImage(seeker)
+		}
+	}
+
+	buffered := bufio.NewReader(reader)
+	if prefix, err := buffered.Peek(4); err == nil && resource.IsRawImage(prefix) {
+		return resource.DecodeRawImage(buffered)
+	}
+
+	img, _, err := image.Decode(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+	return rgba, nil
+}
+
+// Loader загрузчик текстур для resource.ResourceManager. Декодирование идет
+// на воркерах, создание OpenGL текстуры - в главном потоке через ProcessUploads.
+// Данные ресурса - ID текстуры OpenGL (uint32)
+type Loader struct{}
+
+// Load декодирует изображение в RGBA (CPU-фаза)
+func (Loader) Load(reader io.ReadSeeker, path string) (interface{}, error) {
+	return decodeRGBA(reader)
+}
+
+// Upload создает OpenGL текстуру (требует GL-контекст)
+func (Loader) Upload(decoded interface{}, path string) (interface{}, error) {
+	rgba, ok := decoded.(*image.RGBA)
+	if !ok {
+		return nil, fmt.Errorf("unexpected decoded texture data %T", decoded)
+	}
+	return uploadRGBA(rgba), nil
+}
+
+// Unload удаляет OpenGL текстуру
+func (Loader) Unload(data interface{}) error {
+	if texture, ok := data.(uint32); ok {
+		Cleanup(texture)
+	}
+	return nil
+}
+
+// GetType возвращает тип ресурсов загрузчика
+func (Loader) GetType() resource.ResourceType {
+	return resource.ResourceTypeTexture
 }
 
 // Cleanup удаляет текстуру
@@ -0,0 +1,443 @@
+package physics
+
+import (
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// nullNode отсутствующий узел дерева
+const nullNode int32 = -1
+
+// treeNode узел динамического AABB-дерева
+type treeNode struct {
+	aabb   customMath.AABB // Для листьев - расширенный AABB тела
+	parent int32
+	left   int32
+	right  int32
+	height int32 // 0 для листа, -1 для свободного узла
+	body   *RigidBody
+	index  int32  // Индекс тела в списке последнего Update
+	stamp  uint32 // Номер Update, в котором тело последний раз встречалось
+}
+
+// isLeaf проверяет, является ли узел листом
+func (n *treeNode) isLeaf() bool {
+	return n.left == nullNode
+}
+
+// DynamicAABBTree динамическое дерево ограничивающих объемов (как в Box2D).
+// Листья хранят расширенные AABB, поэтому медленно движущиеся тела
+// не требуют перестройки дерева каждый кадр
+type DynamicAABBTree struct {
+	// Margin запас, на который расширяются AABB листьев
+	Margin float32
+
+	// Prediction множитель скорости тела для расширения AABB по направлению движения
+	Prediction float32
+
+	nodes   []treeNode
+	root    int32
+	free    int32
+	proxies map[*RigidBody]int32
+	bodies  []*RigidBody
+	stamp   uint32
+	stack   []int32
+	pairs   []bodyPair
+}
+
+// NewDynamicAABBTree создает пустое дерево
+func NewDynamicAABBTree() *DynamicAABBTree {
+	return &DynamicAABBTree{
+		Margin:     0.1,
+		Prediction: 1.0 / 30.0,
+		root:       nullNode,
+		free:       nullNode,
+		proxies:    make(map[*RigidBody]int32),
+	}
+}
+
+// Update добавляет новые тела, удаляет исчезнувшие и перестраивает листья
+// тел, вышедших за свой расширенный AABB
+func (t *DynamicAABBTree) Update(bodies []*RigidBody) {
+	t.stamp++
+
+	for i, body := range bodies {
+		aabb := body.GetAABB()
+
+		leaf, exists := t.proxies[body]
+		if !exists {
+			leaf = t.createProxy(body, t.fatten(body, aabb))
+		} else if !t.nodes[leaf].aabb.ContainsAABB(aabb) {
+			t.removeLeaf(leaf)
+			t.nodes[leaf].aabb = t.fatten(body, aabb)
+			t.insertLeaf(leaf)
+		}
+
+		t.nodes[leaf].index = int32(i)
+		t.nodes[leaf].stamp = t.stamp
+	}
+
+	// Удаляем тела, которых больше нет в списке
+	for _, body := range t.bodies {
+		leaf, exists := t.proxies[body]
+		if exists && t.nodes[leaf].stamp != t.stamp {
+			t.destroyProxy(body, leaf)
+		}
+	}
+
+	t.bodies = append(t.bodies[:0], bodies...)
+}
+
+// fatten расширяет AABB на запас и по направлению движения тела
+func (t *DynamicAABBTree) fatten(body *RigidBody, aabb customMath.AABB) customMath.AABB {
+	fat := aabb.Expand(t.Margin)
+
+	displacement := body.Velocity.Mul(t.Prediction)
+	for axis := 0; axis < 3; axis++ {
+		if displacement[axis] < 0 {
+			fat.Min[axis] += displacement[axis]
+		} else {
+			fat.Max[axis] += displacement[axis]
+		}
+	}
+	return fat
+}
+
+// Pairs ищет пары, запрашивая дерево расширенным AABB каждого тела.
+// Статические тела дерево не опрашивают: их пары находятся со стороны подвижных
+func (t *DynamicAABBTree) Pairs(callback func(a, b *RigidBody)) {
+	t.pairs = t.pairs[:0]
+
+	for i, body := range t.bodies {
+		if body.Type == Static {
+			continue
+		}
+
+		leaf := t.proxies[body]
+		current := int32(i)
+
+		t.query(t.nodes[leaf].aabb, func(other int32) bool {
+			// Пару двух подвижных тел учитываем один раз - со стороны меньшего индекса
+			node := &t.nodes[other]
+			if node.index == current || (node.index < current && node.body.Type != Static) {
+				return true
+			}
+
+			if node.index < current {
+				t.pairs = append(t.pairs, bodyPair{node.index, current})
+			} else {
+				t.pairs = append(t.pairs, bodyPair{current, node.index})
+			}
+			return true
+		})
+	}
+
+	sortPairs(t.pairs)
+	for _, pair := range t.pairs {
+		callback(t.bodies[pair.a], t.bodies[pair.b])
+	}
+}
+
+// Query обходит дерево и вызывает callback для пересекающихся листьев
+func (t *DynamicAABBTree) Query(aabb customMath.AABB, callback func(body *RigidBody) bool) {
+	t.query(aabb, func(leaf int32) bool {
+		return callback(t.nodes[leaf].body)
+	})
+}
+
+// query обходит дерево без рекурсии
+func (t *DynamicAABBTree) query(aabb customMath.AABB, callback func(leaf int32) bool) {
+	if t.root == nullNode {
+		return
+	}
+
+	stack := append(t.stack[:0], t.root)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := &t.nodes[id]
+		if !overlaps(&node.aabb, &aabb) {
+			continue
+		}
+
+		if node.isLeaf() {
+			if !callback(id) {
+				break
+			}
+		} else {
+			stack = append(stack, node.left, node.right)
+		}
+	}
+	t.stack = stack[:0]
+}
+
+// Raycast обходит дерево, отбрасывая узлы, которые луч не пересекает.
+// Найденное попадание укорачивает луч, и дальние ветви не посещаются
+func (t *DynamicAABBTree) Raycast(ray customMath.Ray, maxDistance float32, callback func(body *RigidBody) float32) {
+	if t.root == nullNode {
+		return
+	}
+
+	stack := append(t.stack[:0], t.root)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := &t.nodes[id]
+		if _, _, ok := rayAABB(ray.Origin, ray.Direction, &node.aabb, maxDistance); !ok {
+			continue
+		}
+
+		if node.isLeaf() {
+			if maxDistance = callback(node.body); maxDistance <= 0 {
+				break
+			}
+		} else {
+			stack = append(stack, node.left, node.right)
+		}
+	}
+	t.stack = stack[:0]
+}
+
+// Height возвращает высоту дерева
+func (t *DynamicAABBTree) Height() int {
+	if t.root == nullNode {
+		return 0
+	}
+	return int(t.nodes[t.root].height)
+}
+
+// ProxyCount возвращает количество тел в дереве
+func (t *DynamicAABBTree) ProxyCount() int {
+	return len(t.proxies)
+}
+
+// createProxy создает лист для тела
+func (t *DynamicAABBTree) createProxy(body *RigidBody, aabb customMath.AABB) int32 {
+	leaf := t.allocateNode()
+	t.nodes[leaf].aabb = aabb
+	t.nodes[leaf].body = body
+	t.nodes[leaf].height = 0
+	t.insertLeaf(leaf)
+	t.proxies[body] = leaf
+	return leaf
+}
+
+// destroyProxy удаляет лист тела
+func (t *DynamicAABBTree) destroyProxy(body *RigidBody, leaf int32) {
+	t.removeLeaf(leaf)
+	t.freeNode(leaf)
+	delete(t.proxies, body)
+}
+
+// allocateNode берет узел из списка свободных или создает новый
+func (t *DynamicAABBTree) allocateNode() int32 {
+	if t.free == nullNode {
+		t.nodes = append(t.nodes, treeNode{parent: nullNode, left: nullNode, right: nullNode, height: -1})
+		return int32(len(t.nodes) - 1)
+	}
+
+	id := t.free
+	t.free = t.nodes[id].parent
+	t.nodes[id] = treeNode{parent: nullNode, left: nullNode, right: nullNode}
+	return id
+}
+
+// freeNode возвращает узел в список свободных
+func (t *DynamicAABBTree) freeNode(id int32) {
+	t.nodes[id] = treeNode{parent: t.free, left: nullNode, right: nullNode, height: -1}
+	t.free = id
+}
+
+// insertLeaf вставляет лист, выбирая соседа по минимальному приросту площади
+func (t *DynamicAABBTree) insertLeaf(leaf int32) {
+	if t.root == nullNode {
+		t.root = leaf
+		t.nodes[leaf].parent = nullNode
+		return
+	}
+
+	leafAABB := t.nodes[leaf].aabb
+	index := t.root
+	for !t.nodes[index].isLeaf() {
+		node := &t.nodes[index]
+		area := node.aabb.SurfaceArea()
+		combinedArea := node.aabb.Merge(leafAABB).SurfaceArea()
+
+		// Стоимость создания нового родителя для узла и листа
+		cost := 2 * combinedArea
+		// Минимальная стоимость спуска ниже
+		inheritance := 2 * (combinedArea - area)
+
+		costLeft := t.descendCost(node.left, leafAABB, inheritance)
+		costRight := t.descendCost(node.right, leafAABB, inheritance)
+
+		if cost < costLeft && cost < costRight {
+			break
+		}
+		if costLeft < costRight {
+			index = node.left
+		} else {
+			index = node.right
+		}
+	}
+
+	sibling := index
+	oldParent := t.nodes[sibling].parent
+	newParent := t.allocateNode()
+	t.nodes[newParent].parent = oldParent
+	t.nodes[newParent].aabb = leafAABB.Merge(t.nodes[sibling].aabb)
+	t.nodes[newParent].height = t.nodes[sibling].height + 1
+	t.nodes[newParent].left = sibling
+	t.nodes[newParent].right = leaf
+	t.nodes[sibling].parent = newParent
+	t.nodes[leaf].parent = newParent
+
+	if oldParent == nullNode {
+		t.root = newParent
+	} else if t.nodes[oldParent].left == sibling {
+		t.nodes[oldParent].left = newParent
+	} else {
+		t.nodes[oldParent].right = newParent
+	}
+
+	t.refit(newParent)
+}
+
+// descendCost стоимость вставки листа в поддерево child
+func (t *DynamicAABBTree) descendCost(child int32, leafAABB customMath.AABB, inheritance float32) float32 {
+	merged := leafAABB.Merge(t.nodes[child].aabb).SurfaceArea()
+	if t.nodes[child].isLeaf() {
+		return merged + inheritance
+	}
+	return merged - t.nodes[child].aabb.SurfaceArea() + inheritance
+}
+
+// removeLeaf убирает лист из дерева (узел остается выделенным)
+func (t *DynamicAABBTree) removeLeaf(leaf int32) {
+	if leaf == t.root {
+		t.root = nullNode
+		return
+	}
+
+	parent := t.nodes[leaf].parent
+	grandParent := t.nodes[parent].parent
+	sibling := t.nodes[parent].left
+	if sibling == leaf {
+		sibling = t.nodes[parent].right
+	}
+
+	if grandParent == nullNode {
+		t.root = sibling
+		t.nodes[sibling].parent = nullNode
+		t.freeNode(parent)
+		return
+	}
+
+	// Соединяем соседа с дедушкой и удаляем родителя
+	if t.nodes[grandParent].left == parent {
+		t.nodes[grandParent].left = sibling
+	} else {
+		t.nodes[grandParent].right = sibling
+	}
+	t.nodes[sibling].parent = grandParent
+	t.freeNode(parent)
+
+	t.refit(grandParent)
+}
+
+// refit поднимается к корню, балансируя узлы и обновляя их AABB и высоту
+func (t *DynamicAABBTree) refit(index int32) {
+	for index != nullNode {
+		index = t.balance(index)
+
+		node := &t.nodes[index]
+		left := &t.nodes[node.left]
+		right := &t.nodes[node.right]
+		node.height = 1 + max32(left.height, right.height)
+		node.aabb = left.aabb.Merge(right.aabb)
+
+		index = node.parent
+	}
+}
+
+// balance выполняет поворот, если поддеревья узла отличаются по высоте больше чем на 1.
+// Возвращает индекс узла, ставшего корнем поддерева
+func (t *DynamicAABBTree) balance(a int32) int32 {
+	nodeA := &t.nodes[a]
+	if nodeA.isLeaf() || nodeA.height < 2 {
+		return a
+	}
+
+	b, c := nodeA.left, nodeA.right
+	diff := t.nodes[c].height - t.nodes[b].height
+
+	if diff > 1 {
+		return t.rotate(a, c, b, true)
+	}
+	if diff < -1 {
+		return t.rotate(a, b, c, false)
+	}
+	return a
+}
+
+// rotate поднимает более высокого ребенка up на место узла a.
+// other - второй ребенок a, upIsRight - был ли up правым ребенком
+func (t *DynamicAABBTree) rotate(a, up, other int32, upIsRight bool) int32 {
+	f := t.nodes[up].left
+	g := t.nodes[up].right
+
+	// up становится на место a
+	t.nodes[up].left = a
+	t.nodes[up].parent = t.nodes[a].parent
+	t.nodes[a].parent = up
+
+	if parent := t.nodes[up].parent; parent != nullNode {
+		if t.nodes[parent].left == a {
+			t.nodes[parent].left = up
+		} else {
+			t.nodes[parent].right = up
+		}
+	} else {
+		t.root = up
+	}
+
+	// Более высокий внук остается у up, более низкий переходит к a
+	keep, move := f, g
+	if t.nodes[f].height < t.nodes[g].height {
+		keep, move = g, f
+	}
+
+	t.nodes[up].right = keep
+	if upIsRight {
+		t.nodes[a].right = move
+	} else {
+		t.nodes[a].left = move
+	}
+	t.nodes[move].parent = a
+
+	nodeA := &t.nodes[a]
+	nodeA.aabb = t.nodes[other].aabb.Merge(t.nodes[move].aabb)
+	nodeA.height = 1 + max32(t.nodes[other].height, t.nodes[move].height)
+
+	nodeUp := &t.nodes[up]
+	nodeUp.aabb = nodeA.aabb.Merge(t.nodes[keep].aabb)
+	nodeUp.height = 1 + max32(nodeA.height, t.nodes[keep].height)
+
+	return up
+}
+
+// overlaps проверяет пересечение AABB без копирования (горячий цикл обхода дерева)
+func overlaps(a, b *customMath.AABB) bool {
+	return a.Min[0] <= b.Max[0] && a.Max[0] >= b.Min[0] &&
+		a.Min[1] <= b.Max[1] && a.Max[1] >= b.Min[1] &&
+		a.Min[2] <= b.Max[2] && a.Max[2] >= b.Min[2]
+}
+
+// max32 возвращает максимальное из двух int32
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
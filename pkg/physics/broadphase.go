@@ -0,0 +1,285 @@
+package physics
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// BroadPhaseType алгоритм широкой фазы
+type BroadPhaseType int
+
+const (
+	BroadPhaseAABBTree      BroadPhaseType = iota // Динамическое AABB-дерево
+	BroadPhaseSweepAndPrune                       // Сортировка и отсечение по оси X
+	BroadPhaseBruteForce                          // Перебор всех пар O(n²)
+)
+
+// String возвращает название алгоритма
+func (t BroadPhaseType) String() string {
+	switch t {
+	case BroadPhaseAABBTree:
+		return "aabb-tree"
+	case BroadPhaseSweepAndPrune:
+		return "sweep-and-prune"
+	case BroadPhaseBruteForce:
+		return "brute-force"
+	default:
+		return "unknown"
+	}
+}
+
+// BroadPhase широкая фаза определения коллизий: быстро отбирает пары тел,
+// ограничивающие объемы которых пересекаются. Точную проверку выполняет мир
+type BroadPhase interface {
+	// Update синхронизирует структуру с текущим списком тел
+	Update(bodies []*RigidBody)
+
+	// Pairs вызывает callback для каждой пары-кандидата. Пары идут в порядке
+	// индексов тел в списке, переданном в Update, что делает результат воспроизводимым.
+	// Пары из двух статических тел могут не возвращаться
+	Pairs(callback func(a, b *RigidBody))
+
+	// Query вызывает callback для тел, AABB которых пересекает aabb.
+	// Если callback возвращает false, поиск прекращается
+	Query(aabb customMath.AABB, callback func(body *RigidBody) bool)
+
+	// Raycast вызывает callback для тел, AABB которых пересекает луч на отрезке
+	// [0, maxDistance]. callback возвращает новую длину луча: расстояние до попадания
+	// отсекает более дальние тела, прежнее значение продолжает поиск, 0 - прекращает
+	Raycast(ray customMath.Ray, maxDistance float32, callback func(body *RigidBody) float32)
+}
+
+// NewBroadPhase создает широкую фазу заданного типа
+func NewBroadPhase(kind BroadPhaseType) BroadPhase {
+	switch kind {
+	case BroadPhaseSweepAndPrune:
+		return NewSweepAndPrune()
+	case BroadPhaseBruteForce:
+		return NewBruteForceBroadPhase()
+	default:
+		return NewDynamicAABBTree()
+	}
+}
+
+// bodyPair пара индексов тел
+type bodyPair struct {
+	a, b int32
+}
+
+// sortPairs упорядочивает пары по индексам тел
+func sortPairs(pairs []bodyPair) {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+}
+
+// rayAABB пересекает луч с AABB методом плит. Возвращает отрезок луча внутри
+// AABB, обрезанный до [0, maxDistance]
+func rayAABB(origin, direction mgl32.Vec3, aabb *customMath.AABB, maxDistance float32) (float32, float32, bool) {
+	tmin, tmax := float32(0), maxDistance
+	for i := 0; i < 3; i++ {
+		if abs32(direction[i]) < 1e-8 {
+			// Луч параллелен плитам - начало должно лежать между ними
+			if origin[i] < aabb.Min[i] || origin[i] > aabb.Max[i] {
+				return 0, 0, false
+			}
+			continue
+		}
+
+		inverse := 1 / direction[i]
+		t1 := (aabb.Min[i] - origin[i]) * inverse
+		t2 := (aabb.Max[i] - origin[i]) * inverse
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tmin {
+			tmin = t1
+		}
+		if t2 < tmax {
+			tmax = t2
+		}
+		if tmin > tmax {
+			return 0, 0, false
+		}
+	}
+	return tmin, tmax, true
+}
+
+// BruteForceBroadPhase перебирает все пары тел. Подходит для небольших сцен
+// и как эталон для проверки других алгоритмов
+type BruteForceBroadPhase struct {
+	bodies []*RigidBody
+	boxes  []customMath.AABB
+}
+
+// NewBruteForceBroadPhase создает широкую фазу полным перебором
+func NewBruteForceBroadPhase() *BruteForceBroadPhase {
+	return &BruteForceBroadPhase{}
+}
+
+// Update сохраняет тела и их AABB
+func (bf *BruteForceBroadPhase) Update(bodies []*RigidBody) {
+	bf.bodies = bodies
+	bf.boxes = bf.boxes[:0]
+	for _, body := range bodies {
+		bf.boxes = append(bf.boxes, body.GetAABB())
+	}
+}
+
+// Pairs перебирает все пары тел
+func (bf *BruteForceBroadPhase) Pairs(callback func(a, b *RigidBody)) {
+	for i := 0; i < len(bf.bodies); i++ {
+		for j := i + 1; j < len(bf.bodies); j++ {
+			if bf.boxes[i].Intersects(bf.boxes[j]) {
+				callback(bf.bodies[i], bf.bodies[j])
+			}
+		}
+	}
+}
+
+// Query перебирает все тела
+func (bf *BruteForceBroadPhase) Query(aabb customMath.AABB, callback func(body *RigidBody) bool) {
+	for i, box := range bf.boxes {
+		if box.Intersects(aabb) && !callback(bf.bodies[i]) {
+			return
+		}
+	}
+}
+
+// Raycast проверяет луч с AABB всех тел
+func (bf *BruteForceBroadPhase) Raycast(ray customMath.Ray, maxDistance float32, callback func(body *RigidBody) float32) {
+	for i := range bf.boxes {
+		if _, _, ok := rayAABB(ray.Origin, ray.Direction, &bf.boxes[i], maxDistance); !ok {
+			continue
+		}
+		if maxDistance = callback(bf.bodies[i]); maxDistance <= 0 {
+			return
+		}
+	}
+}
+
+// SweepAndPrune сортирует AABB по оси X и проверяет только тела,
+// проекции которых на эту ось перекрываются
+type SweepAndPrune struct {
+	bodies []*RigidBody
+	boxes  []customMath.AABB
+	order  []int32 // Индексы тел, отсортированные по Min.X
+	pairs  []bodyPair
+	active []int32
+}
+
+// NewSweepAndPrune создает широкую фазу sweep-and-prune
+func NewSweepAndPrune() *SweepAndPrune {
+	return &SweepAndPrune{}
+}
+
+// Update пересчитывает AABB и сортирует тела вдоль оси X
+func (sp *SweepAndPrune) Update(bodies []*RigidBody) {
+	sp.bodies = bodies
+	sp.boxes = sp.boxes[:0]
+	for _, body := range bodies {
+		sp.boxes = append(sp.boxes, body.GetAABB())
+	}
+
+	// Порядок с прошлого кадра почти отсортирован - сохраняем его,
+	// если набор тел не менялся
+	if len(sp.order) != len(bodies) {
+		sp.order = sp.order[:0]
+		for i := range bodies {
+			sp.order = append(sp.order, int32(i))
+		}
+	}
+
+	// Сортировка вставками быстра на почти упорядоченных данных
+	for i := 1; i < len(sp.order); i++ {
+		current := sp.order[i]
+		j := i - 1
+		for j >= 0 && sp.less(current, sp.order[j]) {
+			sp.order[j+1] = sp.order[j]
+			j--
+		}
+		sp.order[j+1] = current
+	}
+}
+
+// less сравнивает тела по Min.X, при равенстве - по индексу
+func (sp *SweepAndPrune) less(a, b int32) bool {
+	ax, bx := sp.boxes[a].Min.X(), sp.boxes[b].Min.X()
+	if ax != bx {
+		return ax < bx
+	}
+	return a < b
+}
+
+// Pairs проходит по отсортированному списку, поддерживая множество активных тел
+func (sp *SweepAndPrune) Pairs(callback func(a, b *RigidBody)) {
+	sp.pairs = sp.pairs[:0]
+	sp.active = sp.active[:0]
+
+	for _, current := range sp.order {
+		box := sp.boxes[current]
+
+		// Убираем тела, которые закончились левее текущего
+		kept := sp.active[:0]
+		for _, other := range sp.active {
+			if sp.boxes[other].Max.X() >= box.Min.X() {
+				kept = append(kept, other)
+			}
+		}
+		sp.active = kept
+
+		for _, other := range sp.active {
+			if !sp.boxes[other].Intersects(box) {
+				continue
+			}
+			if other < current {
+				sp.pairs = append(sp.pairs, bodyPair{other, current})
+			} else {
+				sp.pairs = append(sp.pairs, bodyPair{current, other})
+			}
+		}
+		sp.active = append(sp.active, current)
+	}
+
+	sortPairs(sp.pairs)
+	for _, pair := range sp.pairs {
+		callback(sp.bodies[pair.a], sp.bodies[pair.b])
+	}
+}
+
+// Query находит тела бинарным поиском по оси X
+func (sp *SweepAndPrune) Query(aabb customMath.AABB, callback func(body *RigidBody) bool) {
+	// Тела с Min.X > aabb.Max.X не могут пересекаться с запросом
+	end := sort.Search(len(sp.order), func(i int) bool {
+		return sp.boxes[sp.order[i]].Min.X() > aabb.Max.X()
+	})
+
+	for _, index := range sp.order[:end] {
+		if sp.boxes[index].Intersects(aabb) && !callback(sp.bodies[index]) {
+			return
+		}
+	}
+}
+
+// Raycast проходит тела по возрастанию Min.X, пока они не окажутся
+// правее конца луча
+func (sp *SweepAndPrune) Raycast(ray customMath.Ray, maxDistance float32, callback func(body *RigidBody) float32) {
+	for _, index := range sp.order {
+		endX := ray.Origin.X() + ray.Direction.X()*maxDistance
+		if sp.boxes[index].Min.X() > max(ray.Origin.X(), endX) {
+			return
+		}
+		if _, _, ok := rayAABB(ray.Origin, ray.Direction, &sp.boxes[index], maxDistance); !ok {
+			continue
+		}
+		if maxDistance = callback(sp.bodies[index]); maxDistance <= 0 {
+			return
+		}
+	}
+}
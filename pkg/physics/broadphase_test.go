@@ -0,0 +1,101 @@
+package physics
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var broadPhaseTypes = []BroadPhaseType{
+	BroadPhaseAABBTree,
+	BroadPhaseSweepAndPrune,
+	BroadPhaseBruteForce,
+}
+
+// newBroadPhaseWorld заполняет мир случайными параллелепипедами и сферами над плоскостью.
+// Плотность подобрана так, чтобы тела регулярно сталкивались
+func newBroadPhaseWorld(kind BroadPhaseType, count int, seed int64) *PhysicsWorld {
+	rng := rand.New(rand.NewSource(seed))
+
+	world := NewPhysicsWorld()
+	world.SetBroadPhase(kind)
+	world.AddBody(NewPlaneBody(mgl32.Vec3{}, mgl32.Vec3{0, 1, 0}))
+
+	extent := float32(count) / 50
+	for i := 0; i < count; i++ {
+		shape := BoxShape
+		if i%2 == 1 {
+			shape = SphereShape
+		}
+
+		body := NewRigidBody(Dynamic, shape)
+		body.Position = mgl32.Vec3{
+			(rng.Float32() - 0.5) * extent,
+			1 + rng.Float32()*20,
+			(rng.Float32() - 0.5) * extent,
+		}
+		if shape == SphereShape {
+			body.Dimensions = mgl32.Vec3{0.5, 0, 0}
+		}
+		body.Velocity = mgl32.Vec3{rng.Float32() - 0.5, 0, rng.Float32() - 0.5}
+		world.AddBody(body)
+	}
+	return world
+}
+
+// overlappingPairs возвращает пары широкой фазы, AABB которых действительно пересекаются
+func overlappingPairs(broadPhase BroadPhase, bodies []*RigidBody) [][2]*RigidBody {
+	var pairs [][2]*RigidBody
+	broadPhase.Update(bodies)
+	broadPhase.Pairs(func(a, b *RigidBody) {
+		if a.GetAABB().Intersects(b.GetAABB()) {
+			pairs = append(pairs, [2]*RigidBody{a, b})
+		}
+	})
+	return pairs
+}
+
+// TestBroadPhasePairs проверяет, что все алгоритмы находят одни и те же
+// пересечения в одном порядке, в том числе после движения тел. Алгоритмы
+// обновляются по телам одного мира, чтобы сравнивать одинаковые состояния
+func TestBroadPhasePairs(t *testing.T) {
+	world := newBroadPhaseWorld(BroadPhaseBruteForce, 2000, 1)
+	broadPhases := make([]BroadPhase, len(broadPhaseTypes))
+	for i, kind := range broadPhaseTypes {
+		broadPhases[i] = NewBroadPhase(kind)
+	}
+
+	for step := 0; step < 10; step++ {
+		reference := overlappingPairs(broadPhases[0], world.Bodies)
+		if step == 0 && len(reference) == 0 {
+			t.Fatal("в сцене нет пересечений")
+		}
+		for i, broadPhase := range broadPhases[1:] {
+			pairs := overlappingPairs(broadPhase, world.Bodies)
+			if len(pairs) != len(reference) {
+				t.Fatalf("шаг %d: %s нашел %d пар, ожидалось %d", step, broadPhaseTypes[i+1], len(pairs), len(reference))
+			}
+			for j := range pairs {
+				if pairs[j][0] != reference[j][0] || pairs[j][1] != reference[j][1] {
+					t.Fatalf("шаг %d: %s: пара %d отличается", step, broadPhaseTypes[i+1], j)
+				}
+			}
+		}
+
+		world.Step(1.0 / 60.0)
+	}
+}
+
+// BenchmarkBroadPhase измеряет шаг мира с 5000 телами для каждого алгоритма
+func BenchmarkBroadPhase(b *testing.B) {
+	for _, kind := range broadPhaseTypes {
+		b.Run(kind.String(), func(b *testing.B) {
+			world := newBroadPhaseWorld(kind, 5000, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				world.Step(1.0 / 60.0)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// Параметры непрерывного определения столкновений
+const (
+	ccdMotionFraction  = 0.5 // Доля наименьшего размера тела, начиная с которой путь за шаг проверяется
+	maxTOISubsteps     = 4   // Сколько раз тело может удариться о статическую геометрию за шаг
+	speculativeFeature = -1  // feature спекулятивных манифолдов, чтобы не путать их прогрев с обычными
+)
+
+// ccdExtent возвращает наименьший полуразмер формы, для которой поддерживается CCD.
+// Многогранники и сетки движутся дискретно
+func (c *collider) ccdExtent() (float32, bool) {
+	switch c.kind {
+	case colliderSphere, colliderCapsule:
+		return c.radius, true
+	case colliderBox:
+		return min(c.halfExtents[0], c.halfExtents[1], c.halfExtents[2]), true
+	}
+	return 0, false
+}
+
+// castProbe двигает сферу, капсулу или параллелепипед probe из origin и находит
+// момент касания с target. Для сферы используется раздутый луч, для остальных -
+// пошаговый поиск с делением пополам. Возвращает нормаль поверхности цели,
+// точку касания и путь до нее
+func castProbe(probe, target *collider, origin, direction mgl32.Vec3, maxDistance float32) (mgl32.Vec3, mgl32.Vec3, float32, bool) {
+	// Для многогранников и треугольников нет аналитического раздутия
+	if probe.kind == colliderSphere && target.kind < colliderHull {
+		normal, distance, ok := target.raycast(origin, direction, probe.radius, maxDistance)
+		center := origin.Add(direction.Mul(distance))
+		return normal, center.Sub(normal.Mul(probe.radius)), distance, ok
+	}
+	return castConvex(probe, target, origin, direction, maxDistance)
+}
+
+// needsCCD проверяет, что тело включило CCD и проходит за шаг заметную долю своего размера
+func needsCCD(body *RigidBody, probe *collider, motion mgl32.Vec3) bool {
+	if !body.CCD || body.Type != Dynamic || body.sleeping || body.IsTrigger {
+		return false
+	}
+	extent, ok := probe.ccdExtent()
+	if !ok {
+		return false
+	}
+	threshold := extent * ccdMotionFraction
+	return motion.LenSqr() > threshold*threshold
+}
+
+// sweptAABB возвращает AABB формы на всем пути motion
+func sweptAABB(probe *collider, motion mgl32.Vec3) customMath.AABB {
+	start := probe.aabb()
+	return start.Merge(customMath.AABB{
+		Min: start.Min.Add(motion),
+		Max: start.Max.Add(motion),
+	})
+}
+
+// canCollideContinuous проверяет, что пара сталкивается физически: без датчиков,
+// с подходящими слоями и без соединения, запрещающего столкновения
+func (w *PhysicsWorld) canCollideContinuous(body, other *RigidBody) bool {
+	if other == body || other.IsTrigger || !body.CanCollideWith(other) {
+		return false
+	}
+	return len(w.jointPairs) == 0 || !w.jointPairs[pairKey(body, other)]
+}
+
+// addSpeculativeContacts строит спекулятивные контакты для быстрых тел с CCD.
+// Форма тела проводится вдоль относительного смещения за шаг; если она коснется
+// другого тела, добавляется манифолд с отрицательной глубиной, равной зазору.
+// Решатель разрешает сближение только до касания, поэтому тело не проскочит
+// даже подвижное препятствие. Пары с обычным контактом пропускаются
+func (w *PhysicsWorld) addSpeculativeContacts(dt float32) {
+	w.speculative = w.speculative[:0]
+	if w.speculativePairs == nil {
+		w.speculativePairs = make(map[contactKey]bool)
+	}
+	prepared := false
+
+	for _, body := range w.Bodies {
+		probe := newCollider(body)
+		motion := body.Velocity.Mul(dt)
+		if !needsCCD(body, &probe, motion) {
+			continue
+		}
+
+		if !prepared {
+			prepared = true
+			for i := range w.contacts {
+				w.speculativePairs[pairKey(w.contacts[i].BodyA, w.contacts[i].BodyB)] = true
+			}
+		}
+
+		w.broadPhase.Query(sweptAABB(&probe, motion), func(other *RigidBody) bool {
+			if !w.canCollideContinuous(body, other) || w.speculativePairs[pairKey(body, other)] {
+				return true
+			}
+
+			relative := motion
+			if other.Type != Static {
+				relative = relative.Sub(other.Velocity.Mul(dt))
+			}
+			length := relative.Len()
+			if length < 1e-6 {
+				return true
+			}
+			direction := relative.Mul(1 / length)
+
+			cast := probe
+			target := newCollider(other)
+			normal, point, distance, ok := castProbe(&cast, &target, body.Position, direction, length)
+			if !ok {
+				return true
+			}
+
+			// Манифолд в момент касания переносится в текущее положение: точки
+			// сдвигаются назад по пути тела, глубина уменьшается на сближение вдоль нормали
+			travel := distance + contactSlop
+			cast.center = body.Position.Add(direction.Mul(travel))
+			manifold, touching := collide(&target, &cast)
+			if !touching || len(manifold.Points) == 0 {
+				manifold = ContactManifold{
+					BodyA:  other,
+					BodyB:  body,
+					Normal: normal,
+					Points: []ContactPoint{{Position: point}},
+				}
+				travel = distance
+			}
+			shift := motion.Mul(travel / length)
+			closing := travel * max(-direction.Dot(manifold.Normal), 0)
+			for i := range manifold.Points {
+				manifold.Points[i].Position = manifold.Points[i].Position.Sub(shift)
+				manifold.Points[i].Depth -= closing
+			}
+			manifold.feature = speculativeFeature
+			w.speculative = append(w.speculative, manifold)
+			w.speculativePairs[pairKey(body, other)] = true
+
+			// Спящее препятствие должно проснуться до решателя
+			w.wakeBody(other)
+			return true
+		})
+	}
+
+	clear(w.speculativePairs)
+}
+
+// solverContacts возвращает контакты для решателя: обычные и спекулятивные
+func (w *PhysicsWorld) solverContacts() []ContactManifold {
+	if len(w.speculative) == 0 {
+		return w.contacts
+	}
+	w.allContacts = append(append(w.allContacts[:0], w.contacts...), w.speculative...)
+	return w.allContacts
+}
+
+// advanceContinuous проверяет путь тела с CCD от start до нового положения
+// против остальных тел. Это страховка на случай, когда спекулятивный контакт
+// не удержал тело (например, из-за вращения): при ударе тело останавливается
+// в момент касания, скорость вдоль нормали гасится (с отскоком для сильных ударов),
+// и остаток шага проходится с новой скоростью. Форма движется с ориентацией
+// начала шага, вращение на пути не учитывается
+func (w *PhysicsWorld) advanceContinuous(body *RigidBody, start mgl32.Vec3, startRotation mgl32.Quat, dt float32) {
+	probe := newCollider(body)
+	probe.rotation = startRotation.Normalize().Mat4().Mat3()
+	remaining := dt
+
+	for i := 0; i < maxTOISubsteps; i++ {
+		motion := body.Position.Sub(start)
+		probe.center = start
+		if !needsCCD(body, &probe, motion) {
+			return
+		}
+
+		length := motion.Len()
+		direction := motion.Mul(1 / length)
+
+		var hit RaycastHit
+		found := false
+		limit := length
+		w.broadPhase.Query(sweptAABB(&probe, motion), func(other *RigidBody) bool {
+			if !w.canCollideContinuous(body, other) {
+				return true
+			}
+			cast := probe
+			target := newCollider(other)
+			normal, point, distance, ok := castProbe(&cast, &target, start, direction, limit)
+			if ok {
+				hit = RaycastHit{Body: other, Point: point, Normal: normal, Distance: distance}
+				found = true
+				limit = distance
+			}
+			return true
+		})
+		if !found {
+			return
+		}
+
+		body.Position = start.Add(direction.Mul(hit.Distance))
+		if i == maxTOISubsteps-1 {
+			return
+		}
+
+		// Гасим сближение с препятствием
+		approach := body.Velocity.Sub(hit.Body.Velocity).Dot(hit.Normal)
+		if approach < 0 {
+			restitution := float32(0)
+			if approach < -restitutionThreshold {
+				restitution = min(body.Restitution, hit.Body.Restitution)
+			}
+			body.Velocity = body.Velocity.Sub(hit.Normal.Mul((1 + restitution) * approach))
+		}
+
+		remaining -= remaining * hit.Distance / length
+		start = body.Position
+		body.Position = start.Add(body.Velocity.Mul(remaining))
+	}
+}
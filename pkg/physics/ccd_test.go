@@ -0,0 +1,46 @@
+package physics
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// fireBullet стреляет сферой радиусом 5 см со скоростью 300 м/с в стену толщиной 5 см.
+// Возвращает true, если пуля оказалась за стеной
+func fireBullet(ccd bool) bool {
+	world := NewPhysicsWorld()
+
+	wall := NewRigidBody(Static, BoxShape)
+	wall.Position = mgl32.Vec3{5, 0, 0}
+	wall.Dimensions = mgl32.Vec3{0.05, 4, 4}
+	world.AddBody(wall)
+
+	bullet := NewRigidBody(Dynamic, SphereShape)
+	bullet.Position = mgl32.Vec3{0.37, 0, 0}
+	bullet.Dimensions = mgl32.Vec3{0.05, 0, 0}
+	bullet.Mass = 0.01
+	bullet.UseGravity = false
+	bullet.Velocity = mgl32.Vec3{300, 0, 0}
+	bullet.CCD = ccd
+	world.AddBody(bullet)
+
+	for i := 0; i < 60; i++ {
+		world.Step(1.0 / 60.0)
+		if bullet.Position.X() > wall.Position.X() {
+			return true
+		}
+	}
+	return false
+}
+
+// TestCCDBulletThinWall проверяет, что без CCD пуля проскакивает тонкую стену,
+// а с CCD останавливается перед ней
+func TestCCDBulletThinWall(t *testing.T) {
+	if !fireBullet(false) {
+		t.Error("без CCD пуля не прошла сквозь стену: сцена больше не проверяет туннелирование")
+	}
+	if fireBullet(true) {
+		t.Error("пуля с CCD прошла сквозь стену")
+	}
+}
@@ -0,0 +1,397 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// Параметры контроллера персонажа
+const (
+	maxSlideIterations = 4 // Сколько поверхностей персонаж может обойти за одно перемещение
+	maxDepenetration   = 4 // Итерации выталкивания из пересечений
+)
+
+// CollisionFlags стороны, которыми персонаж касался препятствий при перемещении
+type CollisionFlags uint8
+
+const (
+	CollidedSides CollisionFlags = 1 << iota // Стена или слишком крутой склон
+	CollidedAbove                            // Потолок
+	CollidedBelow                            // Опора, на которой можно стоять
+)
+
+// CharacterController кинематический персонаж на капсуле. Перемещение
+// скользит вдоль стен, поднимается на невысокие ступени, не дает взойти
+// на склоны круче SlopeLimit, прижимает персонажа к земле на спусках
+// и переносит его вместе с движущейся опорой.
+// Гравитацию и прыжки задает игра через смещение, передаваемое в Move
+type CharacterController struct {
+	body  *RigidBody
+	world *PhysicsWorld
+
+	Up           mgl32.Vec3 // Направление вверх; по умолчанию против гравитации мира
+	SlopeLimit   float32    // Наибольший угол склона, на котором можно стоять, в градусах
+	StepHeight   float32    // Высота ступени, на которую персонаж поднимается без прыжка
+	SnapDistance float32    // Расстояние, на котором персонаж прижимается к земле при спуске
+	SkinWidth    float32    // Зазор между капсулой и препятствиями
+
+	flags         CollisionFlags
+	grounded      bool
+	halfHeight    float32 // Половина полной высоты капсулы
+	groundNormal  mgl32.Vec3
+	groundBody    *RigidBody
+	platform      *RigidBody // Подвижная опора, переносящая персонажа
+	platformLocal mgl32.Vec3 // Положение персонажа в координатах подвижной опоры
+	velocity      mgl32.Vec3
+}
+
+// NewCharacterController создает персонажа-капсулу с полной высотой height
+// и добавляет его кинематическое тело в мир. position - центр капсулы
+func NewCharacterController(world *PhysicsWorld, position mgl32.Vec3, radius, height float32) *CharacterController {
+	up := mgl32.Vec3{0, 1, 0}
+	if world.Gravity.LenSqr() > 0 {
+		up = world.Gravity.Normalize().Mul(-1)
+	}
+
+	segment := float32(math.Max(float64(height-2*radius), 0))
+	body := NewRigidBody(Kinematic, CapsuleShape)
+	body.Position = position
+	body.Rotation = mgl32.QuatBetweenVectors(mgl32.Vec3{0, 1, 0}, up)
+	body.Dimensions = mgl32.Vec3{radius, segment, radius}
+	body.UseGravity = false
+	body.Name = "character"
+	world.AddBody(body)
+
+	return &CharacterController{
+		body:         body,
+		world:        world,
+		Up:           up,
+		SlopeLimit:   45,
+		StepHeight:   0.3,
+		SnapDistance: 0.2,
+		SkinWidth:    0.04,
+		halfHeight:   segment/2 + radius,
+	}
+}
+
+// GetBody возвращает кинематическое тело персонажа
+func (c *CharacterController) GetBody() *RigidBody {
+	return c.body
+}
+
+// GetPosition возвращает центр капсулы
+func (c *CharacterController) GetPosition() mgl32.Vec3 {
+	return c.body.Position
+}
+
+// SetPosition переносит персонажа без проверки столкновений
+func (c *CharacterController) SetPosition(position mgl32.Vec3) {
+	c.body.Position = position
+	c.platform = nil
+	c.world.queryDirty = true
+}
+
+// IsGrounded сообщает, стоял ли персонаж на опоре после последнего Move
+func (c *CharacterController) IsGrounded() bool {
+	return c.grounded
+}
+
+// GetGroundNormal возвращает нормаль опоры; без опоры - нулевой вектор
+func (c *CharacterController) GetGroundNormal() mgl32.Vec3 {
+	return c.groundNormal
+}
+
+// GetGroundBody возвращает тело, на котором стоит персонаж, или nil
+func (c *CharacterController) GetGroundBody() *RigidBody {
+	return c.groundBody
+}
+
+// GetCollisionFlags возвращает касания последнего Move
+func (c *CharacterController) GetCollisionFlags() CollisionFlags {
+	return c.flags
+}
+
+// GetVelocity возвращает фактическую скорость последнего Move
+func (c *CharacterController) GetVelocity() mgl32.Vec3 {
+	return c.velocity
+}
+
+// Move перемещает персонажа на displacement за время dt и возвращает касания.
+// Смещение делится на горизонтальную и вертикальную части: персонаж
+// приподнимается на StepHeight, скользит по горизонтали и опускается обратно,
+// находя опору; если опора выше StepHeight, перемещение повторяется без подъема.
+// Скорость тела выставляется по фактическому смещению, чтобы тела, стоящие
+// на персонаже, двигались вместе с ним, а задетые спящие тела просыпались
+func (c *CharacterController) Move(displacement mgl32.Vec3, dt float32) CollisionFlags {
+	start := c.body.Position
+	position := start
+	c.flags = 0
+
+	// Движущаяся опора переносит персонажа вместе с собой
+	if c.platform != nil {
+		position = c.platform.GetModelMatrix().Mul4x1(c.platformLocal.Vec4(1)).Vec3()
+	}
+
+	position = c.depenetrate(position)
+
+	vertical := displacement.Dot(c.Up)
+	horizontal := displacement.Sub(c.Up.Mul(vertical))
+	wasGrounded := c.grounded
+	c.grounded = false
+	c.groundNormal = mgl32.Vec3{}
+	c.groundBody = nil
+
+	snap := float32(0)
+	if wasGrounded && vertical <= 0 {
+		snap = c.SnapDistance
+	}
+
+	// Сначала пробуем подняться на ступень; если опора оказалась выше
+	// StepHeight, повторяем перемещение без подъема
+	flags := c.flags
+	moved, ok := position, false
+	if wasGrounded && horizontal.LenSqr() > 1e-12 && c.StepHeight > 0 {
+		moved, ok = c.moveStep(position, horizontal, vertical, c.StepHeight, snap)
+	}
+	if !ok {
+		c.flags = flags
+		moved, _ = c.moveStep(position, horizontal, vertical, 0, snap)
+	}
+	position = moved
+
+	c.body.Position = position
+	if dt > 0 {
+		c.velocity = position.Sub(start).Mul(1 / dt)
+	} else {
+		c.velocity = mgl32.Vec3{}
+	}
+	c.body.Velocity = c.velocity
+	c.world.queryDirty = true
+
+	c.platform = nil
+	if c.grounded {
+		c.flags |= CollidedBelow
+		if c.groundBody.Type != Static {
+			c.platform = c.groundBody
+			c.platformLocal = c.groundBody.GetModelMatrix().Inv().Mul4x1(position.Vec4(1)).Vec3()
+		}
+	}
+	return c.flags
+}
+
+// moveStep выполняет перемещение в три прохода: подъем на stepOffset и вверх,
+// скольжение по горизонтали, спуск обратно с поиском опоры. Возвращает false,
+// если после подъема персонаж встал на опору выше StepHeight над исходным положением
+func (c *CharacterController) moveStep(position, horizontal mgl32.Vec3, vertical, stepOffset, snap float32) (mgl32.Vec3, bool) {
+	foot := position.Dot(c.Up) - c.halfHeight
+
+	// Подъем: ступень и движение вверх
+	rise := stepOffset + max(vertical, 0)
+	if rise > 0 {
+		var risen float32
+		position, risen = c.moveAlong(position, c.Up, rise)
+		stepOffset = max(risen-max(vertical, 0), 0)
+	}
+
+	// Скольжение по горизонтали
+	position = c.slide(position, horizontal, true)
+
+	// Спуск: возврат со ступени, движение вниз и прижатие к земле
+	descent := stepOffset + max(-vertical, 0)
+	if descent+snap <= 0 {
+		return position, true
+	}
+
+	down := c.Up.Mul(-1)
+	hit, ok := c.sweep(position, down, descent+snap)
+	if ok && stepOffset > 0 && hit.Point.Dot(c.Up)-foot > c.StepHeight+c.SkinWidth {
+		return position, false
+	}
+	if ok && !c.isWalkable(hit.Normal) {
+		hit = c.groundUnder(hit, position)
+	}
+
+	switch {
+	case ok && c.isWalkable(hit.Normal):
+		position = position.Add(down.Mul(max(hit.Distance-c.SkinWidth, 0)))
+		c.setGround(hit)
+	case ok && hit.Distance <= descent:
+		// Крутой склон: скользим по нему остаток спуска
+		position = position.Add(down.Mul(max(hit.Distance-c.SkinWidth, 0)))
+		position = c.slide(position, down.Mul(descent-hit.Distance), false)
+	default:
+		position = position.Add(down.Mul(descent))
+	}
+	return position, true
+}
+
+// slide двигает персонажа на motion, скользя вдоль препятствий.
+// При горизонтальном движении все препятствия работают как вертикальные стены:
+// подниматься по склонам и ступеням можно только за счет StepHeight,
+// иначе скругленный низ капсулы въезжал бы на любой уступ
+func (c *CharacterController) slide(position, motion mgl32.Vec3, horizontal bool) mgl32.Vec3 {
+	original := motion
+	for i := 0; i < maxSlideIterations; i++ {
+		length := motion.Len()
+		if length < 1e-6 {
+			break
+		}
+		direction := motion.Mul(1 / length)
+
+		hit, ok := c.sweep(position, direction, length)
+		if !ok {
+			position = position.Add(motion)
+			break
+		}
+
+		travel := max(hit.Distance-c.SkinWidth, 0)
+		position = position.Add(direction.Mul(travel))
+
+		normal := hit.Normal
+		c.classify(normal)
+		if horizontal {
+			flat := normal.Sub(c.Up.Mul(normal.Dot(c.Up)))
+			if flat.LenSqr() > 1e-8 {
+				normal = flat.Normalize()
+			}
+		}
+
+		remaining := motion.Sub(direction.Mul(travel))
+		motion = remaining.Sub(normal.Mul(remaining.Dot(normal)))
+
+		// Не разворачиваемся против исходного направления в углах
+		if motion.Dot(original) <= 0 {
+			break
+		}
+	}
+	return position
+}
+
+// groundUnder уточняет опору, когда капсула легла скругленным низом на ребро:
+// нормаль ребра крутая, но если рядом с точкой касания лежит пологая грань
+// (верх ступени или край обрыва), персонаж стоит на ней
+func (c *CharacterController) groundUnder(hit RaycastHit, position mgl32.Vec3) RaycastHit {
+	across := position.Sub(hit.Point)
+	across = across.Sub(c.Up.Mul(across.Dot(c.Up)))
+	if across.LenSqr() < 1e-8 {
+		return hit
+	}
+	across = across.Normalize().Mul(c.SkinWidth)
+
+	filter := func(body *RigidBody) bool {
+		return body == hit.Body
+	}
+	for _, side := range []mgl32.Vec3{across, across.Mul(-1)} {
+		ray := customMath.Ray{
+			Origin:    hit.Point.Add(side).Add(c.Up.Mul(c.SkinWidth)),
+			Direction: c.Up.Mul(-1),
+		}
+		if ground, ok := c.world.Raycast(ray, 2*c.SkinWidth, filter); ok && c.isWalkable(ground.Normal) {
+			hit.Normal = ground.Normal
+			return hit
+		}
+	}
+	return hit
+}
+
+// moveAlong двигает персонажа по направлению до первого препятствия
+// и возвращает новое положение и пройденный путь
+func (c *CharacterController) moveAlong(position, direction mgl32.Vec3, distance float32) (mgl32.Vec3, float32) {
+	hit, ok := c.sweep(position, direction, distance)
+	if !ok {
+		return position.Add(direction.Mul(distance)), distance
+	}
+	c.classify(hit.Normal)
+	travel := max(hit.Distance-c.SkinWidth, 0)
+	return position.Add(direction.Mul(travel)), travel
+}
+
+// depenetrate выталкивает капсулу из тел, в которые она оказалась погружена
+// (например, опора или препятствие сдвинулись на персонажа)
+func (c *CharacterController) depenetrate(position mgl32.Vec3) mgl32.Vec3 {
+	c.world.prepareQuery()
+	for i := 0; i < maxDepenetration; i++ {
+		probe := c.collider(position)
+		pushed := false
+
+		c.world.broadPhase.Query(probe.aabb(), func(other *RigidBody) bool {
+			if !c.filter(other) {
+				return true
+			}
+			target := newCollider(other)
+			manifold, ok := collide(&probe, &target)
+			if !ok {
+				return true
+			}
+			depth := manifold.MaxDepth()
+			if depth <= 0 {
+				return true
+			}
+
+			// Нормаль направлена от персонажа к препятствию
+			position = position.Sub(manifold.Normal.Mul(depth + c.SkinWidth))
+			probe.center = position
+			c.classify(manifold.Normal.Mul(-1))
+			pushed = true
+			return true
+		})
+		if !pushed {
+			break
+		}
+	}
+	return position
+}
+
+// sweep проводит капсулу из position по направлению direction на distance
+func (c *CharacterController) sweep(position, direction mgl32.Vec3, distance float32) (RaycastHit, bool) {
+	if distance <= 0 {
+		return RaycastHit{}, false
+	}
+	probe := c.collider(position)
+	ray := customMath.Ray{Origin: position, Direction: direction}
+	return c.world.shapeCast(&probe, ray, distance, c.filter, func(target *collider, direction mgl32.Vec3, limit float32) (mgl32.Vec3, mgl32.Vec3, float32, bool) {
+		cast := probe
+		return castProbe(&cast, target, position, direction, limit)
+	})
+}
+
+// collider возвращает капсулу персонажа в положении position
+func (c *CharacterController) collider(position mgl32.Vec3) collider {
+	probe := newCollider(c.body)
+	probe.center = position
+	return probe
+}
+
+// filter пропускает тела, с которыми персонаж сталкивается
+func (c *CharacterController) filter(body *RigidBody) bool {
+	return body != c.body && !body.IsTrigger && c.body.CanCollideWith(body)
+}
+
+// isWalkable проверяет, что на поверхности с нормалью normal можно стоять
+func (c *CharacterController) isWalkable(normal mgl32.Vec3) bool {
+	limit := float32(math.Cos(float64(mgl32.DegToRad(c.SlopeLimit))))
+	return normal.Dot(c.Up) >= limit-1e-4
+}
+
+// classify добавляет флаг касания по нормали поверхности
+func (c *CharacterController) classify(normal mgl32.Vec3) {
+	slope := normal.Dot(c.Up)
+	switch {
+	case c.isWalkable(normal):
+		c.flags |= CollidedBelow
+	case slope <= -groundCosine:
+		c.flags |= CollidedAbove
+	default:
+		c.flags |= CollidedSides
+	}
+}
+
+// setGround запоминает опору персонажа
+func (c *CharacterController) setGround(hit RaycastHit) {
+	c.grounded = true
+	c.groundNormal = hit.Normal
+	c.groundBody = hit.Body
+}
@@ -0,0 +1,209 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// meshMergeCosine косинус угла между нормалями, при котором контакты
+// соседних треугольников объединяются в один манифолд
+const meshMergeCosine = 0.995
+
+// collideConcave дописывает контакты выпуклого коллайдера с треугольниками сетки
+// или карты высот. Каждый треугольник проверяется как двусторонний многогранник,
+// контакты с близкими нормалями объединяются, чтобы тело на плоском участке
+// получало один манифолд. Номер первого треугольника группы различает манифолды
+// пары между шагами для прогрева решателя
+func collideConcave(a, b *collider, manifolds []ContactManifold) []ContactManifold {
+	concave, convex, flip := a, b, false
+	if !a.isConcave() {
+		concave, convex, flip = b, a, true
+	}
+	if convex.isConcave() || convex.kind == colliderPlane {
+		return manifolds
+	}
+
+	var poly *polyhedron
+	if convex.kind == colliderBox || convex.kind == colliderHull {
+		poly = convex.polyhedron()
+	}
+
+	first := len(manifolds)
+	concave.forEachTriangle(convex.aabb().Expand(contactMargin), func(id int32, p0, p1, p2 mgl32.Vec3) {
+		var manifold ContactManifold
+		var ok bool
+		switch convex.kind {
+		case colliderSphere:
+			ok = collideTriangleSphere(p0, p1, p2, convex.center, convex.radius, &manifold)
+		case colliderCapsule:
+			ok = collideTriangleCapsule(p0, p1, p2, convex, &manifold)
+		default:
+			ok = collidePolyhedra(trianglePolyhedron(p0, p1, p2), poly, &manifold)
+		}
+		if !ok {
+			return
+		}
+
+		for i := first; i < len(manifolds); i++ {
+			if manifolds[i].Normal.Dot(manifold.Normal) > meshMergeCosine {
+				manifolds[i].Points = append(manifolds[i].Points, manifold.Points...)
+				return
+			}
+		}
+		manifold.BodyA, manifold.BodyB = concave.body, convex.body
+		manifold.feature = id
+		manifolds = append(manifolds, manifold)
+	})
+
+	for i := first; i < len(manifolds); i++ {
+		manifold := &manifolds[i]
+		dedupePoints(manifold)
+		if flip {
+			manifold.BodyA, manifold.BodyB = manifold.BodyB, manifold.BodyA
+			manifold.Normal = manifold.Normal.Mul(-1)
+		}
+	}
+	return manifolds
+}
+
+// forEachTriangle вызывает callback для треугольников, которые может задеть
+// мировой объем bounds. Вершины передаются в мировых координатах
+func (c *collider) forEachTriangle(bounds customMath.AABB, callback func(id int32, a, b, cc mgl32.Vec3)) {
+	local := bounds.Transform(c.inverse)
+
+	switch c.kind {
+	case colliderMesh:
+		c.mesh.query(local, func(tri int32) {
+			a, b, cc := c.mesh.triangle(tri)
+			callback(tri, c.toWorld(a), c.toWorld(b), c.toWorld(cc))
+		})
+	case colliderHeightfield:
+		h := c.heightfield
+		h.query(local, func(row, col, k int) {
+			a, b, cc := h.triangle(row, col, k)
+			callback(h.triangleID(row, col, k), c.toWorld(a), c.toWorld(b), c.toWorld(cc))
+		})
+	}
+}
+
+// rayConcave пересекает луч с треугольниками сетки или карты высот.
+// Нормаль треугольника разворачивается навстречу лучу
+func (c *collider) rayConcave(origin, direction mgl32.Vec3, maxDistance float32) (mgl32.Vec3, float32, bool) {
+	// Аффинное преобразование сохраняет параметр луча, поэтому расстояния
+	// в локальных координатах и в мире совпадают
+	localOrigin := c.inverse.Mul4x1(origin.Vec4(1)).Vec3()
+	localDirection := c.inverse.Mul4x1(direction.Vec4(0)).Vec3()
+
+	var normal mgl32.Vec3
+	best := maxDistance
+	found := false
+	test := func(a, b, cc mgl32.Vec3) (float32, bool) {
+		a, b, cc = c.toWorld(a), c.toWorld(b), c.toWorld(cc)
+		t, ok := rayTriangle(origin, direction, a, b, cc, best)
+		if !ok {
+			return 0, false
+		}
+		normal = safeNormalize(b.Sub(a).Cross(cc.Sub(a)))
+		if normal.Dot(direction) > 0 {
+			normal = normal.Mul(-1)
+		}
+		best, found = t, true
+		return t, true
+	}
+
+	switch c.kind {
+	case colliderMesh:
+		c.mesh.raycast(localOrigin, localDirection, maxDistance, func(tri int32) float32 {
+			test(c.mesh.triangle(tri))
+			return best
+		})
+	case colliderHeightfield:
+		h := c.heightfield
+		h.raycast(localOrigin, localDirection, maxDistance, func(row, col, k int) (float32, bool) {
+			return test(h.triangle(row, col, k))
+		})
+	}
+	return normal, best, found
+}
+
+// collideTriangleSphere тест треугольника и сферы; нормаль от треугольника к сфере
+func collideTriangleSphere(a, b, c, center mgl32.Vec3, radius float32, manifold *ContactManifold) bool {
+	closest := closestPointOnTriangle(center, a, b, c)
+	diff := center.Sub(closest)
+	distance := diff.Len()
+	if distance > radius {
+		return false
+	}
+
+	normal := safeNormalize(b.Sub(a).Cross(c.Sub(a)))
+	if distance > 1e-6 {
+		normal = diff.Mul(1 / distance)
+	}
+
+	surface := center.Sub(normal.Mul(radius))
+	manifold.Normal = normal
+	manifold.Points = append(manifold.Points, ContactPoint{
+		Position: closest.Add(surface).Mul(0.5),
+		Depth:    radius - distance,
+	})
+	return true
+}
+
+// collideTriangleCapsule тест треугольника и капсулы: ближайшие точки осевого
+// отрезка и треугольника, плюс концы капсулы, лежащей вдоль грани
+func collideTriangleCapsule(a, b, c mgl32.Vec3, capsule *collider, manifold *ContactManifold) bool {
+	p0, p1 := capsule.segment()
+
+	// Ближайшая пара: концы отрезка к треугольнику и отрезок к ребрам
+	segPoint, triPoint := p0, closestPointOnTriangle(p0, a, b, c)
+	best := segPoint.Sub(triPoint).LenSqr()
+	consider := func(s, t mgl32.Vec3) {
+		if d := s.Sub(t).LenSqr(); d < best {
+			segPoint, triPoint, best = s, t, d
+		}
+	}
+	consider(p1, closestPointOnTriangle(p1, a, b, c))
+	for _, edge := range [3][2]mgl32.Vec3{{a, b}, {b, c}, {c, a}} {
+		consider(closestPointsSegments(p0, p1, edge[0], edge[1]))
+	}
+	if t, ok := rayTriangle(p0, p1.Sub(p0), a, b, c, 1); ok {
+		point := p0.Add(p1.Sub(p0).Mul(t))
+		consider(point, point)
+	}
+
+	distance := sqrt32(best)
+	if distance > capsule.radius {
+		return false
+	}
+
+	faceNormal := safeNormalize(b.Sub(a).Cross(c.Sub(a)))
+	normal := faceNormal
+	if distance > 1e-6 {
+		normal = segPoint.Sub(triPoint).Mul(1 / distance)
+	} else if faceNormal.Dot(capsule.center.Sub(a)) < 0 {
+		normal = faceNormal.Mul(-1)
+	}
+
+	manifold.Normal = normal
+	manifold.Points = append(manifold.Points, ContactPoint{
+		Position: triPoint.Add(segPoint.Sub(normal.Mul(capsule.radius))).Mul(0.5),
+		Depth:    capsule.radius - distance,
+	})
+
+	// Концы капсулы, проецирующиеся на грань вдоль нормали
+	for _, end := range [2]mgl32.Vec3{p0, p1} {
+		closest := closestPointOnTriangle(end, a, b, c)
+		diff := end.Sub(closest)
+		d := diff.Dot(normal)
+		if d > capsule.radius+contactMargin || diff.Sub(normal.Mul(d)).LenSqr() > 1e-6 {
+			continue
+		}
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: closest.Add(end.Sub(normal.Mul(capsule.radius))).Mul(0.5),
+			Depth:    capsule.radius - d,
+		})
+	}
+	dedupePoints(manifold)
+	return true
+}
@@ -0,0 +1,105 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Детерминизм: мир обходит тела, пары, связи и машины в порядке добавления и
+// не использует случайные числа и время, поэтому одинаковые сцены после
+// одинаковых шагов совпадают побитово. Для lockstep по сети и повторов
+// включите Deterministic и вызывайте Step(FixedTimeStep) один раз на тик.
+// Между машинами это верно при одинаковых GOARCH и GOAMD64: компилятор
+// может по-разному сливать умножение и сложение (FMA)
+
+// FNV-1a, 64 бита
+const (
+	hashOffset uint64 = 14695981039346656037
+	hashPrime  uint64 = 1099511628211
+)
+
+// stateHasher накапливает хеш состояния мира
+type stateHasher struct {
+	sum uint64
+}
+
+// StateHash возвращает хеш состояния мира: положения, повороты, скорости и сон
+// тел, состояние машин и частиц жидкостей. Совпадение хешей двух миров после
+// каждого шага показывает, что симуляции не разошлись
+func (w *PhysicsWorld) StateHash() uint64 {
+	h := stateHasher{sum: hashOffset}
+
+	h.int(len(w.Bodies))
+	for _, body := range w.Bodies {
+		h.int(body.ID)
+		h.int(int(body.Type))
+		h.vec3(body.Position)
+		h.float(body.Rotation.W)
+		h.vec3(body.Rotation.V)
+		h.vec3(body.Velocity)
+		h.vec3(body.AngularVelocity)
+		h.bool(body.sleeping)
+		h.float(body.sleepTime)
+	}
+
+	h.int(len(w.vehicles))
+	for _, vehicle := range w.vehicles {
+		h.int(vehicle.gear)
+		h.float(vehicle.rpm)
+		h.float(vehicle.shiftTimer)
+		for _, wheel := range vehicle.Wheels {
+			h.float(wheel.Compression)
+			h.float(wheel.SteerAngle)
+			h.float(wheel.SpinSpeed)
+			h.float(wheel.SpinAngle)
+		}
+	}
+
+	h.int(len(w.fluids))
+	for _, fluid := range w.fluids {
+		h.int(len(fluid.Particles))
+		for _, particle := range fluid.Particles {
+			h.vec3(particle.Position)
+			h.vec3(particle.Velocity)
+		}
+	}
+
+	return h.sum
+}
+
+// uint32 добавляет в хеш четыре байта
+func (h *stateHasher) uint32(v uint32) {
+	for i := 0; i < 4; i++ {
+		h.sum ^= uint64(v & 0xff)
+		h.sum *= hashPrime
+		v >>= 8
+	}
+}
+
+// float добавляет в хеш биты числа: хеш различает даже -0 и 0
+func (h *stateHasher) float(v float32) {
+	h.uint32(math.Float32bits(v))
+}
+
+// vec3 добавляет в хеш вектор
+func (h *stateHasher) vec3(v mgl32.Vec3) {
+	h.float(v[0])
+	h.float(v[1])
+	h.float(v[2])
+}
+
+// int добавляет в хеш целое
+func (h *stateHasher) int(v int) {
+	h.uint32(uint32(v))
+	h.uint32(uint32(int64(v) >> 32))
+}
+
+// bool добавляет в хеш флаг
+func (h *stateHasher) bool(v bool) {
+	if v {
+		h.uint32(1)
+	} else {
+		h.uint32(0)
+	}
+}
@@ -0,0 +1,90 @@
+package physics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// newDeterministicScene создает мир в детерминированном режиме с телами разных
+// форм, выпуклой оболочкой и жидкостью
+func newDeterministicScene(t *testing.T, seed int64) *PhysicsWorld {
+	rng := rand.New(rand.NewSource(seed))
+
+	world := NewPhysicsWorld()
+	world.Deterministic = true
+	world.AddBody(NewPlaneBody(mgl32.Vec3{}, mgl32.Vec3{0, 1, 0}))
+
+	points := make([]mgl32.Vec3, 32)
+	for i := range points {
+		points[i] = mgl32.Vec3{rng.Float32() - 0.5, rng.Float32() - 0.5, rng.Float32() - 0.5}
+	}
+	hull, err := NewConvexHull(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 60; i++ {
+		shape := []CollisionShape{BoxShape, SphereShape, ConvexHullShape}[i%3]
+		body := NewRigidBody(Dynamic, shape)
+		body.Position = mgl32.Vec3{(rng.Float32() - 0.5) * 6, 1 + rng.Float32()*8, (rng.Float32() - 0.5) * 6}
+		body.Rotation = mgl32.QuatRotate(rng.Float32()*math.Pi, mgl32.Vec3{1, 1, 0}.Normalize())
+		switch shape {
+		case SphereShape:
+			body.Dimensions = mgl32.Vec3{0.5, 0, 0}
+		case ConvexHullShape:
+			body.Hull = hull
+		}
+		world.AddBody(body)
+	}
+
+	world.AddFluid(newFluidBlock(FluidSolverPBF, 125))
+	return world
+}
+
+// TestDeterminism шагает две одинаковые сцены кадрами неравной длины, удаляет
+// и добавляет тело посередине и сравнивает хеши после каждого кадра
+func TestDeterminism(t *testing.T) {
+	const steps = 300
+
+	worlds := [2]*PhysicsWorld{newDeterministicScene(t, 1), newDeterministicScene(t, 1)}
+	frames := rand.New(rand.NewSource(1))
+
+	for i := 0; i < steps; i++ {
+		// Кадр от половины до полутора фиксированных шагов
+		dt := (0.5 + frames.Float32()) / 60
+		for _, world := range worlds {
+			if i == steps/2 {
+				world.RemoveBody(world.Bodies[10])
+				ball := NewRigidBody(Dynamic, SphereShape)
+				ball.Position = mgl32.Vec3{0, 6, 0}
+				ball.Dimensions = mgl32.Vec3{0.3, 0, 0}
+				world.AddBody(ball)
+			}
+			world.Step(dt)
+		}
+		if a, b := worlds[0].StateHash(), worlds[1].StateHash(); a != b {
+			t.Fatalf("миры разошлись на шаге %d: %016x != %016x", i, a, b)
+		}
+	}
+}
+
+// TestStepMaxSubSteps проверяет, что долгий кадр делает не больше MaxSubSteps
+// шагов и оставляет в накопителе только дробный остаток
+func TestStepMaxSubSteps(t *testing.T) {
+	capped, manual := newDeterministicScene(t, 1), newDeterministicScene(t, 1)
+
+	capped.Step(10)
+	for i := 0; i < capped.MaxSubSteps; i++ {
+		manual.step(manual.FixedTimeStep)
+	}
+
+	if a, b := capped.StateHash(), manual.StateHash(); a != b {
+		t.Fatalf("после долгого кадра %016x, после %d шагов %016x", a, capped.MaxSubSteps, b)
+	}
+	if capped.accumulator < 0 || capped.accumulator >= capped.FixedTimeStep {
+		t.Fatalf("остаток накопителя %v вне [0, %v)", capped.accumulator, capped.FixedTimeStep)
+	}
+}
@@ -0,0 +1,119 @@
+package physics
+
+import (
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
+)
+
+// touchingPair пара касающихся тел на шаге и данные для ее событий
+type touchingPair struct {
+	key          contactKey
+	bodyA, bodyB *RigidBody
+	data         *event.CollisionData
+}
+
+// SetEventBus задает шину, в которую мир публикует EventCollisionEnter,
+// EventCollisionStay и EventCollisionExit. nil отключает события
+func (w *PhysicsWorld) SetEventBus(bus *event.EventBus) {
+	w.eventBus = bus
+	w.touching = w.touching[:0]
+	clear(w.touchingKeys)
+}
+
+// GetEventBus возвращает шину событий коллизий
+func (w *PhysicsWorld) GetEventBus() *event.EventBus {
+	return w.eventBus
+}
+
+// GetTriggerOverlaps возвращает пересечения с датчиками на последнем шаге
+func (w *PhysicsWorld) GetTriggerOverlaps() []ContactManifold {
+	return w.triggers
+}
+
+// emitCollisionEvents сравнивает касания с прошлым шагом: новые пары получают
+// enter, сохранившиеся - stay, пропавшие - exit. Пары спящих тел не проверяются
+// на шаге и остаются касающимися без событий stay. События отправляются синхронно
+// после шага, поэтому обработчики могут менять тела и мир
+func (w *PhysicsWorld) emitCollisionEvents() {
+	if w.eventBus == nil {
+		return
+	}
+
+	previous, previousKeys := w.touching, w.touchingKeys
+	w.touching, w.touchingKeys = w.previousTouching[:0], w.previousKeys
+	clear(w.touchingKeys)
+
+	w.collectTouching(w.contacts, false)
+	w.collectTouching(w.triggers, true)
+
+	var dormant int
+	for _, pair := range previous {
+		if !w.touchingKeys[pair.key] && isDormantPair(pair.bodyA, pair.bodyB) {
+			w.touchingKeys[pair.key] = true
+			w.touching = append(w.touching, pair)
+			dormant++
+		}
+	}
+	active := w.touching[:len(w.touching)-dormant]
+
+	var pending []*event.Event
+	for _, pair := range active {
+		if previousKeys[pair.key] {
+			pending = append(pending, event.NewEvent(event.EventCollisionStay, pair.data))
+		} else {
+			pending = append(pending, event.NewEvent(event.EventCollisionEnter, pair.data))
+		}
+	}
+	for _, pair := range previous {
+		if !w.touchingKeys[pair.key] {
+			pending = append(pending, event.NewEvent(event.EventCollisionExit, pair.data))
+		}
+	}
+
+	w.previousTouching, w.previousKeys = previous, previousKeys
+
+	for _, e := range pending {
+		w.eventBus.EmitSync(e)
+	}
+}
+
+// collectTouching добавляет пары из манифолдов; несколько манифолдов пары
+// (группы треугольников сетки) дают одно событие
+func (w *PhysicsWorld) collectTouching(manifolds []ContactManifold, trigger bool) {
+	for i := range manifolds {
+		manifold := &manifolds[i]
+		key := pairKey(manifold.BodyA, manifold.BodyB)
+		if w.touchingKeys[key] {
+			continue
+		}
+		w.touchingKeys[key] = true
+
+		// Самая глубокая точка контакта
+		var point ContactPoint
+		for j, p := range manifold.Points {
+			if j == 0 || p.Depth > point.Depth {
+				point = p
+			}
+		}
+
+		w.touching = append(w.touching, touchingPair{
+			key:   key,
+			bodyA: manifold.BodyA,
+			bodyB: manifold.BodyB,
+			data: &event.CollisionData{
+				EntityA:   manifold.BodyA.EntityID,
+				EntityB:   manifold.BodyB.EntityID,
+				BodyA:     manifold.BodyA,
+				BodyB:     manifold.BodyB,
+				Point:     point.Position,
+				Normal:    manifold.Normal,
+				IsTrigger: trigger,
+			},
+		})
+	}
+}
+
+// isDormantPair сообщает, что пару пропустили из-за сна: одно из тел спит,
+// и ни одно не может сдвинуть другое
+func isDormantPair(a, b *RigidBody) bool {
+	return (a.sleeping || b.sleeping) && !a.isAwake() && !b.isAwake()
+}
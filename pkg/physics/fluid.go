@@ -6,13 +6,55 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
+// FluidSolverType метод решения жидкости
+type FluidSolverType int
+
+const (
+	FluidSolverSPH FluidSolverType = iota // Явный SPH: давление из плотности, силы и интегрирование
+	FluidSolverPBF                        // Position Based Fluids: ограничения плотности на положения
+)
+
+// String возвращает название метода
+func (t FluidSolverType) String() string {
+	switch t {
+	case FluidSolverSPH:
+		return "sph"
+	case FluidSolverPBF:
+		return "pbf"
+	default:
+		return "unknown"
+	}
+}
+
+// sphKernels коэффициенты ядер SPH для текущего радиуса сглаживания
+type sphKernels struct {
+	h, h2     float32
+	poly6     float32
+	spiky     float32
+	viscosity float32
+}
+
+// newSPHKernels вычисляет коэффициенты ядер для радиуса h
+func newSPHKernels(h float32) sphKernels {
+	h2 := h * h
+	h6 := h2 * h2 * h2
+	h9 := h6 * h2 * h
+	return sphKernels{
+		h:         h,
+		h2:        h2,
+		poly6:     float32(315.0 / (64.0 * math.Pi * float64(h9))),
+		spiky:     float32(-45.0 / (math.Pi * float64(h6))),
+		viscosity: float32(45.0 / (math.Pi * float64(h6))),
+	}
+}
+
 // FluidParticle частица жидкости
 type FluidParticle struct {
 	Position mgl32.Vec3
 	Velocity mgl32.Vec3
-	Force    mgl32.Vec3
+	Force    mgl32.Vec3 // Сумма сил (только SPH)
 	Density  float32
-	Pressure float32
+	Pressure float32 // Давление (только SPH)
 }
 
 // FluidSystem система симуляции жидкости (SPH - Smoothed Particle Hydrodynamics)
@@ -31,6 +73,28 @@ type FluidSystem struct {
 	Bounds       mgl32.Vec3 // Границы контейнера
 	Damping      float32    // Затухание при столкновении
 	TimeStep     float32    // Шаг времени
+
+	// Метод решения; FluidSolverPBF устойчив при обычной гравитации и шаге кадра
+	Solver FluidSolverType
+
+	// Параметры PBF
+	Iterations    int     // Итерации ограничений плотности за шаг
+	Relaxation    float32 // Регуляризация знаменателя множителя ограничения
+	Vorticity     float32 // Сохранение вихрей (vorticity confinement), м/с
+	XSPHViscosity float32 // Доля сглаживания скорости по соседям (0-1)
+
+	// Горутины для проходов плотности, сил и интегрирования; 0 - по числу процессоров
+	Workers int
+
+	// Связь с телами мира (PhysicsWorld.AddFluid)
+	ParticleRadius float32 // Радиус частицы при столкновениях с телами
+	BodyFriction   float32 // Доля касательной скорости частицы, гасимая о тело (0-1)
+	Buoyancy       float32 // Множитель выталкивающей силы; 1 - закон Архимеда с RestDensity
+	BodyDrag       float32 // Сопротивление движению погруженных тел, 1/с
+	CollisionMask  uint32  // Слои тел, с которыми сталкиваются частицы
+
+	grid fluidGrid  // Сетка соседей, перестраивается каждый шаг
+	pbf  pbfBuffers // Рабочие массивы PBF
 }
 
 // NewFluidSystem создает новую систему жидкости
@@ -46,9 +110,31 @@ func NewFluidSystem() *FluidSystem {
 		Bounds:          mgl32.Vec3{10, 10, 10},
 		Damping:         0.01,    // Почти нет отскока - частицы прилипают
 		TimeStep:        0.016,
+
+		Iterations:    4,
+		Relaxation:    1,
+		Vorticity:     0.01,
+		XSPHViscosity: 0.1,
+
+		ParticleRadius: 0.1,
+		BodyFriction:   0.1,
+		Buoyancy:       1,
+		BodyDrag:       1,
+		CollisionMask:  AllLayers,
 	}
 }
 
+// NewPBFFluidSystem создает жидкость с решателем PBF при земной гравитации.
+// Масса частицы рассчитана на расстановку с шагом SmoothingRadius/2
+func NewPBFFluidSystem() *FluidSystem {
+	fs := NewFluidSystem()
+	fs.Solver = FluidSolverPBF
+	fs.Gravity = mgl32.Vec3{0, -9.81, 0}
+	spacing := fs.SmoothingRadius / 2
+	fs.Mass = fs.RestDensity * spacing * spacing * spacing
+	return fs
+}
+
 // AddParticle добавляет частицу в систему
 func (fs *FluidSystem) AddParticle(position mgl32.Vec3) *FluidParticle {
 	particle := &FluidParticle{
@@ -62,90 +148,145 @@ func (fs *FluidSystem) AddParticle(position mgl32.Vec3) *FluidParticle {
 	return particle
 }
 
-// Update обновляет симуляцию жидкости
+// Update обновляет симуляцию жидкости без тел выбранным методом Solver. Соседи
+// ищутся по сетке с ячейкой SmoothingRadius, проходы по частицам выполняются параллельно
 func (fs *FluidSystem) Update(dt float32) {
-	// Вычисляем плотность и давление
-	fs.computeDensityPressure()
+	fs.update(nil, dt)
+}
+
+// update делает шаг жидкости; с миром частицы обмениваются импульсами с телами
+func (fs *FluidSystem) update(w *PhysicsWorld, dt float32) {
+	if len(fs.Particles) == 0 {
+		return
+	}
+
+	if fs.Solver == FluidSolverPBF {
+		fs.stepPBF(dt)
+	} else {
+		fs.grid.build(fs.Particles, fs.Bounds, fs.SmoothingRadius)
 
-	// Вычисляем силы
-	fs.computeForces()
+		// Вычисляем плотность и давление
+		fs.computeDensityPressure()
 
-	// Интегрируем
-	fs.integrate(dt)
+		// Вычисляем силы
+		fs.computeForces()
+
+		// Интегрируем
+		fs.integrate(dt)
+	}
+
+	if w != nil {
+		fs.coupleBodies(w, dt)
+	}
 }
 
 // computeDensityPressure вычисляет плотность и давление для каждой частицы
 func (fs *FluidSystem) computeDensityPressure() {
-	h2 := fs.SmoothingRadius * fs.SmoothingRadius
-
-	for _, pi := range fs.Particles {
-		pi.Density = 0
+	g := &fs.grid
+	kernels := newSPHKernels(fs.SmoothingRadius)
+
+	parallelFor(len(g.order), fs.Workers, func(start, end int) {
+		var ranges [9]cellRange
+		for k := start; k < end; k++ {
+			position := g.positions[k]
+			density := float32(0)
+
+			// Суммируем вклад соседних частиц, включая саму частицу.
+			// Во внутренних циклах координаты считаются покомпонентно:
+			// массивы mgl32 компилятор не держит в регистрах
+			for _, cells := range ranges[:g.neighborRanges(k, &ranges)] {
+				for j := cells.start; j < cells.end; j++ {
+					q := &g.positions[j]
+					dx, dy, dz := q[0]-position[0], q[1]-position[1], q[2]-position[2]
+					r2 := dx*dx + dy*dy + dz*dz
+
+					if r2 < kernels.h2 {
+						// Poly6 kernel
+						density += fs.Mass * kernels.poly6Kernel(r2)
+					}
+				}
+			}
 
-		// Суммируем вклад всех соседних частиц
-		for _, pj := range fs.Particles {
-			diff := pj.Position.Sub(pi.Position)
-			r2 := diff.Len() * diff.Len()
+			// Вычисляем давление из плотности
+			g.densities[k] = density
+			g.pressures[k] = fs.GasConstant * (density - fs.RestDensity)
 
-			if r2 < h2 {
-				// Poly6 kernel
-				pi.Density += fs.Mass * fs.poly6Kernel(r2)
-			}
+			p := fs.Particles[g.order[k]]
+			p.Density = g.densities[k]
+			p.Pressure = g.pressures[k]
 		}
-
-		// Вычисляем давление из плотности
-		pi.Pressure = fs.GasConstant * (pi.Density - fs.RestDensity)
-	}
+	})
 }
 
 // computeForces вычисляет силы для каждой частицы
 func (fs *FluidSystem) computeForces() {
-	h := fs.SmoothingRadius
-
-	for _, pi := range fs.Particles {
-		pressureForce := mgl32.Vec3{0, 0, 0}
-		viscosityForce := mgl32.Vec3{0, 0, 0}
-
-		for _, pj := range fs.Particles {
-			if pi == pj {
-				continue
+	g := &fs.grid
+	kernels := newSPHKernels(fs.SmoothingRadius)
+
+	parallelFor(len(g.order), fs.Workers, func(start, end int) {
+		var ranges [9]cellRange
+		for k := start; k < end; k++ {
+			position, velocity := g.positions[k], g.velocities[k]
+			pressure := g.pressures[k]
+			var px, py, pz float32 // Сила давления
+			var vx, vy, vz float32 // Сила вязкости
+
+			for _, cells := range ranges[:g.neighborRanges(k, &ranges)] {
+				for j := cells.start; j < cells.end; j++ {
+					if int(j) == k {
+						continue
+					}
+
+					q := &g.positions[j]
+					dx, dy, dz := q[0]-position[0], q[1]-position[1], q[2]-position[2]
+					r2 := dx*dx + dy*dy + dz*dz
+					if r2 >= kernels.h2 {
+						continue
+					}
+					r := sqrt32(r2)
+
+					if r > 0.0001 {
+						// Сила давления (Spiky kernel gradient)
+						f := -fs.Mass * (pressure + g.pressures[j]) / (2.0 * g.densities[j]) * kernels.spikyGradient(r) / r
+						px += dx * f
+						py += dy * f
+						pz += dz * f
+
+						// Сила вязкости (Viscosity kernel laplacian)
+						u := &g.velocities[j]
+						f = fs.Mass * fs.Viscosity / g.densities[j] * kernels.viscosityLaplacian(r)
+						vx += (u[0] - velocity[0]) * f
+						vy += (u[1] - velocity[1]) * f
+						vz += (u[2] - velocity[2]) * f
+					}
+				}
 			}
+			pressureForce := mgl32.Vec3{px, py, pz}
+			viscosityForce := mgl32.Vec3{vx, vy, vz}
 
-			diff := pj.Position.Sub(pi.Position)
-			r := diff.Len()
-
-			if r < h && r > 0.0001 {
-				// Сила давления (Spiky kernel gradient)
-				pressureForce = pressureForce.Add(
-					diff.Normalize().Mul(-fs.Mass * (pi.Pressure + pj.Pressure) / (2.0 * pj.Density) * fs.spikyGradient(r)),
-				)
+			// Гравитация
+			gravityForce := fs.Gravity.Mul(g.densities[k])
 
-				// Сила вязкости (Viscosity kernel laplacian)
-				viscosityForce = viscosityForce.Add(
-					pj.Velocity.Sub(pi.Velocity).Mul(fs.Mass * fs.Viscosity / pj.Density * fs.viscosityLaplacian(r)),
-				)
-			}
+			// Суммируем все силы
+			fs.Particles[g.order[k]].Force = pressureForce.Add(viscosityForce).Add(gravityForce)
 		}
-
-		// Гравитация
-		gravityForce := fs.Gravity.Mul(pi.Density)
-
-		// Суммируем все силы
-		pi.Force = pressureForce.Add(viscosityForce).Add(gravityForce)
-	}
+	})
 }
 
 // integrate интегрирует частицы
 func (fs *FluidSystem) integrate(dt float32) {
-	for _, p := range fs.Particles {
-		// Обновляем скорость
-		p.Velocity = p.Velocity.Add(p.Force.Mul(dt / p.Density))
+	parallelFor(len(fs.Particles), fs.Workers, func(start, end int) {
+		for _, p := range fs.Particles[start:end] {
+			// Обновляем скорость
+			p.Velocity = p.Velocity.Add(p.Force.Mul(dt / p.Density))
 
-		// Обновляем позицию
-		p.Position = p.Position.Add(p.Velocity.Mul(dt))
+			// Обновляем позицию
+			p.Position = p.Position.Add(p.Velocity.Mul(dt))
 
-		// Обрабатываем столкновения с границами
-		fs.handleBoundaryCollision(p)
-	}
+			// Обрабатываем столкновения с границами
+			fs.handleBoundaryCollision(p)
+		}
+	})
 }
 
 // handleBoundaryCollision обрабатывает столкновение с границами
@@ -196,41 +337,26 @@ func (fs *FluidSystem) handleBoundaryCollision(p *FluidParticle) {
 }
 
 // poly6Kernel ядро Poly6 для вычисления плотности
-func (fs *FluidSystem) poly6Kernel(r2 float32) float32 {
-	h := fs.SmoothingRadius
-	h2 := h * h
-	h9 := h2 * h2 * h2 * h2 * h
-
-	if r2 >= h2 {
+func (k *sphKernels) poly6Kernel(r2 float32) float32 {
+	if r2 >= k.h2 {
 		return 0
 	}
-
-	coeff := 315.0 / (64.0 * math.Pi * h9)
-	return float32(coeff) * (h2 - r2) * (h2 - r2) * (h2 - r2)
+	d := k.h2 - r2
+	return k.poly6 * d * d * d
 }
 
 // spikyGradient градиент ядра Spiky для силы давления
-func (fs *FluidSystem) spikyGradient(r float32) float32 {
-	h := fs.SmoothingRadius
-	h6 := h * h * h * h * h * h
-
-	if r >= h {
+func (k *sphKernels) spikyGradient(r float32) float32 {
+	if r >= k.h {
 		return 0
 	}
-
-	coeff := -45.0 / (math.Pi * h6)
-	return float32(coeff) * (h - r) * (h - r)
+	return k.spiky * (k.h - r) * (k.h - r)
 }
 
 // viscosityLaplacian лапласиан ядра вязкости
-func (fs *FluidSystem) viscosityLaplacian(r float32) float32 {
-	h := fs.SmoothingRadius
-	h6 := h * h * h * h * h * h
-
-	if r >= h {
+func (k *sphKernels) viscosityLaplacian(r float32) float32 {
+	if r >= k.h {
 		return 0
 	}
-
-	coeff := 45.0 / (math.Pi * h6)
-	return float32(coeff) * (h - r)
+	return k.viscosity * (k.h - r)
 }
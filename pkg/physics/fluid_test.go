@@ -0,0 +1,84 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// newFluidBlock создает систему жидкости с кубом частиц через половину радиуса сглаживания.
+// Масса частицы подобрана так, чтобы начальная плотность была близка к плотности покоя
+func newFluidBlock(solver FluidSolverType, count int) *FluidSystem {
+	fluid := NewPBFFluidSystem()
+	if solver == FluidSolverSPH {
+		fluid = NewFluidSystem()
+	}
+	spacing := fluid.SmoothingRadius / 2
+	fluid.Mass = fluid.RestDensity * spacing * spacing * spacing
+
+	side := int(math.Ceil(math.Cbrt(float64(count))))
+	fluid.Bounds = mgl32.Vec3{
+		float32(side)*spacing + 2,
+		float32(side)*spacing + 2,
+		float32(side)*spacing + 2,
+	}
+	start := mgl32.Vec3{-float32(side) * spacing / 2, spacing, -float32(side) * spacing / 2}
+	for i := 0; i < count; i++ {
+		x, y, z := i%side, i/side%side, i/(side*side)
+		fluid.AddParticle(start.Add(mgl32.Vec3{float32(x), float32(y), float32(z)}.Mul(spacing)))
+	}
+	return fluid
+}
+
+// testPoly6 ядро плотности SPH, посчитанное независимо от fluid.go
+func testPoly6(r2, h float32) float32 {
+	h2 := h * h
+	d := h2 - r2
+	return float32(315/(64*math.Pi*math.Pow(float64(h), 9))) * d * d * d
+}
+
+// TestFluidDensityGrid проверяет, что сетка соседей дает ту же плотность,
+// что и полный перебор пар, для перемешанных частиц
+func TestFluidDensityGrid(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	fluid := newFluidBlock(FluidSolverSPH, 2000)
+	for _, p := range fluid.Particles {
+		p.Position = p.Position.Add(mgl32.Vec3{rng.Float32(), rng.Float32(), rng.Float32()}.Mul(0.3))
+	}
+	fluid.Update(0)
+
+	h2 := fluid.SmoothingRadius * fluid.SmoothingRadius
+	for i, pi := range fluid.Particles {
+		var expected float32
+		for _, pj := range fluid.Particles {
+			diff := pj.Position.Sub(pi.Position)
+			if r2 := diff.Dot(diff); r2 < h2 {
+				expected += fluid.Mass * testPoly6(r2, fluid.SmoothingRadius)
+			}
+		}
+		if math.Abs(float64(expected-pi.Density)) > 1e-3*float64(expected) {
+			t.Fatalf("частица %d: плотность %v, полный перебор дает %v", i, pi.Density, expected)
+		}
+	}
+}
+
+// BenchmarkFluid измеряет шаг жидкости из 20000 частиц для каждого метода
+// в одной горутине и на всех процессорах
+func BenchmarkFluid(b *testing.B) {
+	for _, solver := range []FluidSolverType{FluidSolverSPH, FluidSolverPBF} {
+		for _, workers := range []int{1, 0} {
+			b.Run(fmt.Sprintf("%s/workers=%d", solver, workers), func(b *testing.B) {
+				fluid := newFluidBlock(solver, 20000)
+				fluid.Workers = workers
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					fluid.Update(fluid.TimeStep)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Параметры связи жидкости с телами
+const (
+	maxBuoyancySamples = 6     // Точек выборки объема тела по каждой оси
+	samplePointRadius  = 0.001 // Радиус пробы при проверке, лежит ли точка в теле
+)
+
+// AddFluid добавляет жидкость в мир: Step обновляет ее после машин и до
+// интегрирования тел. Частицы сталкиваются с телами и толкают их, а погруженные
+// динамические тела получают выталкивающую силу и сопротивление.
+// FluidSystem.Update для такой жидкости вызывать не нужно
+func (w *PhysicsWorld) AddFluid(fluid *FluidSystem) *FluidSystem {
+	w.fluids = append(w.fluids, fluid)
+	return fluid
+}
+
+// RemoveFluid удаляет жидкость из мира
+func (w *PhysicsWorld) RemoveFluid(fluid *FluidSystem) {
+	for i, f := range w.fluids {
+		if f == fluid {
+			w.fluids = append(w.fluids[:i], w.fluids[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetFluids возвращает жидкости мира
+func (w *PhysicsWorld) GetFluids() []*FluidSystem {
+	return w.fluids
+}
+
+// coupleBodies обменивается импульсами с телами мира после интегрирования частиц
+func (fs *FluidSystem) coupleBodies(w *PhysicsWorld, dt float32) {
+	for _, body := range w.Bodies {
+		if body.IsTrigger || body.Shape == LiquidShape || body.Layer&fs.CollisionMask == 0 {
+			continue
+		}
+
+		target := newCollider(body)
+		fs.collideParticles(w, &target)
+		if body.Type == Dynamic && !body.sleeping {
+			fs.applyBuoyancy(w, &target, dt)
+		}
+	}
+}
+
+// collideParticles выталкивает частицы из тела и гасит их скорость сближения.
+// Противоположный импульс достается телу, поэтому брызги толкают ящики.
+// Кандидаты берутся из ячеек сетки вокруг тела с запасом в ячейку: за шаг
+// частица смещается меньше радиуса сглаживания
+func (fs *FluidSystem) collideParticles(w *PhysicsWorld, target *collider) {
+	body := target.body
+	box := target.aabb()
+	margin := fs.ParticleRadius + fs.grid.cellSize
+	lo := box.Min.Sub(mgl32.Vec3{margin, margin, margin})
+	hi := box.Max.Add(mgl32.Vec3{margin, margin, margin})
+
+	probe := collider{
+		kind:     colliderSphere,
+		rotation: mgl32.Ident3(),
+		radius:   fs.ParticleRadius,
+	}
+	invParticleMass := 1 / fs.Mass
+
+	fs.grid.forEachInBox(lo, hi, func(i int32) {
+		p := fs.Particles[i]
+		probe.center = p.Position
+		manifold, ok := collide(&probe, target)
+		if !ok {
+			return
+		}
+		depth := manifold.MaxDepth()
+		if depth <= 0 {
+			return
+		}
+
+		// Нормаль направлена от частицы к телу
+		normal := manifold.Normal
+		p.Position = p.Position.Sub(normal.Mul(depth))
+		point := p.Position.Add(normal.Mul(fs.ParticleRadius))
+
+		relative := p.Velocity.Sub(body.GetPointVelocity(point))
+		approach := relative.Dot(normal)
+		if approach <= 0 {
+			return
+		}
+
+		// Неупругий удар частицы о тело с учетом эффективной массы тела в точке
+		k := invParticleMass
+		if mass := body.pointMass(point, normal); mass > 0 && !body.sleeping {
+			k += 1 / mass
+		}
+		impulse := normal.Mul(approach / k)
+
+		// Трение гасит часть касательной скорости частицы
+		tangent := relative.Sub(normal.Mul(approach))
+		impulse = impulse.Add(tangent.Mul(fs.BodyFriction * fs.Mass))
+
+		p.Velocity = p.Velocity.Sub(impulse.Mul(invParticleMass))
+		if body.Type != Dynamic {
+			return
+		}
+		if body.sleeping {
+			// Спящее тело будит только заметный удар
+			if impulse.Len()*body.inverseMass() < w.SleepLinearVelocity {
+				return
+			}
+			w.wakeBody(body)
+		}
+		body.applyImpulseAtPoint(impulse, point)
+	})
+}
+
+// applyBuoyancy прикладывает к телу выталкивающую силу и сопротивление жидкости.
+// Объем тела выбирается решеткой точек внутри AABB; точка погружена, если
+// лежит ниже уровня жидкости рядом с телом. Уровень - самая высокая частица
+// в AABB тела, расширенном на радиус сглаживания: вытесненная телом жидкость
+// стоит вокруг него, а не под ним
+func (fs *FluidSystem) applyBuoyancy(w *PhysicsWorld, target *collider, dt float32) {
+	body := target.body
+	box := target.aabb()
+	h := fs.SmoothingRadius
+	lo := box.Min.Sub(mgl32.Vec3{h, h, h})
+	hi := box.Max.Add(mgl32.Vec3{h, h, h})
+
+	level := float32(math.Inf(-1))
+	var flow mgl32.Vec3
+	count := 0
+	fs.grid.forEachInBox(lo, hi, func(i int32) {
+		p := fs.Particles[i]
+		if !pointInBox(p.Position, lo, hi) {
+			return
+		}
+		level = max(level, p.Position.Y())
+		flow = flow.Add(p.Velocity)
+		count++
+	})
+	if count == 0 || level <= box.Min.Y() {
+		return
+	}
+	flow = flow.Mul(1 / float32(count))
+
+	// Решетка точек выборки по ячейкам около половины радиуса сглаживания
+	size := box.Max.Sub(box.Min)
+	var steps [3]int
+	volume := float32(1)
+	for axis := 0; axis < 3; axis++ {
+		steps[axis] = min(max(int(math.Ceil(float64(size[axis]/(h/2)))), 1), maxBuoyancySamples)
+		volume *= size[axis] / float32(steps[axis])
+	}
+
+	probe := collider{
+		kind:     colliderSphere,
+		rotation: mgl32.Ident3(),
+		radius:   samplePointRadius,
+	}
+	var submerged []mgl32.Vec3
+	for x := 0; x < steps[0]; x++ {
+		for y := 0; y < steps[1]; y++ {
+			for z := 0; z < steps[2]; z++ {
+				sample := box.Min.Add(mgl32.Vec3{
+					(float32(x) + 0.5) / float32(steps[0]) * size[0],
+					(float32(y) + 0.5) / float32(steps[1]) * size[1],
+					(float32(z) + 0.5) / float32(steps[2]) * size[2],
+				})
+				if sample.Y() > level {
+					continue
+				}
+				probe.center = sample
+				if _, ok := collide(&probe, target); ok {
+					submerged = append(submerged, sample)
+				}
+			}
+		}
+	}
+	if len(submerged) == 0 {
+		return
+	}
+
+	// Архимедова сила в центре погруженного объема
+	var center mgl32.Vec3
+	for _, sample := range submerged {
+		center = center.Add(sample)
+	}
+	center = center.Mul(1 / float32(len(submerged)))
+	displaced := volume * float32(len(submerged))
+	body.applyImpulseAtPoint(w.Gravity.Mul(-fs.Buoyancy*fs.RestDensity*displaced*dt), center)
+
+	// Сопротивление по точкам выборки относительно течения. Неявная форма
+	// не дает сопротивлению развернуть скорость тела за один шаг
+	k := fs.BodyDrag * fs.RestDensity * volume * dt
+	scale := 1 / (1 + k*float32(len(submerged))*body.inverseMass())
+	for _, sample := range submerged {
+		relative := body.GetPointVelocity(sample).Sub(flow)
+		body.applyImpulseAtPoint(relative.Mul(-k*scale), sample)
+	}
+}
+
+// pointInBox проверяет, что точка лежит в прямоугольном объеме
+func pointInBox(p, lo, hi mgl32.Vec3) bool {
+	return p.X() >= lo.X() && p.X() <= hi.X() &&
+		p.Y() >= lo.Y() && p.Y() <= hi.Y() &&
+		p.Z() >= lo.Z() && p.Z() <= hi.Z()
+}
@@ -0,0 +1,182 @@
+package physics
+
+import (
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Параметры сетки соседей и параллельных проходов жидкости
+const (
+	maxFluidCells        = 1 << 21 // Предел числа ячеек: при мелком радиусе ячейки укрупняются
+	minParallelParticles = 1024    // Меньше частиц считаем в одной горутине
+)
+
+// fluidGrid равномерная сетка соседей с ячейкой не меньше SmoothingRadius:
+// соседи частицы лежат в 27 ячейках вокруг нее. Частицы сортируются по ячейкам
+// подсчетом, а их данные копируются в плотные массивы в порядке ячеек, чтобы
+// проходы читали память подряд. Сетка покрывает контейнер Bounds; частицы за
+// его пределами попадают в крайние ячейки
+type fluidGrid struct {
+	cellSize float32
+	origin   mgl32.Vec3
+	dims     [3]int
+
+	cellStart []int32 // Начало ячейки в order; последняя запись - число частиц
+	cellOf    []int32 // Ячейка частицы по ее индексу в Particles
+	order     []int32 // Индексы частиц в порядке ячеек
+
+	// Данные частиц в порядке order
+	positions  []mgl32.Vec3
+	velocities []mgl32.Vec3
+	densities  []float32
+	pressures  []float32
+}
+
+// build раскладывает частицы по ячейкам
+func (g *fluidGrid) build(particles []*FluidParticle, bounds mgl32.Vec3, radius float32) {
+	g.cellSize = radius
+	g.origin = mgl32.Vec3{-bounds.X() / 2, 0, -bounds.Z() / 2}
+	cells := g.resize(bounds)
+
+	n := len(particles)
+	g.cellStart = resizeSlice(g.cellStart, cells+1)
+	g.cellOf = resizeSlice(g.cellOf, n)
+	g.order = resizeSlice(g.order, n)
+	clear(g.cellStart)
+
+	// Подсчет частиц в ячейках и префиксные суммы
+	for i, p := range particles {
+		x, y, z := g.cellCoords(p.Position)
+		cell := int32(g.cellIndex(x, y, z))
+		g.cellOf[i] = cell
+		g.cellStart[cell+1]++
+	}
+	for c := 1; c <= cells; c++ {
+		g.cellStart[c] += g.cellStart[c-1]
+	}
+
+	// Раскладка: следующая свободная позиция ячейки берется из cellStart,
+	// затем начала ячеек восстанавливаются сдвигом
+	for i := range particles {
+		cell := g.cellOf[i]
+		g.order[g.cellStart[cell]] = int32(i)
+		g.cellStart[cell]++
+	}
+	copy(g.cellStart[1:], g.cellStart[:cells])
+	g.cellStart[0] = 0
+
+	g.positions = resizeSlice(g.positions, n)
+	g.velocities = resizeSlice(g.velocities, n)
+	g.densities = resizeSlice(g.densities, n)
+	g.pressures = resizeSlice(g.pressures, n)
+	for k, i := range g.order {
+		g.positions[k] = particles[i].Position
+		g.velocities[k] = particles[i].Velocity
+	}
+}
+
+// resize подбирает размеры сетки под контейнер и возвращает число ячеек
+func (g *fluidGrid) resize(bounds mgl32.Vec3) int {
+	for {
+		cells := 1
+		for axis := 0; axis < 3; axis++ {
+			g.dims[axis] = max(1, int(math.Ceil(float64(bounds[axis]/g.cellSize))))
+			cells *= g.dims[axis]
+		}
+		if cells <= maxFluidCells {
+			return cells
+		}
+		g.cellSize *= 1.25
+	}
+}
+
+// cellCoords возвращает ячейку точки, прижатую к границам сетки
+func (g *fluidGrid) cellCoords(position mgl32.Vec3) (int, int, int) {
+	var coords [3]int
+	for axis := 0; axis < 3; axis++ {
+		c := int(math.Floor(float64((position[axis] - g.origin[axis]) / g.cellSize)))
+		coords[axis] = min(max(c, 0), g.dims[axis]-1)
+	}
+	return coords[0], coords[1], coords[2]
+}
+
+// cellIndex возвращает индекс ячейки; соседние по X ячейки идут подряд
+func (g *fluidGrid) cellIndex(x, y, z int) int {
+	return (z*g.dims[1]+y)*g.dims[0] + x
+}
+
+// cellRange непрерывный отрезок [start, end) частиц в order
+type cellRange struct {
+	start, end int32
+}
+
+// neighborRanges записывает в ranges ряды ячеек вокруг частицы k (в порядке order)
+// и возвращает их число. Каждый ряд - до трех соседних по X ячеек, которые
+// в order идут подряд, поэтому 27 ячеек сводятся к девяти отрезкам.
+// Ячейка берется на момент build: PBF сдвигает частицы между перестройками
+func (g *fluidGrid) neighborRanges(k int, ranges *[9]cellRange) int {
+	cell := int(g.cellOf[g.order[k]])
+	x := cell % g.dims[0]
+	y := cell / g.dims[0] % g.dims[1]
+	z := cell / (g.dims[0] * g.dims[1])
+	x0, x1 := max(x-1, 0), min(x+1, g.dims[0]-1)
+	count := 0
+	for nz := max(z-1, 0); nz <= min(z+1, g.dims[2]-1); nz++ {
+		for ny := max(y-1, 0); ny <= min(y+1, g.dims[1]-1); ny++ {
+			base := g.cellIndex(0, ny, nz)
+			ranges[count] = cellRange{g.cellStart[base+x0], g.cellStart[base+x1+1]}
+			count++
+		}
+	}
+	return count
+}
+
+// forEachInBox вызывает fn для индексов в Particles частиц из ячеек,
+// пересекающих объем [lo, hi]. Положения частиц fn проверяет сама
+func (g *fluidGrid) forEachInBox(lo, hi mgl32.Vec3, fn func(i int32)) {
+	x0, y0, z0 := g.cellCoords(lo)
+	x1, y1, z1 := g.cellCoords(hi)
+	for z := z0; z <= z1; z++ {
+		for y := y0; y <= y1; y++ {
+			base := g.cellIndex(0, y, z)
+			for k := g.cellStart[base+x0]; k < g.cellStart[base+x1+1]; k++ {
+				fn(g.order[k])
+			}
+		}
+	}
+}
+
+// resizeSlice возвращает срез длины n, переиспользуя память
+func resizeSlice[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
+
+// parallelFor делит [0, n) на равные части и выполняет body в workers горутинах.
+// workers <= 0 - по числу процессоров; малые объемы считаются в текущей горутине
+func parallelFor(n, workers int, body func(start, end int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 || n < minParallelParticles {
+		body(0, n)
+		return
+	}
+
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += chunk {
+		end := min(start+chunk, n)
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			body(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,287 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// pbfBuffers рабочие массивы решателя PBF. Все, кроме previous, идут в порядке
+// ячеек сетки, как положения в fluidGrid
+type pbfBuffers struct {
+	previous   []mgl32.Vec3 // Положения до шага по индексу в Particles
+	lambdas    []float32    // Множители ограничений плотности
+	deltas     []mgl32.Vec3 // Поправки положений на итерации
+	vorticity  []mgl32.Vec3 // Завихренность скорости
+	velocities []mgl32.Vec3 // Скорости после вязкости XSPH и сохранения вихрей
+}
+
+// stepPBF делает шаг Position Based Fluids (Macklin, Müller 2013): положения
+// предсказываются под действием гравитации, затем итерации Якоби сдвигают
+// частицы, пока плотность не опустится до RestDensity. Ограничение одностороннее:
+// разреженная жидкость у поверхности не стягивается. Скорость берется из смещения
+// за шаг, после чего сглаживается XSPH и получает силу сохранения вихрей
+func (fs *FluidSystem) stepPBF(dt float32) {
+	// Скорость берется из смещения, поэтому нулевой шаг ничего не делает
+	if dt <= 0 {
+		return
+	}
+	b := &fs.pbf
+	n := len(fs.Particles)
+	b.previous = resizeSlice(b.previous, n)
+
+	// Предсказываем положения
+	parallelFor(n, fs.Workers, func(start, end int) {
+		for i := start; i < end; i++ {
+			p := fs.Particles[i]
+			b.previous[i] = p.Position
+			p.Velocity = p.Velocity.Add(fs.Gravity.Mul(dt))
+			p.Position = fs.clampToBounds(p.Position.Add(p.Velocity.Mul(dt)))
+		}
+	})
+
+	g := &fs.grid
+	g.build(fs.Particles, fs.Bounds, fs.SmoothingRadius)
+	b.lambdas = resizeSlice(b.lambdas, n)
+	b.deltas = resizeSlice(b.deltas, n)
+	b.vorticity = resizeSlice(b.vorticity, n)
+	b.velocities = resizeSlice(b.velocities, n)
+	kernels := newSPHKernels(fs.SmoothingRadius)
+
+	for iteration := 0; iteration < fs.Iterations; iteration++ {
+		fs.computeLambdas(&kernels)
+		fs.computeDeltas(&kernels)
+		parallelFor(n, fs.Workers, func(start, end int) {
+			for k := start; k < end; k++ {
+				g.positions[k] = fs.clampToBounds(g.positions[k].Add(b.deltas[k]))
+			}
+		})
+	}
+
+	// Скорость по смещению за шаг
+	parallelFor(n, fs.Workers, func(start, end int) {
+		for k := start; k < end; k++ {
+			g.velocities[k] = g.positions[k].Sub(b.previous[g.order[k]]).Mul(1 / dt)
+		}
+	})
+
+	// Плотность для весов соседей - по итоговым положениям
+	fs.computeLambdas(&kernels)
+	if fs.Vorticity != 0 {
+		fs.computeVorticity(&kernels)
+	}
+	fs.computeVelocities(&kernels, dt)
+
+	parallelFor(n, fs.Workers, func(start, end int) {
+		for k := start; k < end; k++ {
+			p := fs.Particles[g.order[k]]
+			p.Position = g.positions[k]
+			p.Velocity = b.velocities[k]
+			p.Density = g.densities[k]
+			p.Force = mgl32.Vec3{}
+			p.Pressure = 0
+		}
+	})
+}
+
+// computeLambdas считает плотность частиц и множители ограничений
+// C = max(ρ/ρ0 - 1, 0). Знаменатель - сумма квадратов градиентов C по всем
+// частицам плюс Relaxation, которая не дает множителю расти у одиноких частиц
+func (fs *FluidSystem) computeLambdas(kernels *sphKernels) {
+	g := &fs.grid
+	b := &fs.pbf
+	scale := fs.Mass / fs.RestDensity
+
+	parallelFor(len(g.order), fs.Workers, func(start, end int) {
+		var ranges [9]cellRange
+		for k := start; k < end; k++ {
+			position := g.positions[k]
+			density := float32(0)
+			var gx, gy, gz float32 // Градиент по самой частице
+			gradients := float32(0)
+
+			for _, cells := range ranges[:g.neighborRanges(k, &ranges)] {
+				for j := cells.start; j < cells.end; j++ {
+					q := &g.positions[j]
+					dx, dy, dz := position[0]-q[0], position[1]-q[1], position[2]-q[2]
+					r2 := dx*dx + dy*dy + dz*dz
+					if r2 >= kernels.h2 {
+						continue
+					}
+					density += fs.Mass * kernels.poly6Kernel(r2)
+
+					r := sqrt32(r2)
+					if int(j) == k || r <= 0.0001 {
+						continue
+					}
+					f := scale * kernels.spikyGradient(r) / r
+					gx += dx * f
+					gy += dy * f
+					gz += dz * f
+					gradients += f * f * r2
+				}
+			}
+			gradients += gx*gx + gy*gy + gz*gz
+
+			g.densities[k] = density
+			constraint := max(density/fs.RestDensity-1, 0)
+			b.lambdas[k] = -constraint / (gradients + fs.Relaxation)
+		}
+	})
+}
+
+// computeDeltas считает поправки положений по множителям частицы и соседей
+func (fs *FluidSystem) computeDeltas(kernels *sphKernels) {
+	g := &fs.grid
+	b := &fs.pbf
+	scale := fs.Mass / fs.RestDensity
+
+	parallelFor(len(g.order), fs.Workers, func(start, end int) {
+		var ranges [9]cellRange
+		for k := start; k < end; k++ {
+			position := g.positions[k]
+			lambda := b.lambdas[k]
+			var x, y, z float32
+
+			for _, cells := range ranges[:g.neighborRanges(k, &ranges)] {
+				for j := cells.start; j < cells.end; j++ {
+					if int(j) == k {
+						continue
+					}
+					q := &g.positions[j]
+					dx, dy, dz := position[0]-q[0], position[1]-q[1], position[2]-q[2]
+					r2 := dx*dx + dy*dy + dz*dz
+					if r2 >= kernels.h2 {
+						continue
+					}
+					r := sqrt32(r2)
+					if r <= 0.0001 {
+						continue
+					}
+					f := (lambda + b.lambdas[j]) * scale * kernels.spikyGradient(r) / r
+					x += dx * f
+					y += dy * f
+					z += dz * f
+				}
+			}
+			b.deltas[k] = mgl32.Vec3{x, y, z}
+		}
+	})
+}
+
+// computeVorticity считает завихренность ω = ∇×v по соседям
+func (fs *FluidSystem) computeVorticity(kernels *sphKernels) {
+	g := &fs.grid
+	b := &fs.pbf
+
+	parallelFor(len(g.order), fs.Workers, func(start, end int) {
+		var ranges [9]cellRange
+		for k := start; k < end; k++ {
+			position, velocity := g.positions[k], g.velocities[k]
+			var wx, wy, wz float32
+
+			for _, cells := range ranges[:g.neighborRanges(k, &ranges)] {
+				for j := cells.start; j < cells.end; j++ {
+					if int(j) == k {
+						continue
+					}
+					q := &g.positions[j]
+					dx, dy, dz := position[0]-q[0], position[1]-q[1], position[2]-q[2]
+					r2 := dx*dx + dy*dy + dz*dz
+					if r2 >= kernels.h2 {
+						continue
+					}
+					r := sqrt32(r2)
+					if r <= 0.0001 {
+						continue
+					}
+
+					// Градиент ядра, умноженный на объем соседа
+					f := fs.Mass / g.densities[j] * kernels.spikyGradient(r) / r
+					ax, ay, az := dx*f, dy*f, dz*f
+					u := &g.velocities[j]
+					ux, uy, uz := u[0]-velocity[0], u[1]-velocity[1], u[2]-velocity[2]
+					wx += ay*uz - az*uy
+					wy += az*ux - ax*uz
+					wz += ax*uy - ay*ux
+				}
+			}
+			b.vorticity[k] = mgl32.Vec3{wx, wy, wz}
+		}
+	})
+}
+
+// computeVelocities сглаживает скорости XSPH и добавляет силу сохранения вихрей
+// ε(N×ω), где N направлена к росту |ω|: численная вязкость PBF гасит мелкие
+// вихри, и сила возвращает им энергию
+func (fs *FluidSystem) computeVelocities(kernels *sphKernels, dt float32) {
+	g := &fs.grid
+	b := &fs.pbf
+
+	parallelFor(len(g.order), fs.Workers, func(start, end int) {
+		var ranges [9]cellRange
+		for k := start; k < end; k++ {
+			position, velocity := g.positions[k], g.velocities[k]
+			var sx, sy, sz float32 // Сглаживание XSPH
+			var nx, ny, nz float32 // Градиент |ω|
+			var curl float32
+			if fs.Vorticity != 0 {
+				curl = b.vorticity[k].Len()
+			}
+
+			for _, cells := range ranges[:g.neighborRanges(k, &ranges)] {
+				for j := cells.start; j < cells.end; j++ {
+					if int(j) == k {
+						continue
+					}
+					q := &g.positions[j]
+					dx, dy, dz := position[0]-q[0], position[1]-q[1], position[2]-q[2]
+					r2 := dx*dx + dy*dy + dz*dz
+					if r2 >= kernels.h2 {
+						continue
+					}
+					volume := fs.Mass / g.densities[j]
+
+					u := &g.velocities[j]
+					f := volume * kernels.poly6Kernel(r2)
+					sx += (u[0] - velocity[0]) * f
+					sy += (u[1] - velocity[1]) * f
+					sz += (u[2] - velocity[2]) * f
+
+					if fs.Vorticity == 0 {
+						continue
+					}
+					r := sqrt32(r2)
+					if r <= 0.0001 {
+						continue
+					}
+					f = volume * (b.vorticity[j].Len() - curl) * kernels.spikyGradient(r) / r
+					nx += dx * f
+					ny += dy * f
+					nz += dz * f
+				}
+			}
+
+			result := velocity.Add(mgl32.Vec3{sx, sy, sz}.Mul(fs.XSPHViscosity))
+			if length := sqrt32(nx*nx + ny*ny + nz*nz); length > 0.0001 {
+				normal := mgl32.Vec3{nx, ny, nz}.Mul(1 / length)
+				result = result.Add(normal.Cross(b.vorticity[k]).Mul(fs.Vorticity * dt))
+			}
+			b.velocities[k] = result
+		}
+	})
+}
+
+// clampToBounds возвращает положение внутри контейнера Bounds с тем же отступом
+// от стенок, что и handleBoundaryCollision
+func (fs *FluidSystem) clampToBounds(position mgl32.Vec3) mgl32.Vec3 {
+	const epsilon = 0.01
+	lo := mgl32.Vec3{-fs.Bounds.X() / 2, 0, -fs.Bounds.Z() / 2}
+	hi := mgl32.Vec3{fs.Bounds.X() / 2, fs.Bounds.Y(), fs.Bounds.Z() / 2}
+	for axis := 0; axis < 3; axis++ {
+		if position[axis] < lo[axis] {
+			position[axis] = lo[axis] + epsilon
+		} else if position[axis] > hi[axis] {
+			position[axis] = hi[axis] - epsilon
+		}
+	}
+	return position
+}
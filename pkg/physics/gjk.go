@@ -0,0 +1,301 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Параметры GJK/EPA
+const (
+	gjkMaxIterations = 64
+	epaMaxIterations = 64
+	epaTolerance     = 1e-4
+)
+
+// supportPoint точка разности Минковского A - B вместе с исходными точками тел
+type supportPoint struct {
+	v mgl32.Vec3 // a - b
+	a mgl32.Vec3
+	b mgl32.Vec3
+}
+
+// minkowskiSupport возвращает опорную точку разности Минковского в направлении dir
+func minkowskiSupport(a, b *collider, dir mgl32.Vec3) supportPoint {
+	pa := a.support(dir)
+	pb := b.support(dir.Mul(-1))
+	return supportPoint{v: pa.Sub(pb), a: pa, b: pb}
+}
+
+// gjkIntersect проверяет пересечение двух выпуклых коллайдеров алгоритмом GJK.
+// При пересечении возвращает симплекс, содержащий начало координат (для EPA)
+func gjkIntersect(a, b *collider) ([]supportPoint, bool) {
+	dir := b.center.Sub(a.center)
+	if dir.Dot(dir) < 1e-12 {
+		dir = mgl32.Vec3{1, 0, 0}
+	}
+
+	simplex := make([]supportPoint, 0, 4)
+	simplex = append(simplex, minkowskiSupport(a, b, dir))
+	dir = simplex[0].v.Mul(-1)
+
+	for i := 0; i < gjkMaxIterations; i++ {
+		// Начало координат на симплексе - касание
+		if dir.Dot(dir) < 1e-12 {
+			return simplex, true
+		}
+
+		point := minkowskiSupport(a, b, dir)
+		if point.v.Dot(dir) < 0 {
+			return nil, false
+		}
+
+		simplex = append(simplex, point)
+		var contains bool
+		simplex, dir, contains = doSimplex(simplex)
+		if contains {
+			return simplex, true
+		}
+	}
+
+	return nil, false
+}
+
+// doSimplex оставляет часть симплекса, ближайшую к началу координат, и выбирает
+// новое направление поиска. Последняя точка симплекса - самая новая
+func doSimplex(simplex []supportPoint) ([]supportPoint, mgl32.Vec3, bool) {
+	switch len(simplex) {
+	case 2:
+		return simplexLine(simplex[1], simplex[0])
+	case 3:
+		return simplexTriangle(simplex[2], simplex[1], simplex[0])
+	default:
+		return simplexTetrahedron(simplex[3], simplex[2], simplex[1], simplex[0])
+	}
+}
+
+// simplexLine обрабатывает отрезок [b, a], a - новая точка
+func simplexLine(a, b supportPoint) ([]supportPoint, mgl32.Vec3, bool) {
+	ab := b.v.Sub(a.v)
+	ao := a.v.Mul(-1)
+
+	if ab.Dot(ao) > 0 {
+		return []supportPoint{b, a}, ab.Cross(ao).Cross(ab), false
+	}
+	return []supportPoint{a}, ao, false
+}
+
+// simplexTriangle обрабатывает треугольник [c, b, a], a - новая точка
+func simplexTriangle(a, b, c supportPoint) ([]supportPoint, mgl32.Vec3, bool) {
+	ab := b.v.Sub(a.v)
+	ac := c.v.Sub(a.v)
+	ao := a.v.Mul(-1)
+	abc := ab.Cross(ac)
+
+	if abc.Cross(ac).Dot(ao) > 0 {
+		if ac.Dot(ao) > 0 {
+			return []supportPoint{c, a}, ac.Cross(ao).Cross(ac), false
+		}
+		return simplexLine(a, b)
+	}
+
+	if ab.Cross(abc).Dot(ao) > 0 {
+		return simplexLine(a, b)
+	}
+
+	if abc.Dot(ao) > 0 {
+		return []supportPoint{c, b, a}, abc, false
+	}
+	// Начало координат под треугольником - меняем порядок обхода
+	return []supportPoint{b, c, a}, abc.Mul(-1), false
+}
+
+// simplexTetrahedron обрабатывает тетраэдр [d, c, b, a], a - новая точка
+func simplexTetrahedron(a, b, c, d supportPoint) ([]supportPoint, mgl32.Vec3, bool) {
+	ab := b.v.Sub(a.v)
+	ac := c.v.Sub(a.v)
+	ad := d.v.Sub(a.v)
+	ao := a.v.Mul(-1)
+
+	if ab.Cross(ac).Dot(ao) > 0 {
+		return simplexTriangle(a, b, c)
+	}
+	if ac.Cross(ad).Dot(ao) > 0 {
+		return simplexTriangle(a, c, d)
+	}
+	if ad.Cross(ab).Dot(ao) > 0 {
+		return simplexTriangle(a, d, b)
+	}
+	return []supportPoint{d, c, b, a}, mgl32.Vec3{}, true
+}
+
+// epaFace грань политопа EPA
+type epaFace struct {
+	a, b, c  int
+	normal   mgl32.Vec3
+	distance float32
+}
+
+// epaEdge ребро горизонта EPA
+type epaEdge struct {
+	a, b int
+}
+
+// epaPenetration вычисляет нормаль (от A к B), глубину проникновения и точку контакта
+// по симплексу GJK, содержащему начало координат
+func epaPenetration(a, b *collider, simplex []supportPoint) (mgl32.Vec3, float32, mgl32.Vec3, bool) {
+	points := expandSimplex(a, b, simplex)
+	if points == nil {
+		return mgl32.Vec3{}, 0, mgl32.Vec3{}, false
+	}
+
+	// Центр начального тетраэдра лежит внутри политопа на всех итерациях
+	inside := points[0].v.Add(points[1].v).Add(points[2].v).Add(points[3].v).Mul(0.25)
+
+	faces := make([]epaFace, 0, 32)
+	addFace := func(i, j, k int) {
+		normal := points[j].v.Sub(points[i].v).Cross(points[k].v.Sub(points[i].v))
+		length := normal.Len()
+		if length < 1e-10 {
+			return
+		}
+		normal = normal.Mul(1 / length)
+		if normal.Dot(points[i].v.Sub(inside)) < 0 {
+			normal = normal.Mul(-1)
+			j, k = k, j
+		}
+		faces = append(faces, epaFace{a: i, b: j, c: k, normal: normal, distance: normal.Dot(points[i].v)})
+	}
+
+	addFace(0, 1, 2)
+	addFace(0, 3, 1)
+	addFace(0, 2, 3)
+	addFace(1, 3, 2)
+
+	var closest epaFace
+	for iteration := 0; iteration < epaMaxIterations && len(faces) > 0; iteration++ {
+		best := 0
+		for i := range faces {
+			if faces[i].distance < faces[best].distance {
+				best = i
+			}
+		}
+		closest = faces[best]
+
+		point := minkowskiSupport(a, b, closest.normal)
+		if point.v.Dot(closest.normal)-closest.distance < epaTolerance {
+			break
+		}
+
+		// Удаляем грани, видимые из новой точки, и собираем горизонт
+		points = append(points, point)
+		newIndex := len(points) - 1
+		var horizon []epaEdge
+		kept := faces[:0]
+		for _, face := range faces {
+			if face.normal.Dot(point.v.Sub(points[face.a].v)) > 0 {
+				horizon = toggleEdge(horizon, epaEdge{face.a, face.b})
+				horizon = toggleEdge(horizon, epaEdge{face.b, face.c})
+				horizon = toggleEdge(horizon, epaEdge{face.c, face.a})
+			} else {
+				kept = append(kept, face)
+			}
+		}
+		faces = kept
+
+		for _, edge := range horizon {
+			addFace(edge.a, edge.b, newIndex)
+		}
+	}
+
+	// Точка контакта - проекция начала координат на ближайшую грань
+	projection := closest.normal.Mul(closest.distance)
+	u, v, w := barycentric(projection, points[closest.a].v, points[closest.b].v, points[closest.c].v)
+	pointA := points[closest.a].a.Mul(u).Add(points[closest.b].a.Mul(v)).Add(points[closest.c].a.Mul(w))
+	pointB := points[closest.a].b.Mul(u).Add(points[closest.b].b.Mul(v)).Add(points[closest.c].b.Mul(w))
+
+	return closest.normal, closest.distance, pointA.Add(pointB).Mul(0.5), true
+}
+
+// toggleEdge добавляет ребро горизонта или удаляет его, если встречено обратное
+func toggleEdge(edges []epaEdge, edge epaEdge) []epaEdge {
+	for i, e := range edges {
+		if e.a == edge.b && e.b == edge.a {
+			return append(edges[:i], edges[i+1:]...)
+		}
+	}
+	return append(edges, edge)
+}
+
+// expandSimplex достраивает вырожденный симплекс GJK до тетраэдра
+func expandSimplex(a, b *collider, simplex []supportPoint) []supportPoint {
+	points := make([]supportPoint, len(simplex), 16)
+	copy(points, simplex)
+
+	axes := [6]mgl32.Vec3{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+
+	if len(points) == 1 {
+		for _, axis := range axes {
+			point := minkowskiSupport(a, b, axis)
+			if point.v.Sub(points[0].v).Len() > 1e-6 {
+				points = append(points, point)
+				break
+			}
+		}
+	}
+
+	if len(points) == 2 {
+		line := points[1].v.Sub(points[0].v)
+		// Перпендикуляр к отрезку через ось, наименее с ним совпадающую
+		axis := mgl32.Vec3{1, 0, 0}
+		if abs32(line.X()) > abs32(line.Y()) {
+			axis = mgl32.Vec3{0, 1, 0}
+		}
+		dir := line.Cross(axis)
+		for i := 0; i < 6 && len(points) == 2; i++ {
+			point := minkowskiSupport(a, b, dir)
+			if point.v.Sub(points[0].v).Cross(line).Len() > 1e-6 {
+				points = append(points, point)
+			}
+			dir = mgl32.QuatRotate(mgl32.DegToRad(60), line.Normalize()).Rotate(dir)
+		}
+	}
+
+	if len(points) == 3 {
+		normal := points[1].v.Sub(points[0].v).Cross(points[2].v.Sub(points[0].v))
+		point := minkowskiSupport(a, b, normal)
+		if abs32(point.v.Sub(points[0].v).Dot(normal)) < 1e-8 {
+			point = minkowskiSupport(a, b, normal.Mul(-1))
+		}
+		points = append(points, point)
+	}
+
+	if len(points) < 4 {
+		return nil
+	}
+
+	// Вырожденный тетраэдр - тела только касаются
+	volume := points[1].v.Sub(points[0].v).Cross(points[2].v.Sub(points[0].v)).Dot(points[3].v.Sub(points[0].v))
+	if abs32(volume) < 1e-9 {
+		return nil
+	}
+	return points
+}
+
+// barycentric возвращает барицентрические координаты точки p в треугольнике abc
+func barycentric(p, a, b, c mgl32.Vec3) (float32, float32, float32) {
+	v0 := b.Sub(a)
+	v1 := c.Sub(a)
+	v2 := p.Sub(a)
+	d00 := v0.Dot(v0)
+	d01 := v0.Dot(v1)
+	d11 := v1.Dot(v1)
+	d20 := v2.Dot(v0)
+	d21 := v2.Dot(v1)
+
+	denom := d00*d11 - d01*d01
+	if abs32(denom) < 1e-12 {
+		return 1, 0, 0
+	}
+	v := (d11*d20 - d01*d21) / denom
+	w := (d00*d21 - d01*d20) / denom
+	return 1 - v - w, v, w
+}
@@ -0,0 +1,212 @@
+package physics
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// ErrHeightfieldSize размеры сетки не совпадают с количеством высот
+var ErrHeightfieldSize = errors.New("heightfield needs at least 2x2 samples matching rows*cols")
+
+// Heightfield карта высот ландшафта на регулярной сетке в плоскости XZ.
+// Узел (row, col) лежит в локальной точке (col*CellSize, высота, row*CellSize),
+// каждая ячейка делится на два треугольника
+type Heightfield struct {
+	rows, cols int
+	cellSize   float32
+	heights    []float32
+	minHeight  float32
+	maxHeight  float32
+}
+
+// NewHeightfield создает карту высот из rows*cols значений, записанных по строкам
+func NewHeightfield(rows, cols int, cellSize float32, heights []float32) (*Heightfield, error) {
+	if rows < 2 || cols < 2 || len(heights) != rows*cols || cellSize <= 0 {
+		return nil, ErrHeightfieldSize
+	}
+
+	h := &Heightfield{
+		rows:     rows,
+		cols:     cols,
+		cellSize: cellSize,
+		heights:  append([]float32(nil), heights...),
+	}
+	h.updateBounds()
+	return h, nil
+}
+
+// updateBounds пересчитывает диапазон высот
+func (h *Heightfield) updateBounds() {
+	h.minHeight, h.maxHeight = h.heights[0], h.heights[0]
+	for _, height := range h.heights {
+		h.minHeight = min(h.minHeight, height)
+		h.maxHeight = max(h.maxHeight, height)
+	}
+}
+
+// GetRows возвращает количество строк узлов
+func (h *Heightfield) GetRows() int {
+	return h.rows
+}
+
+// GetCols возвращает количество столбцов узлов
+func (h *Heightfield) GetCols() int {
+	return h.cols
+}
+
+// GetCellSize возвращает размер ячейки
+func (h *Heightfield) GetCellSize() float32 {
+	return h.cellSize
+}
+
+// GetHeight возвращает высоту узла
+func (h *Heightfield) GetHeight(row, col int) float32 {
+	return h.heights[row*h.cols+col]
+}
+
+// SetHeight меняет высоту узла (например, для деформации ландшафта)
+func (h *Heightfield) SetHeight(row, col int, height float32) {
+	h.heights[row*h.cols+col] = height
+	h.updateBounds()
+}
+
+// GetHeightAt возвращает высоту поверхности в локальной точке (x, z).
+// false - точка за пределами карты
+func (h *Heightfield) GetHeightAt(x, z float32) (float32, bool) {
+	fx, fz := x/h.cellSize, z/h.cellSize
+	if fx < 0 || fz < 0 || fx > float32(h.cols-1) || fz > float32(h.rows-1) {
+		return 0, false
+	}
+
+	col := min(int(fx), h.cols-2)
+	row := min(int(fz), h.rows-2)
+	u, v := fx-float32(col), fz-float32(row)
+
+	h00 := h.GetHeight(row, col)
+	h01 := h.GetHeight(row, col+1)
+	h10 := h.GetHeight(row+1, col)
+	h11 := h.GetHeight(row+1, col+1)
+
+	// Диагональ ячейки идет из (row, col) в (row+1, col+1)
+	if v >= u {
+		return h00 + (h11-h10)*u + (h10-h00)*v, true
+	}
+	return h00 + (h01-h00)*u + (h11-h01)*v, true
+}
+
+// bounds возвращает ограничивающий объем в локальных координатах
+func (h *Heightfield) bounds() customMath.AABB {
+	return customMath.NewAABB(
+		mgl32.Vec3{0, h.minHeight, 0},
+		mgl32.Vec3{float32(h.cols-1) * h.cellSize, h.maxHeight, float32(h.rows-1) * h.cellSize},
+	)
+}
+
+// point возвращает узел сетки в локальных координатах
+func (h *Heightfield) point(row, col int) mgl32.Vec3 {
+	return mgl32.Vec3{float32(col) * h.cellSize, h.GetHeight(row, col), float32(row) * h.cellSize}
+}
+
+// triangle возвращает треугольник ячейки: k = 0 или 1. Нормали смотрят вверх
+func (h *Heightfield) triangle(row, col, k int) (mgl32.Vec3, mgl32.Vec3, mgl32.Vec3) {
+	if k == 0 {
+		return h.point(row, col), h.point(row+1, col), h.point(row+1, col+1)
+	}
+	return h.point(row, col), h.point(row+1, col+1), h.point(row, col+1)
+}
+
+// triangleID номер треугольника ячейки
+func (h *Heightfield) triangleID(row, col, k int) int32 {
+	return int32((row*(h.cols-1)+col)*2 + k)
+}
+
+// query вызывает callback для треугольников ячеек, которые накрывает bounds
+func (h *Heightfield) query(bounds customMath.AABB, callback func(row, col, k int)) {
+	if bounds.Max.Y() < h.minHeight || bounds.Min.Y() > h.maxHeight {
+		return
+	}
+
+	colMin := max(int(math.Floor(float64(bounds.Min.X()/h.cellSize))), 0)
+	colMax := min(int(math.Floor(float64(bounds.Max.X()/h.cellSize))), h.cols-2)
+	rowMin := max(int(math.Floor(float64(bounds.Min.Z()/h.cellSize))), 0)
+	rowMax := min(int(math.Floor(float64(bounds.Max.Z()/h.cellSize))), h.rows-2)
+
+	for row := rowMin; row <= rowMax; row++ {
+		for col := colMin; col <= colMax; col++ {
+			callback(row, col, 0)
+			callback(row, col, 1)
+		}
+	}
+}
+
+// raycast проходит ячейки вдоль проекции луча на XZ (алгоритм Amanatides-Woo)
+// и останавливается на первой ячейке с попаданием. Луч в локальных координатах,
+// callback возвращает расстояние до попадания в треугольник ячейки
+func (h *Heightfield) raycast(origin, direction mgl32.Vec3, maxDistance float32, callback func(row, col, k int) (float32, bool)) (float32, bool) {
+	bounds := h.bounds()
+	enter, exit, ok := rayAABB(origin, direction, &bounds, maxDistance)
+	if !ok {
+		return 0, false
+	}
+
+	start := origin.Add(direction.Mul(enter))
+	col := clampInt(int(start.X()/h.cellSize), 0, h.cols-2)
+	row := clampInt(int(start.Z()/h.cellSize), 0, h.rows-2)
+
+	// Параметры луча до следующих границ ячеек по X и Z
+	next := func(cell int, o, d float32) (int, float32, float32) {
+		if abs32(d) < 1e-9 {
+			return 0, float32(math.Inf(1)), float32(math.Inf(1))
+		}
+		if d > 0 {
+			return 1, (float32(cell+1)*h.cellSize - o) / d, h.cellSize / d
+		}
+		return -1, (float32(cell)*h.cellSize - o) / d, -h.cellSize / d
+	}
+	stepX, nextX, deltaX := next(col, origin.X(), direction.X())
+	stepZ, nextZ, deltaZ := next(row, origin.Z(), direction.Z())
+
+	for {
+		best, found := float32(0), false
+		for k := 0; k < 2; k++ {
+			if t, hit := callback(row, col, k); hit && (!found || t < best) {
+				best, found = t, true
+			}
+		}
+		if found {
+			return best, true
+		}
+
+		if nextX < nextZ {
+			if nextX > exit {
+				return 0, false
+			}
+			col += stepX
+			nextX += deltaX
+		} else {
+			if nextZ > exit {
+				return 0, false
+			}
+			row += stepZ
+			nextZ += deltaZ
+		}
+		if col < 0 || col > h.cols-2 || row < 0 || row > h.rows-2 {
+			return 0, false
+		}
+	}
+}
+
+// clampInt ограничивает целое отрезком
+func clampInt(x, lo, hi int) int {
+	if x < lo {
+		return lo
+	}
+	if x > hi {
+		return hi
+	}
+	return x
+}
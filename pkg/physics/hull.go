@@ -0,0 +1,538 @@
+package physics
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ErrDegenerateHull точки не образуют объемную оболочку
+var ErrDegenerateHull = errors.New("convex hull points are coplanar or too few")
+
+// ConvexHull выпуклая оболочка облака точек в локальных координатах тела.
+// Строится один раз и может использоваться несколькими телами
+type ConvexHull struct {
+	vertices []mgl32.Vec3
+	faces    []hullFace
+	edges    [][2]int
+	min, max mgl32.Vec3 // Локальный ограничивающий объем
+}
+
+// hullFace грань оболочки: вершины против часовой стрелки, если смотреть снаружи
+type hullFace struct {
+	normal  mgl32.Vec3
+	indices []int
+}
+
+// hullTriangle треугольник оболочки во время построения
+type hullTriangle struct {
+	a, b, c int
+	normal  mgl32.Vec3
+	offset  float32
+}
+
+// NewConvexHull строит выпуклую оболочку точек (например, вершин меша модели)
+// инкрементальным алгоритмом. Копланарные треугольники объединяются в многоугольные грани
+func NewConvexHull(points []mgl32.Vec3) (*ConvexHull, error) {
+	if len(points) < 4 {
+		return nil, ErrDegenerateHull
+	}
+
+	minPoint, maxPoint := points[0], points[0]
+	for _, p := range points {
+		for i := 0; i < 3; i++ {
+			minPoint[i] = min(minPoint[i], p[i])
+			maxPoint[i] = max(maxPoint[i], p[i])
+		}
+	}
+	size := maxPoint.Sub(minPoint).Len()
+	eps := size * 1e-5
+	if size < 1e-6 {
+		return nil, ErrDegenerateHull
+	}
+
+	initial, ok := initialTetrahedron(points, eps)
+	if !ok {
+		return nil, ErrDegenerateHull
+	}
+
+	makeTriangle := func(a, b, c int) hullTriangle {
+		normal := safeNormalize(points[b].Sub(points[a]).Cross(points[c].Sub(points[a])))
+		return hullTriangle{a: a, b: b, c: c, normal: normal, offset: normal.Dot(points[a])}
+	}
+
+	// Грани начального тетраэдра смотрят от его центра
+	centroid := points[initial[0]].Add(points[initial[1]]).Add(points[initial[2]]).Add(points[initial[3]]).Mul(0.25)
+	var triangles []hullTriangle
+	for _, face := range [4][3]int{{0, 1, 2}, {0, 1, 3}, {0, 2, 3}, {1, 2, 3}} {
+		a, b, c := initial[face[0]], initial[face[1]], initial[face[2]]
+		t := makeTriangle(a, b, c)
+		if t.normal.Dot(centroid)-t.offset > 0 {
+			t = makeTriangle(a, c, b)
+		}
+		triangles = append(triangles, t)
+	}
+
+	// Добавляем точки по одной: видимые из точки грани заменяются веером
+	// треугольников от точки к ребрам горизонта
+	visible := make(map[[2]int]bool)
+	for i, p := range points {
+		if i == initial[0] || i == initial[1] || i == initial[2] || i == initial[3] {
+			continue
+		}
+
+		clear(visible)
+		kept := triangles[:0:0]
+		var lit []hullTriangle
+		for _, t := range triangles {
+			if t.normal.Dot(p)-t.offset > eps {
+				visible[[2]int{t.a, t.b}] = true
+				visible[[2]int{t.b, t.c}] = true
+				visible[[2]int{t.c, t.a}] = true
+				lit = append(lit, t)
+			} else {
+				kept = append(kept, t)
+			}
+		}
+		if len(lit) == 0 {
+			continue
+		}
+
+		// Ребра горизонта обходятся в порядке треугольников, а не по карте:
+		// так оболочка одинакова при каждом построении
+		for _, t := range lit {
+			for _, edge := range [3][2]int{{t.a, t.b}, {t.b, t.c}, {t.c, t.a}} {
+				if !visible[[2]int{edge[1], edge[0]}] {
+					kept = append(kept, makeTriangle(edge[0], edge[1], i))
+				}
+			}
+		}
+		triangles = kept
+	}
+
+	return buildHull(points, triangles, eps), nil
+}
+
+// initialTetrahedron выбирает четыре точки, образующие тетраэдр с ненулевым объемом
+func initialTetrahedron(points []mgl32.Vec3, eps float32) ([4]int, bool) {
+	var result [4]int
+
+	for i, p := range points {
+		if p.X() < points[result[0]].X() {
+			result[0] = i
+		}
+	}
+
+	farthest := func(distance func(p mgl32.Vec3) float32) (int, float32) {
+		best, bestDist := 0, float32(-1)
+		for i, p := range points {
+			if d := distance(p); d > bestDist {
+				best, bestDist = i, d
+			}
+		}
+		return best, bestDist
+	}
+
+	p0 := points[result[0]]
+	var d float32
+	result[1], d = farthest(func(p mgl32.Vec3) float32 { return p.Sub(p0).Len() })
+	if d <= eps {
+		return result, false
+	}
+
+	p1 := points[result[1]]
+	result[2], d = farthest(func(p mgl32.Vec3) float32 {
+		return p.Sub(closestPointOnSegment(p, p0, p1)).Len()
+	})
+	if d <= eps {
+		return result, false
+	}
+
+	normal := safeNormalize(p1.Sub(p0).Cross(points[result[2]].Sub(p0)))
+	result[3], d = farthest(func(p mgl32.Vec3) float32 { return abs32(normal.Dot(p.Sub(p0))) })
+	return result, d > eps
+}
+
+// buildHull объединяет копланарные треугольники в грани и оставляет только
+// вершины, входящие в оболочку
+func buildHull(points []mgl32.Vec3, triangles []hullTriangle, eps float32) *ConvexHull {
+	type faceGroup struct {
+		normal   mgl32.Vec3
+		offset   float32
+		vertices []int
+	}
+
+	var groups []*faceGroup
+	for _, t := range triangles {
+		// Вырожденные треугольники не дают надежной нормали
+		area := points[t.b].Sub(points[t.a]).Cross(points[t.c].Sub(points[t.a])).Len()
+		if area < eps*eps {
+			continue
+		}
+
+		var group *faceGroup
+		for _, g := range groups {
+			if g.normal.Dot(t.normal) > 0.9995 && abs32(g.offset-t.offset) < eps*10 {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &faceGroup{normal: t.normal, offset: t.offset}
+			groups = append(groups, group)
+		}
+		group.vertices = append(group.vertices, t.a, t.b, t.c)
+	}
+
+	hull := &ConvexHull{}
+	remap := make(map[int]int)
+	edges := make(map[[2]int]bool)
+
+	for _, g := range groups {
+		// Уникальные вершины грани по углу вокруг нормали
+		unique := g.vertices[:0]
+		seen := make(map[int]bool)
+		var center mgl32.Vec3
+		for _, index := range g.vertices {
+			if !seen[index] {
+				seen[index] = true
+				unique = append(unique, index)
+				center = center.Add(points[index])
+			}
+		}
+		center = center.Mul(1 / float32(len(unique)))
+
+		t1, t2 := tangentBasis(g.normal)
+		angle := func(index int) float64 {
+			d := points[index].Sub(center)
+			return math.Atan2(float64(d.Dot(t2)), float64(d.Dot(t1)))
+		}
+		sort.Slice(unique, func(i, j int) bool {
+			return angle(unique[i]) < angle(unique[j])
+		})
+
+		face := hullFace{normal: g.normal}
+		for _, index := range unique {
+			mapped, ok := remap[index]
+			if !ok {
+				mapped = len(hull.vertices)
+				remap[index] = mapped
+				hull.vertices = append(hull.vertices, points[index])
+			}
+			face.indices = append(face.indices, mapped)
+		}
+		hull.faces = append(hull.faces, face)
+
+		for i, a := range face.indices {
+			b := face.indices[(i+1)%len(face.indices)]
+			if a > b {
+				a, b = b, a
+			}
+			if !edges[[2]int{a, b}] {
+				edges[[2]int{a, b}] = true
+				hull.edges = append(hull.edges, [2]int{a, b})
+			}
+		}
+	}
+
+	hull.min, hull.max = hull.vertices[0], hull.vertices[0]
+	for _, v := range hull.vertices {
+		for i := 0; i < 3; i++ {
+			hull.min[i] = min(hull.min[i], v[i])
+			hull.max[i] = max(hull.max[i], v[i])
+		}
+	}
+	return hull
+}
+
+// GetVertices возвращает вершины оболочки в локальных координатах
+func (h *ConvexHull) GetVertices() []mgl32.Vec3 {
+	return h.vertices
+}
+
+// GetFaceCount возвращает количество граней оболочки
+func (h *ConvexHull) GetFaceCount() int {
+	return len(h.faces)
+}
+
+// polyhedron выпуклый многогранник в мировых координатах для SAT и отсечения граней.
+// Оболочки, параллелепипеды и треугольники сеток сводятся к нему
+type polyhedron struct {
+	vertices []mgl32.Vec3
+	normals  []mgl32.Vec3 // Нормали граней
+	faces    [][]int      // Вершины граней против часовой стрелки снаружи
+	edges    [][2]int
+}
+
+// boxFaces и boxEdges - топология параллелепипеда в порядке вершин collider.vertices
+var (
+	boxFaces = [][]int{{1, 3, 7, 5}, {0, 4, 6, 2}, {2, 6, 7, 3}, {0, 1, 5, 4}, {4, 5, 7, 6}, {0, 2, 3, 1}}
+	boxEdges = [][2]int{
+		{0, 1}, {2, 3}, {4, 5}, {6, 7},
+		{0, 2}, {1, 3}, {4, 6}, {5, 7},
+		{0, 4}, {1, 5}, {2, 6}, {3, 7},
+	}
+	triangleFaces = [][]int{{0, 1, 2}, {0, 2, 1}}
+	triangleEdges = [][2]int{{0, 1}, {1, 2}, {2, 0}}
+)
+
+// newHullPolyhedron переводит оболочку в мир с учетом масштаба тела
+func newHullPolyhedron(hull *ConvexHull, center mgl32.Vec3, rotation mgl32.Mat3, scale mgl32.Vec3) *polyhedron {
+	poly := &polyhedron{
+		vertices: make([]mgl32.Vec3, len(hull.vertices)),
+		normals:  make([]mgl32.Vec3, len(hull.faces)),
+		faces:    make([][]int, len(hull.faces)),
+		edges:    hull.edges,
+	}
+	for i, v := range hull.vertices {
+		poly.vertices[i] = center.Add(rotation.Mul3x1(mulElem(v, scale)))
+	}
+	for i, face := range hull.faces {
+		// Нормали при неравномерном масштабе преобразуются обратной матрицей
+		n := mgl32.Vec3{face.normal[0] / scale[0], face.normal[1] / scale[1], face.normal[2] / scale[2]}
+		poly.normals[i] = safeNormalize(rotation.Mul3x1(n))
+		poly.faces[i] = face.indices
+	}
+	return poly
+}
+
+// boxPolyhedron представляет параллелепипед многогранником
+func boxPolyhedron(box *collider) *polyhedron {
+	vertices := box.vertices()
+	normals := make([]mgl32.Vec3, 6)
+	for i := 0; i < 3; i++ {
+		normals[2*i] = box.axis(i)
+		normals[2*i+1] = box.axis(i).Mul(-1)
+	}
+	return &polyhedron{vertices: vertices[:], normals: normals, faces: boxFaces, edges: boxEdges}
+}
+
+// trianglePolyhedron представляет треугольник двусторонним многогранником
+func trianglePolyhedron(a, b, c mgl32.Vec3) *polyhedron {
+	normal := safeNormalize(b.Sub(a).Cross(c.Sub(a)))
+	return &polyhedron{
+		vertices: []mgl32.Vec3{a, b, c},
+		normals:  []mgl32.Vec3{normal, normal.Mul(-1)},
+		faces:    triangleFaces,
+		edges:    triangleEdges,
+	}
+}
+
+// project возвращает отрезок проекции вершин на ось
+func (p *polyhedron) project(axis mgl32.Vec3) (float32, float32) {
+	lo := axis.Dot(p.vertices[0])
+	hi := lo
+	for _, v := range p.vertices[1:] {
+		d := axis.Dot(v)
+		lo = min(lo, d)
+		hi = max(hi, d)
+	}
+	return lo, hi
+}
+
+// support возвращает вершину, самую дальнюю в направлении dir
+func (p *polyhedron) support(dir mgl32.Vec3) mgl32.Vec3 {
+	best := p.vertices[0]
+	bestDot := dir.Dot(best)
+	for _, v := range p.vertices[1:] {
+		if d := dir.Dot(v); d > bestDot {
+			best, bestDot = v, d
+		}
+	}
+	return best
+}
+
+// supportEdge возвращает ребро, параллельное direction и самое дальнее в направлении dir
+func (p *polyhedron) supportEdge(direction, dir mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	var best [2]int
+	bestDot := float32(-1e30)
+	for _, e := range p.edges {
+		v0, v1 := p.vertices[e[0]], p.vertices[e[1]]
+		edge := v1.Sub(v0)
+		if edge.Cross(direction).Len() > 1e-3*edge.Len()*direction.Len() {
+			continue
+		}
+		if d := dir.Dot(v0.Add(v1)); d > bestDot {
+			best, bestDot = e, d
+		}
+	}
+	return p.vertices[best[0]], p.vertices[best[1]]
+}
+
+// collidePolyhedra тест двух выпуклых многогранников теоремой о разделяющих осях:
+// нормали граней обоих и векторные произведения ребер. Контакт грань-грань
+// строится отсечением, как у параллелепипедов; нормаль от a к b
+func collidePolyhedra(a, b *polyhedron, manifold *ContactManifold) bool {
+	// Оси граней: разделение вдоль внешней нормали грани владельца
+	faceSep := float32(-1e30)
+	var faceOwner, faceOther *polyhedron
+	faceIndex := -1
+	faceFlip := false
+
+	testFaces := func(owner, other *polyhedron, flip bool) bool {
+		for i, normal := range owner.normals {
+			lo, _ := other.project(normal)
+			separation := lo - normal.Dot(owner.vertices[owner.faces[i][0]])
+			if separation > 0 {
+				return false
+			}
+			if separation > faceSep {
+				faceSep, faceOwner, faceOther, faceIndex, faceFlip = separation, owner, other, i, flip
+			}
+		}
+		return true
+	}
+	if !testFaces(a, b, false) || !testFaces(b, a, true) {
+		return false
+	}
+
+	// Оси ребер: ориентация выбирается по стороне, с которой разделение больше
+	edgeSep := float32(-1e30)
+	var edgeAxis, edgeDirA, edgeDirB mgl32.Vec3
+	for _, ea := range a.edges {
+		dirA := a.vertices[ea[1]].Sub(a.vertices[ea[0]])
+		for _, eb := range b.edges {
+			dirB := b.vertices[eb[1]].Sub(b.vertices[eb[0]])
+			axis := dirA.Cross(dirB)
+			length := axis.Len()
+			if length < 1e-6*dirA.Len()*dirB.Len() {
+				continue
+			}
+			axis = axis.Mul(1 / length)
+
+			loA, hiA := a.project(axis)
+			loB, hiB := b.project(axis)
+			separation := loB - hiA
+			if s := loA - hiB; s > separation {
+				separation = s
+				axis = axis.Mul(-1)
+			}
+			if separation > 0 {
+				return false
+			}
+			if separation > edgeSep {
+				edgeSep, edgeAxis, edgeDirA, edgeDirB = separation, axis, dirA, dirB
+			}
+		}
+	}
+
+	// Оси ребер выбираем, только если они заметно лучше осей граней
+	if edgeSep > -1e29 && -edgeSep*1.05+0.001 < -faceSep {
+		a0, a1 := a.supportEdge(edgeDirA, edgeAxis)
+		b0, b1 := b.supportEdge(edgeDirB, edgeAxis.Mul(-1))
+		pa, pb := closestPointsSegments(a0, a1, b0, b1)
+		manifold.Normal = edgeAxis
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: pa.Add(pb).Mul(0.5),
+			Depth:    -edgeSep,
+		})
+		return true
+	}
+
+	normal := faceOwner.normals[faceIndex]
+	clipPolyhedronFaces(faceOwner, faceOther, faceIndex, manifold)
+	if len(manifold.Points) == 0 {
+		// Отсечение не дало точек (численные погрешности) - самая глубокая вершина
+		deepest := faceOther.support(normal.Mul(-1))
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: deepest.Add(normal.Mul(-faceSep / 2)),
+			Depth:    -faceSep,
+		})
+	}
+
+	manifold.Normal = normal
+	if faceFlip {
+		manifold.Normal = normal.Mul(-1)
+	}
+	return true
+}
+
+// clipPolyhedronFaces отсекает самую встречную грань incident боковыми
+// плоскостями опорной грани reference и оставляет точки под ней
+func clipPolyhedronFaces(reference, incident *polyhedron, face int, manifold *ContactManifold) {
+	normal := reference.normals[face]
+	refFace := reference.faces[face]
+
+	incFace := 0
+	best := float32(1e30)
+	for i, n := range incident.normals {
+		if d := n.Dot(normal); d < best {
+			incFace, best = i, d
+		}
+	}
+
+	polygon := make([]mgl32.Vec3, 0, len(incident.faces[incFace])+4)
+	for _, index := range incident.faces[incFace] {
+		polygon = append(polygon, incident.vertices[index])
+	}
+
+	for i, index := range refFace {
+		v0 := reference.vertices[index]
+		v1 := reference.vertices[refFace[(i+1)%len(refFace)]]
+		side := v1.Sub(v0).Cross(normal)
+		length := side.Len()
+		if length < 1e-9 {
+			continue
+		}
+		side = side.Mul(1 / length)
+		polygon = clipPolygon(polygon, side, side.Dot(v0))
+	}
+
+	offset := normal.Dot(reference.vertices[refFace[0]])
+	for _, point := range polygon {
+		separation := normal.Dot(point) - offset
+		if separation > contactMargin {
+			continue
+		}
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: point.Sub(normal.Mul(separation / 2)),
+			Depth:    -separation,
+		})
+	}
+	dedupePoints(manifold)
+}
+
+// rayPolyhedron пересекает луч с выпуклым многогранником отсечением плоскостями граней
+func rayPolyhedron(p *polyhedron, origin, direction mgl32.Vec3, maxDistance float32) (mgl32.Vec3, float32, bool) {
+	enter, exit := float32(0), maxDistance
+	var normal mgl32.Vec3
+	inside := true
+
+	for i, n := range p.normals {
+		distance := n.Dot(origin) - n.Dot(p.vertices[p.faces[i][0]])
+		if distance > 0 {
+			inside = false
+		}
+		speed := n.Dot(direction)
+		if abs32(speed) < 1e-9 {
+			if distance > 0 {
+				return mgl32.Vec3{}, 0, false
+			}
+			continue
+		}
+
+		t := -distance / speed
+		if speed < 0 {
+			if t > enter {
+				enter, normal = t, n
+			}
+		} else if t < exit {
+			exit = t
+		}
+		if enter > exit {
+			return mgl32.Vec3{}, 0, false
+		}
+	}
+
+	if inside {
+		return mgl32.Vec3{}, 0, false
+	}
+	return normal, enter, true
+}
+
+// mulElem покомпонентное произведение векторов
+func mulElem(a, b mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{a[0] * b[0], a[1] * b[1], a[2] * b[2]}
+}
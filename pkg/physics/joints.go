@@ -0,0 +1,536 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// jointBaumgarte доля ошибки положения соединения, устраняемая за шаг
+const jointBaumgarte = 0.2
+
+// Joint соединение двух тел. Соединения решаются в том же цикле, что и контакты.
+// Вместо первого тела можно передать nil - тогда второе тело крепится к миру.
+// Углы и смещения отсчитываются для тела B относительно тела A
+type Joint interface {
+	// GetBodyA возвращает первое тело
+	GetBodyA() *RigidBody
+
+	// GetBodyB возвращает второе тело
+	GetBodyB() *RigidBody
+
+	// IsBroken сообщает, разрушено ли соединение превышением BreakForce
+	IsBroken() bool
+
+	// GetReactionForce возвращает силу, с которой соединение действовало на тело B на последнем шаге
+	GetReactionForce() mgl32.Vec3
+
+	base() *jointBase
+	buildRows(dt float32)
+}
+
+// Идентификаторы строк соединения для переноса импульсов между шагами
+const (
+	rowLinearX = iota
+	rowLinearY
+	rowLinearZ
+	rowAngularX
+	rowAngularY
+	rowAngularZ
+	rowLowerLimit
+	rowUpperLimit
+	rowMotor
+)
+
+// jointRow одномерное ограничение вида J·v = bias.
+// Линейная часть действует на B, на A - с обратным знаком
+type jointRow struct {
+	id       int
+	linear   mgl32.Vec3
+	angularA mgl32.Vec3
+	angularB mgl32.Vec3
+	bias     float32 // Целевая скорость (мотор)
+	position float32 // Ошибка положения C для коррекции псевдоскоростями
+	lower    float32 // Границы накопленного импульса
+	upper    float32
+	motor    bool // Строка мотора не участвует в расчете разрушения
+
+	mass    float32
+	impulse float32
+	pseudo  float32
+}
+
+// jointBase общие данные всех соединений
+type jointBase struct {
+	bodyA *RigidBody
+	bodyB *RigidBody
+
+	localAnchorA mgl32.Vec3
+	localAnchorB mgl32.Vec3
+
+	// BreakForce сила разрыва; 0 - соединение неразрушимо
+	BreakForce float32
+
+	// CollideConnected разрешает столкновения между соединенными телами
+	CollideConnected bool
+
+	broken   bool
+	reaction mgl32.Vec3
+
+	rows     []jointRow
+	previous []jointRow
+
+	invMassA, invMassB       float32
+	invInertiaA, invInertiaB mgl32.Mat3
+
+	// Опорные точки и плечи в мире на текущем шаге
+	anchorA, anchorB mgl32.Vec3
+	armA, armB       mgl32.Vec3
+}
+
+// newJointBase связывает тела и переводит мировые опорные точки в локальные
+func newJointBase(a, b *RigidBody, anchorA, anchorB mgl32.Vec3) jointBase {
+	if a == nil {
+		// Тело мира: статическое, в начале координат
+		a = NewRigidBody(Static, BoxShape)
+		a.ID = -1
+		a.Name = "world"
+	}
+	return jointBase{
+		bodyA:        a,
+		bodyB:        b,
+		localAnchorA: toLocal(a, anchorA),
+		localAnchorB: toLocal(b, anchorB),
+	}
+}
+
+// GetBodyA возвращает первое тело
+func (j *jointBase) GetBodyA() *RigidBody {
+	return j.bodyA
+}
+
+// GetBodyB возвращает второе тело
+func (j *jointBase) GetBodyB() *RigidBody {
+	return j.bodyB
+}
+
+// IsBroken сообщает, разрушено ли соединение
+func (j *jointBase) IsBroken() bool {
+	return j.broken
+}
+
+// GetReactionForce возвращает силу реакции соединения на последнем шаге
+func (j *jointBase) GetReactionForce() mgl32.Vec3 {
+	return j.reaction
+}
+
+// base возвращает общие данные соединения
+func (j *jointBase) base() *jointBase {
+	return j
+}
+
+// begin пересчитывает массы и опорные точки и готовит список строк
+func (j *jointBase) begin() {
+	j.previous, j.rows = j.rows, j.previous[:0]
+
+	a, b := j.bodyA, j.bodyB
+	j.invMassA, j.invMassB = a.inverseMass(), b.inverseMass()
+	j.invInertiaA, j.invInertiaB = a.inverseInertia(), b.inverseInertia()
+
+	j.armA = a.Rotation.Rotate(j.localAnchorA)
+	j.armB = b.Rotation.Rotate(j.localAnchorB)
+	j.anchorA = a.Position.Add(j.armA)
+	j.anchorB = b.Position.Add(j.armB)
+}
+
+// addLinear добавляет строку, удерживающую опорные точки вдоль direction.
+// armA - плечо точки приложения для тела A
+func (j *jointBase) addLinear(id int, direction, armA mgl32.Vec3, position, lower, upper float32) *jointRow {
+	j.rows = append(j.rows, jointRow{
+		id:       id,
+		linear:   direction,
+		angularA: armA.Cross(direction).Mul(-1),
+		angularB: j.armB.Cross(direction),
+		position: position,
+		lower:    lower,
+		upper:    upper,
+	})
+	return &j.rows[len(j.rows)-1]
+}
+
+// addAngular добавляет строку, ограничивающую относительное вращение вокруг axis
+func (j *jointBase) addAngular(id int, axis mgl32.Vec3, position, lower, upper float32) *jointRow {
+	j.rows = append(j.rows, jointRow{
+		id:       id,
+		angularA: axis.Mul(-1),
+		angularB: axis,
+		position: position,
+		lower:    lower,
+		upper:    upper,
+	})
+	return &j.rows[len(j.rows)-1]
+}
+
+// addPoint добавляет три строки, совмещающие опорные точки тел
+func (j *jointBase) addPoint() {
+	offset := j.anchorB.Sub(j.anchorA)
+	inf := float32(math.Inf(1))
+	for i, axis := range [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+		j.addLinear(rowLinearX+i, axis, j.armA, offset[i], -inf, inf)
+	}
+}
+
+// addOrientation добавляет три строки, сохраняющие относительный поворот rest
+func (j *jointBase) addOrientation(rest mgl32.Quat) {
+	// Ошибка - поворот B относительно целевой ориентации, как вектор оси-угла
+	target := j.bodyA.Rotation.Mul(rest)
+	diff := j.bodyB.Rotation.Mul(target.Conjugate()).Normalize()
+	if diff.W < 0 {
+		diff = diff.Scale(-1)
+	}
+	err := diff.V.Mul(2)
+
+	inf := float32(math.Inf(1))
+	for i, axis := range [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+		j.addAngular(rowAngularX+i, axis, err[i], -inf, inf)
+	}
+}
+
+// addLimit добавляет строку предела, если координата value вышла за [lower, upper].
+// Строку нужного вида (линейную или угловую) создает add
+func (j *jointBase) addLimit(value, lower, upper float32, add func(id int, position, lo, hi float32)) {
+	inf := float32(math.Inf(1))
+	switch {
+	case upper-lower < 1e-5:
+		add(rowLowerLimit, value-lower, -inf, inf)
+	case value <= lower:
+		add(rowLowerLimit, value-lower, 0, inf)
+	case value >= upper:
+		add(rowUpperLimit, value-upper, -inf, 0)
+	}
+}
+
+// finish вычисляет эффективные массы строк и переносит импульсы прошлого шага
+func (j *jointBase) finish(warmStarting bool) {
+	for i := range j.rows {
+		row := &j.rows[i]
+		k := (j.invMassA+j.invMassB)*row.linear.Dot(row.linear) +
+			j.invInertiaA.Mul3x1(row.angularA).Dot(row.angularA) +
+			j.invInertiaB.Mul3x1(row.angularB).Dot(row.angularB)
+		if k > 1e-12 {
+			row.mass = 1 / k
+		}
+
+		if !warmStarting {
+			continue
+		}
+		for _, old := range j.previous {
+			if old.id == row.id {
+				row.impulse = clamp32(old.impulse, row.lower, row.upper)
+				break
+			}
+		}
+	}
+}
+
+// apply применяет импульс строки к скоростям тел
+func (j *jointBase) apply(row *jointRow, impulse float32) {
+	a, b := j.bodyA, j.bodyB
+	a.Velocity = a.Velocity.Sub(row.linear.Mul(impulse * j.invMassA))
+	a.AngularVelocity = a.AngularVelocity.Add(j.invInertiaA.Mul3x1(row.angularA.Mul(impulse)))
+	b.Velocity = b.Velocity.Add(row.linear.Mul(impulse * j.invMassB))
+	b.AngularVelocity = b.AngularVelocity.Add(j.invInertiaB.Mul3x1(row.angularB.Mul(impulse)))
+}
+
+// warmStart применяет импульсы, накопленные на прошлом шаге
+func (j *jointBase) warmStart() {
+	for i := range j.rows {
+		j.apply(&j.rows[i], j.rows[i].impulse)
+	}
+}
+
+// solveVelocities выполняет одну итерацию по скоростям
+func (j *jointBase) solveVelocities() {
+	a, b := j.bodyA, j.bodyB
+	for i := range j.rows {
+		row := &j.rows[i]
+		cdot := row.linear.Dot(b.Velocity.Sub(a.Velocity)) +
+			row.angularA.Dot(a.AngularVelocity) +
+			row.angularB.Dot(b.AngularVelocity)
+
+		old := row.impulse
+		row.impulse = clamp32(old-row.mass*(cdot-row.bias), row.lower, row.upper)
+		j.apply(row, row.impulse-old)
+	}
+}
+
+// solvePositions выполняет одну итерацию коррекции положения псевдоскоростями
+func (j *jointBase) solvePositions(dt float32) {
+	a, b := j.bodyA, j.bodyB
+	for i := range j.rows {
+		row := &j.rows[i]
+		if row.motor {
+			continue
+		}
+
+		cdot := row.linear.Dot(b.pseudoVelocity.Sub(a.pseudoVelocity)) +
+			row.angularA.Dot(a.pseudoAngular) +
+			row.angularB.Dot(b.pseudoAngular)
+		target := -jointBaumgarte / dt * row.position
+
+		old := row.pseudo
+		row.pseudo = clamp32(old+row.mass*(target-cdot), row.lower, row.upper)
+		impulse := row.pseudo - old
+
+		a.pseudoVelocity = a.pseudoVelocity.Sub(row.linear.Mul(impulse * j.invMassA))
+		a.pseudoAngular = a.pseudoAngular.Add(j.invInertiaA.Mul3x1(row.angularA.Mul(impulse)))
+		b.pseudoVelocity = b.pseudoVelocity.Add(row.linear.Mul(impulse * j.invMassB))
+		b.pseudoAngular = b.pseudoAngular.Add(j.invInertiaB.Mul3x1(row.angularB.Mul(impulse)))
+	}
+}
+
+// end вычисляет силу реакции и разрушает соединение при превышении BreakForce
+func (j *jointBase) end(dt float32) {
+	var impulse mgl32.Vec3
+	for i := range j.rows {
+		if !j.rows[i].motor {
+			impulse = impulse.Add(j.rows[i].linear.Mul(j.rows[i].impulse))
+		}
+	}
+	j.reaction = impulse.Mul(1 / dt)
+
+	if j.BreakForce > 0 && j.reaction.Len() > j.BreakForce {
+		j.broken = true
+	}
+}
+
+// BallJoint шаровой шарнир: опорные точки тел совпадают, вращение свободно
+type BallJoint struct {
+	jointBase
+}
+
+// NewBallJoint создает шаровой шарнир в мировой точке anchor
+func NewBallJoint(a, b *RigidBody, anchor mgl32.Vec3) *BallJoint {
+	return &BallJoint{jointBase: newJointBase(a, b, anchor, anchor)}
+}
+
+// buildRows строит строки ограничений на текущем шаге
+func (j *BallJoint) buildRows(dt float32) {
+	j.begin()
+	j.addPoint()
+}
+
+// HingeJoint петля: опорные точки совпадают, вращение только вокруг оси.
+// Поддерживает пределы угла и мотор
+type HingeJoint struct {
+	jointBase
+
+	EnableLimit bool
+	LowerAngle  float32 // Радианы
+	UpperAngle  float32
+
+	EnableMotor    bool
+	MotorSpeed     float32 // Радиан в секунду
+	MaxMotorTorque float32
+
+	localAxisA mgl32.Vec3
+	localAxisB mgl32.Vec3
+	localRefA  mgl32.Vec3 // Перпендикуляр к оси для отсчета угла
+	localRefB  mgl32.Vec3
+
+	angle float32
+}
+
+// NewHingeJoint создает петлю в мировой точке anchor с мировой осью axis.
+// Текущее взаимное положение тел соответствует углу 0
+func NewHingeJoint(a, b *RigidBody, anchor, axis mgl32.Vec3) *HingeJoint {
+	j := &HingeJoint{jointBase: newJointBase(a, b, anchor, anchor)}
+	axis = safeNormalize(axis)
+	ref, _ := tangentBasis(axis)
+
+	j.localAxisA = toLocalDirection(j.bodyA, axis)
+	j.localAxisB = toLocalDirection(j.bodyB, axis)
+	j.localRefA = toLocalDirection(j.bodyA, ref)
+	j.localRefB = toLocalDirection(j.bodyB, ref)
+	return j
+}
+
+// GetAngle возвращает угол поворота B относительно A вокруг оси
+func (j *HingeJoint) GetAngle() float32 {
+	return j.angle
+}
+
+// buildRows строит строки ограничений на текущем шаге
+func (j *HingeJoint) buildRows(dt float32) {
+	j.begin()
+	j.addPoint()
+
+	axisA := j.bodyA.Rotation.Rotate(j.localAxisA)
+	axisB := j.bodyB.Rotation.Rotate(j.localAxisB)
+	p, q := tangentBasis(axisA)
+	err := axisA.Cross(axisB)
+	inf := float32(math.Inf(1))
+	j.addAngular(rowAngularX, p, err.Dot(p), -inf, inf)
+	j.addAngular(rowAngularY, q, err.Dot(q), -inf, inf)
+
+	refA := j.bodyA.Rotation.Rotate(j.localRefA)
+	refB := j.bodyB.Rotation.Rotate(j.localRefB)
+	j.angle = float32(math.Atan2(float64(axisA.Dot(refA.Cross(refB))), float64(refA.Dot(refB))))
+
+	if j.EnableLimit {
+		j.addLimit(j.angle, j.LowerAngle, j.UpperAngle, func(id int, position, lo, hi float32) {
+			j.addAngular(id, axisA, position, lo, hi)
+		})
+	}
+	if j.EnableMotor {
+		limit := j.MaxMotorTorque * dt
+		row := j.addAngular(rowMotor, axisA, 0, -limit, limit)
+		row.bias = j.MotorSpeed
+		row.motor = true
+	}
+}
+
+// SliderJoint ползун: вращение заблокировано, смещение только вдоль оси.
+// Поддерживает пределы смещения и мотор
+type SliderJoint struct {
+	jointBase
+
+	EnableLimit      bool
+	LowerTranslation float32
+	UpperTranslation float32
+
+	EnableMotor   bool
+	MotorSpeed    float32 // Метров в секунду
+	MaxMotorForce float32
+
+	localAxisA mgl32.Vec3
+	rest       mgl32.Quat
+
+	translation float32
+}
+
+// NewSliderJoint создает ползун с мировой осью axis через точку anchor.
+// Текущее взаимное положение тел соответствует смещению 0
+func NewSliderJoint(a, b *RigidBody, anchor, axis mgl32.Vec3) *SliderJoint {
+	j := &SliderJoint{jointBase: newJointBase(a, b, anchor, anchor)}
+	j.localAxisA = toLocalDirection(j.bodyA, safeNormalize(axis))
+	j.rest = j.bodyA.Rotation.Conjugate().Mul(j.bodyB.Rotation)
+	return j
+}
+
+// GetTranslation возвращает смещение B относительно A вдоль оси
+func (j *SliderJoint) GetTranslation() float32 {
+	return j.translation
+}
+
+// buildRows строит строки ограничений на текущем шаге
+func (j *SliderJoint) buildRows(dt float32) {
+	j.begin()
+
+	axis := j.bodyA.Rotation.Rotate(j.localAxisA)
+	offset := j.anchorB.Sub(j.anchorA)
+	// Плечо A тянется до опорной точки B, потому что ось вращается вместе с A
+	arm := j.armA.Add(offset)
+	p, q := tangentBasis(axis)
+	inf := float32(math.Inf(1))
+	j.addLinear(rowLinearX, p, arm, offset.Dot(p), -inf, inf)
+	j.addLinear(rowLinearY, q, arm, offset.Dot(q), -inf, inf)
+	j.addOrientation(j.rest)
+
+	j.translation = offset.Dot(axis)
+	if j.EnableLimit {
+		j.addLimit(j.translation, j.LowerTranslation, j.UpperTranslation, func(id int, position, lo, hi float32) {
+			j.addLinear(id, axis, arm, position, lo, hi)
+		})
+	}
+	if j.EnableMotor {
+		limit := j.MaxMotorForce * dt
+		row := j.addLinear(rowMotor, axis, arm, 0, -limit, limit)
+		row.bias = j.MotorSpeed
+		row.motor = true
+	}
+}
+
+// DistanceJoint удерживает расстояние между опорными точками в [MinLength, MaxLength].
+// При MinLength = 0 работает как веревка. Поддерживает мотор вдоль линии точек
+type DistanceJoint struct {
+	jointBase
+
+	MinLength float32
+	MaxLength float32
+
+	EnableMotor   bool
+	MotorSpeed    float32 // Скорость изменения длины, м/с
+	MaxMotorForce float32
+
+	length float32
+}
+
+// NewDistanceJoint создает жесткую связь между мировыми точками anchorA и anchorB
+// с длиной, равной текущему расстоянию между ними
+func NewDistanceJoint(a, b *RigidBody, anchorA, anchorB mgl32.Vec3) *DistanceJoint {
+	length := anchorB.Sub(anchorA).Len()
+	return &DistanceJoint{
+		jointBase: newJointBase(a, b, anchorA, anchorB),
+		MinLength: length,
+		MaxLength: length,
+	}
+}
+
+// GetLength возвращает текущее расстояние между опорными точками
+func (j *DistanceJoint) GetLength() float32 {
+	return j.length
+}
+
+// buildRows строит строки ограничений на текущем шаге
+func (j *DistanceJoint) buildRows(dt float32) {
+	j.begin()
+
+	offset := j.anchorB.Sub(j.anchorA)
+	j.length = offset.Len()
+	direction := mgl32.Vec3{0, 1, 0}
+	if j.length > 1e-6 {
+		direction = offset.Mul(1 / j.length)
+	}
+
+	j.addLimit(j.length, j.MinLength, j.MaxLength, func(id int, position, lo, hi float32) {
+		j.addLinear(id, direction, j.armA, position, lo, hi)
+	})
+	if j.EnableMotor {
+		limit := j.MaxMotorForce * dt
+		row := j.addLinear(rowMotor, direction, j.armA, 0, -limit, limit)
+		row.bias = j.MotorSpeed
+		row.motor = true
+	}
+}
+
+// FixedJoint жестко скрепляет тела в текущем взаимном положении
+type FixedJoint struct {
+	jointBase
+
+	rest mgl32.Quat
+}
+
+// NewFixedJoint скрепляет тела в точке центра тела B
+func NewFixedJoint(a, b *RigidBody) *FixedJoint {
+	j := &FixedJoint{jointBase: newJointBase(a, b, b.Position, b.Position)}
+	j.rest = j.bodyA.Rotation.Conjugate().Mul(j.bodyB.Rotation)
+	return j
+}
+
+// buildRows строит строки ограничений на текущем шаге
+func (j *FixedJoint) buildRows(dt float32) {
+	j.begin()
+	j.addPoint()
+	j.addOrientation(j.rest)
+}
+
+// toLocal переводит мировую точку в локальные координаты тела
+func toLocal(body *RigidBody, point mgl32.Vec3) mgl32.Vec3 {
+	return body.Rotation.Conjugate().Rotate(point.Sub(body.Position))
+}
+
+// toLocalDirection переводит мировое направление в локальные оси тела
+func toLocalDirection(body *RigidBody, direction mgl32.Vec3) mgl32.Vec3 {
+	return body.Rotation.Conjugate().Rotate(direction)
+}
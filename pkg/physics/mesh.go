@@ -0,0 +1,249 @@
+package physics
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// meshLeafSize максимальное количество треугольников в листе BVH
+const meshLeafSize = 4
+
+// ErrInvalidMesh индексы не образуют треугольники или ссылаются на несуществующие вершины
+var ErrInvalidMesh = errors.New("invalid triangle mesh indices")
+
+// TriangleMesh статическая треугольная сетка в локальных координатах тела
+// с иерархией ограничивающих объемов (BVH) для быстрого поиска треугольников.
+// Треугольники двусторонние, поэтому порядок обхода вершин не важен
+type TriangleMesh struct {
+	vertices []mgl32.Vec3
+	indices  []uint32
+	order    []int32 // Индексы треугольников в порядке листьев BVH
+	nodes    []meshNode
+}
+
+// meshStackSize стек обхода BVH на стеке горутины; глубже растет через append.
+// Сетку могут делить тела разных миров, поэтому запросы не меняют ее полей
+const meshStackSize = 64
+
+// meshNode узел BVH: внутренний - два потомка, лист - отрезок order
+type meshNode struct {
+	bounds      customMath.AABB
+	left, right int32 // nullNode для листа
+	start       int32
+	count       int32
+}
+
+// NewTriangleMesh строит сетку по вершинам и индексам (по три на треугольник),
+// например по model.Mesh: позиции вершин и Indices
+func NewTriangleMesh(vertices []mgl32.Vec3, indices []uint32) (*TriangleMesh, error) {
+	if len(indices) == 0 || len(indices)%3 != 0 {
+		return nil, ErrInvalidMesh
+	}
+	for _, index := range indices {
+		if int(index) >= len(vertices) {
+			return nil, ErrInvalidMesh
+		}
+	}
+
+	m := &TriangleMesh{vertices: vertices, indices: indices}
+	m.order = make([]int32, len(indices)/3)
+	for i := range m.order {
+		m.order[i] = int32(i)
+	}
+
+	centroids := make([]mgl32.Vec3, len(m.order))
+	for i := range centroids {
+		a, b, c := m.triangle(int32(i))
+		centroids[i] = a.Add(b).Add(c).Mul(1.0 / 3.0)
+	}
+	m.build(0, int32(len(m.order)), centroids)
+	return m, nil
+}
+
+// build строит поддерево над order[start:end] разбиением по медиане
+// вдоль самой длинной оси центров треугольников
+func (m *TriangleMesh) build(start, end int32, centroids []mgl32.Vec3) int32 {
+	id := int32(len(m.nodes))
+	m.nodes = append(m.nodes, meshNode{left: nullNode, right: nullNode, start: start, count: end - start})
+
+	bounds := m.triangleBounds(m.order[start])
+	centerMin, centerMax := centroids[m.order[start]], centroids[m.order[start]]
+	for _, tri := range m.order[start+1 : end] {
+		bounds = bounds.Merge(m.triangleBounds(tri))
+		for i := 0; i < 3; i++ {
+			centerMin[i] = min(centerMin[i], centroids[tri][i])
+			centerMax[i] = max(centerMax[i], centroids[tri][i])
+		}
+	}
+	m.nodes[id].bounds = bounds
+
+	if end-start <= meshLeafSize {
+		return id
+	}
+
+	axis := 0
+	extent := centerMax.Sub(centerMin)
+	if extent[1] > extent[axis] {
+		axis = 1
+	}
+	if extent[2] > extent[axis] {
+		axis = 2
+	}
+
+	part := m.order[start:end]
+	sort.Slice(part, func(i, j int) bool {
+		return centroids[part[i]][axis] < centroids[part[j]][axis]
+	})
+
+	mid := (start + end) / 2
+	left := m.build(start, mid, centroids)
+	right := m.build(mid, end, centroids)
+	m.nodes[id].left, m.nodes[id].right = left, right
+	return id
+}
+
+// triangle возвращает вершины треугольника в локальных координатах
+func (m *TriangleMesh) triangle(tri int32) (mgl32.Vec3, mgl32.Vec3, mgl32.Vec3) {
+	i := tri * 3
+	return m.vertices[m.indices[i]], m.vertices[m.indices[i+1]], m.vertices[m.indices[i+2]]
+}
+
+// triangleBounds возвращает AABB треугольника
+func (m *TriangleMesh) triangleBounds(tri int32) customMath.AABB {
+	a, b, c := m.triangle(tri)
+	return customMath.NewAABB(a, a).Merge(customMath.NewAABB(b, b)).Merge(customMath.NewAABB(c, c))
+}
+
+// GetTriangleCount возвращает количество треугольников
+func (m *TriangleMesh) GetTriangleCount() int {
+	return len(m.order)
+}
+
+// GetBounds возвращает ограничивающий объем сетки в локальных координатах
+func (m *TriangleMesh) GetBounds() customMath.AABB {
+	return m.nodes[0].bounds
+}
+
+// query вызывает callback для треугольников, AABB которых пересекает bounds
+func (m *TriangleMesh) query(bounds customMath.AABB, callback func(tri int32)) {
+	var buffer [meshStackSize]int32
+	stack := append(buffer[:0], 0)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := &m.nodes[id]
+		if !overlaps(&node.bounds, &bounds) {
+			continue
+		}
+
+		if node.left == nullNode {
+			for _, tri := range m.order[node.start : node.start+node.count] {
+				triBounds := m.triangleBounds(tri)
+				if overlaps(&triBounds, &bounds) {
+					callback(tri)
+				}
+			}
+		} else {
+			stack = append(stack, node.left, node.right)
+		}
+	}
+}
+
+// raycast обходит BVH лучом в локальных координатах. callback возвращает
+// новую длину луча, как в BroadPhase.Raycast
+func (m *TriangleMesh) raycast(origin, direction mgl32.Vec3, maxDistance float32, callback func(tri int32) float32) {
+	var buffer [meshStackSize]int32
+	stack := append(buffer[:0], 0)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := &m.nodes[id]
+		if _, _, ok := rayAABB(origin, direction, &node.bounds, maxDistance); !ok {
+			continue
+		}
+
+		if node.left == nullNode {
+			for _, tri := range m.order[node.start : node.start+node.count] {
+				maxDistance = callback(tri)
+			}
+		} else {
+			stack = append(stack, node.left, node.right)
+		}
+	}
+}
+
+// rayTriangle пересекает луч с двусторонним треугольником (Моллер-Трумбор)
+func rayTriangle(origin, direction, a, b, c mgl32.Vec3, maxDistance float32) (float32, bool) {
+	edge1 := b.Sub(a)
+	edge2 := c.Sub(a)
+	p := direction.Cross(edge2)
+	det := edge1.Dot(p)
+	if abs32(det) < 1e-10 {
+		return 0, false
+	}
+
+	inverse := 1 / det
+	s := origin.Sub(a)
+	u := s.Dot(p) * inverse
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := s.Cross(edge1)
+	v := direction.Dot(q) * inverse
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+
+	t := edge2.Dot(q) * inverse
+	if t < 0 || t > maxDistance {
+		return 0, false
+	}
+	return t, true
+}
+
+// closestPointOnTriangle возвращает ближайшую к p точку треугольника (по Эриксону)
+func closestPointOnTriangle(p, a, b, c mgl32.Vec3) mgl32.Vec3 {
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ap := p.Sub(a)
+	d1, d2 := ab.Dot(ap), ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+
+	bp := p.Sub(b)
+	d3, d4 := ab.Dot(bp), ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.Mul(d1 / (d1 - d3)))
+	}
+
+	cp := p.Sub(c)
+	d5, d6 := ab.Dot(cp), ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.Mul(d2 / (d2 - d6)))
+	}
+
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		return b.Add(c.Sub(b).Mul((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+
+	denom := 1 / (va + vb + vc)
+	return a.Add(ab.Mul(vb * denom)).Add(ac.Mul(vc * denom))
+}
@@ -0,0 +1,69 @@
+package physics
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// newGridMesh создает плоскую сетку size*size клеток по два треугольника
+func newGridMesh(t *testing.T, size int) *TriangleMesh {
+	var vertices []mgl32.Vec3
+	for z := 0; z <= size; z++ {
+		for x := 0; x <= size; x++ {
+			vertices = append(vertices, mgl32.Vec3{float32(x) - float32(size)/2, 0, float32(z) - float32(size)/2})
+		}
+	}
+	var indices []uint32
+	row := uint32(size + 1)
+	for z := uint32(0); z < uint32(size); z++ {
+		for x := uint32(0); x < uint32(size); x++ {
+			i := z*row + x
+			indices = append(indices, i, i+row, i+1, i+1, i+row, i+row+1)
+		}
+	}
+	mesh, err := NewTriangleMesh(vertices, indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mesh
+}
+
+// TestTriangleMeshSharedRaycast проверяет, что одну сетку могут одновременно
+// использовать тела разных миров в разных горутинах
+func TestTriangleMeshSharedRaycast(t *testing.T) {
+	mesh := newGridMesh(t, 19)
+
+	var wg sync.WaitGroup
+	misses := make([]int, 4)
+	for g := range misses {
+		world := NewPhysicsWorld()
+		ground := NewRigidBody(Static, ModelShape)
+		ground.Mesh = mesh
+		world.AddBody(ground)
+		world.SyncBroadPhase()
+
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 5000; i++ {
+				x := float32(i%90)/10 - 4.5
+				z := float32(i/90%90)/10 - 4.5
+				ray := customMath.NewRay(mgl32.Vec3{x, 5, z}, mgl32.Vec3{0, -1, 0})
+				if _, ok := world.Raycast(ray, 10, nil); !ok {
+					misses[g]++
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for g, count := range misses {
+		if count > 0 {
+			t.Errorf("горутина %d: %d лучей не попали в сетку", g, count)
+		}
+	}
+}
@@ -0,0 +1,670 @@
+package physics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// maxManifoldPoints максимальное количество точек контакта в манифолде
+const maxManifoldPoints = 4
+
+// contactMargin зазор, при котором вершина еще входит в манифолд с отрицательной
+// глубиной. Опоры качающегося тела не пропадают между шагами, и решатель
+// сохраняет для них накопленные импульсы
+const contactMargin = 0.02
+
+// ContactPoint точка контакта двух тел
+type ContactPoint struct {
+	Position mgl32.Vec3 // Точка в мировых координатах (посередине между поверхностями)
+	Depth    float32    // Глубина проникновения; отрицательная - небольшой зазор
+}
+
+// ContactManifold контакт пары тел: общая нормаль и до четырех точек
+type ContactManifold struct {
+	BodyA  *RigidBody
+	BodyB  *RigidBody
+	Normal mgl32.Vec3 // Единичная нормаль от A к B
+	Points []ContactPoint
+
+	// feature различает манифолды одной пары (группы треугольников сетки)
+	feature int32
+}
+
+// MaxDepth возвращает наибольшую глубину проникновения
+func (m *ContactManifold) MaxDepth() float32 {
+	var depth float32
+	for _, point := range m.Points {
+		if point.Depth > depth {
+			depth = point.Depth
+		}
+	}
+	return depth
+}
+
+// Collide выполняет точную проверку столкновения двух тел и строит манифолд.
+// Возвращает false, если тела не пересекаются. Для сеток и карт высот
+// возвращается самый глубокий из манифолдов
+func Collide(a, b *RigidBody) (ContactManifold, bool) {
+	ca := newCollider(a)
+	cb := newCollider(b)
+	return collide(&ca, &cb)
+}
+
+// CollideSpheres проверяет сферы одного радиуса против тела и вызывает fn для
+// каждой пересекающей его сферы. Нормаль манифолда направлена от сферы к телу.
+// Коллайдер тела строится один раз, поэтому так дешевле, чем Collide на каждую сферу
+func CollideSpheres(centers []mgl32.Vec3, radius float32, body *RigidBody, fn func(index int, manifold ContactManifold)) {
+	target := newCollider(body)
+	box := target.aabb()
+	probe := collider{
+		kind:     colliderSphere,
+		rotation: mgl32.Ident3(),
+		radius:   radius,
+	}
+
+	for i, center := range centers {
+		// Плоскость - полупространство: сферу под ней тоже нужно вытолкнуть
+		if target.kind != colliderPlane && (center.X()+radius < box.Min.X() || center.X()-radius > box.Max.X() ||
+			center.Y()+radius < box.Min.Y() || center.Y()-radius > box.Max.Y() ||
+			center.Z()+radius < box.Min.Z() || center.Z()-radius > box.Max.Z()) {
+			continue
+		}
+		probe.center = center
+		if manifold, ok := collide(&probe, &target); ok {
+			fn(i, manifold)
+		}
+	}
+}
+
+// collideBodies дописывает в manifolds контакты пары тел. Выпуклые пары дают
+// не больше одного манифолда, сетки и карты высот - по манифолду на группу
+// треугольников с общей нормалью
+func collideBodies(a, b *RigidBody, manifolds []ContactManifold) []ContactManifold {
+	ca := newCollider(a)
+	cb := newCollider(b)
+	if ca.isConcave() || cb.isConcave() {
+		return collideConcave(&ca, &cb, manifolds)
+	}
+	if manifold, ok := collide(&ca, &cb); ok {
+		manifolds = append(manifolds, manifold)
+	}
+	return manifolds
+}
+
+// collide выбирает тест по видам коллайдеров. Пары упорядочиваются так,
+// чтобы вид A был не больше вида B; при перестановке нормаль разворачивается
+func collide(a, b *collider) (ContactManifold, bool) {
+	if a.kind > b.kind {
+		manifold, ok := collide(b, a)
+		if ok {
+			manifold.BodyA, manifold.BodyB = manifold.BodyB, manifold.BodyA
+			manifold.Normal = manifold.Normal.Mul(-1)
+		}
+		return manifold, ok
+	}
+
+	manifold := ContactManifold{BodyA: a.body, BodyB: b.body}
+	var ok bool
+
+	if b.isConcave() {
+		// Самый глубокий манифолд из групп треугольников
+		var best ContactManifold
+		for _, m := range collideConcave(a, b, nil) {
+			if !ok || m.MaxDepth() > best.MaxDepth() {
+				best, ok = m, true
+			}
+		}
+		return best, ok
+	}
+
+	switch a.kind {
+	case colliderPlane:
+		switch b.kind {
+		case colliderPlane:
+			return manifold, false
+		case colliderSphere:
+			ok = collidePlaneSphere(a, b, &manifold)
+		case colliderCapsule:
+			ok = collidePlaneCapsule(a, b, &manifold)
+		default:
+			ok = collidePlaneConvex(a, b, &manifold)
+		}
+	case colliderSphere:
+		switch b.kind {
+		case colliderSphere:
+			ok = collideSpheres(a.center, a.radius, b.center, b.radius, &manifold)
+		case colliderCapsule:
+			p0, p1 := b.segment()
+			closest := closestPointOnSegment(a.center, p0, p1)
+			ok = collideSpheres(a.center, a.radius, closest, b.radius, &manifold)
+		case colliderBox:
+			ok = collideSphereBox(a, b, &manifold)
+		default:
+			ok = collideConvex(a, b, &manifold)
+		}
+	case colliderCapsule:
+		switch b.kind {
+		case colliderCapsule:
+			ok = collideCapsules(a, b, &manifold)
+		default:
+			ok = collideCapsuleBox(a, b, &manifold)
+		}
+	default:
+		if a.kind == colliderBox && b.kind == colliderBox {
+			ok = collideBoxes(a, b, &manifold)
+		} else {
+			ok = collidePolyhedra(a.polyhedron(), b.polyhedron(), &manifold)
+		}
+	}
+
+	return manifold, ok
+}
+
+// collideConvex общий тест для любых выпуклых коллайдеров через GJK/EPA
+func collideConvex(a, b *collider, manifold *ContactManifold) bool {
+	simplex, intersect := gjkIntersect(a, b)
+	if !intersect {
+		return false
+	}
+
+	normal, depth, point, ok := epaPenetration(a, b, simplex)
+	if !ok || depth <= 0 {
+		return false
+	}
+
+	manifold.Normal = normal
+	manifold.Points = append(manifold.Points[:0], ContactPoint{Position: point, Depth: depth})
+	return true
+}
+
+// collideSpheres тест двух сфер; нормаль от первой ко второй
+func collideSpheres(centerA mgl32.Vec3, radiusA float32, centerB mgl32.Vec3, radiusB float32, manifold *ContactManifold) bool {
+	diff := centerB.Sub(centerA)
+	distSq := diff.Dot(diff)
+	radius := radiusA + radiusB
+	if distSq > radius*radius {
+		return false
+	}
+
+	distance := sqrt32(distSq)
+	normal := mgl32.Vec3{0, 1, 0}
+	if distance > 1e-6 {
+		normal = diff.Mul(1 / distance)
+	}
+
+	surfaceA := centerA.Add(normal.Mul(radiusA))
+	surfaceB := centerB.Sub(normal.Mul(radiusB))
+
+	manifold.Normal = normal
+	manifold.Points = append(manifold.Points, ContactPoint{
+		Position: surfaceA.Add(surfaceB).Mul(0.5),
+		Depth:    radius - distance,
+	})
+	return true
+}
+
+// collidePlaneSphere тест плоскости и сферы
+func collidePlaneSphere(plane, sphere *collider, manifold *ContactManifold) bool {
+	distance := plane.normal.Dot(sphere.center) - plane.offset
+	if distance > sphere.radius {
+		return false
+	}
+
+	manifold.Normal = plane.normal
+	manifold.Points = append(manifold.Points, ContactPoint{
+		Position: sphere.center.Sub(plane.normal.Mul((sphere.radius + distance) / 2)),
+		Depth:    sphere.radius - distance,
+	})
+	return true
+}
+
+// collidePlaneCapsule тест плоскости и капсулы: концы отрезка как сферы
+func collidePlaneCapsule(plane, capsule *collider, manifold *ContactManifold) bool {
+	p0, p1 := capsule.segment()
+	touching := false
+	for _, end := range [2]mgl32.Vec3{p0, p1} {
+		distance := plane.normal.Dot(end) - plane.offset
+		if distance > capsule.radius+contactMargin {
+			continue
+		}
+		touching = touching || distance <= capsule.radius
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: end.Sub(plane.normal.Mul((capsule.radius + distance) / 2)),
+			Depth:    capsule.radius - distance,
+		})
+	}
+
+	manifold.Normal = plane.normal
+	return touching
+}
+
+// collidePlaneConvex тест плоскости и многогранника: вершины под плоскостью
+func collidePlaneConvex(plane, convex *collider, manifold *ContactManifold) bool {
+	touching := false
+	for _, vertex := range convex.convexVertices() {
+		distance := plane.normal.Dot(vertex) - plane.offset
+		if distance > contactMargin {
+			continue
+		}
+		touching = touching || distance <= 0
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: vertex.Sub(plane.normal.Mul(distance / 2)),
+			Depth:    -distance,
+		})
+	}
+
+	manifold.Normal = plane.normal
+	reducePoints(manifold)
+	return touching
+}
+
+// collideSphereBox тест сферы и ориентированного параллелепипеда
+func collideSphereBox(sphere, box *collider, manifold *ContactManifold) bool {
+	// Центр сферы в локальных координатах коллайдера
+	rel := sphere.center.Sub(box.center)
+	local := box.rotation.Transpose().Mul3x1(rel)
+
+	clamped := local
+	inside := true
+	for i := 0; i < 3; i++ {
+		if local[i] < -box.halfExtents[i] || local[i] > box.halfExtents[i] {
+			inside = false
+			clamped[i] = clamp32(local[i], -box.halfExtents[i], box.halfExtents[i])
+		}
+	}
+
+	if inside {
+		// Центр внутри - выталкиваем через ближайшую грань
+		axis := 0
+		minDist := box.halfExtents[0] - abs32(local[0])
+		for i := 1; i < 3; i++ {
+			if d := box.halfExtents[i] - abs32(local[i]); d < minDist {
+				axis, minDist = i, d
+			}
+		}
+
+		sign := float32(1)
+		if local[axis] < 0 {
+			sign = -1
+		}
+		// Нормаль от сферы (A) к коробке (B) - внутрь коробки
+		normal := box.axis(axis).Mul(-sign)
+		manifold.Normal = normal
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: sphere.center,
+			Depth:    sphere.radius + minDist,
+		})
+		return true
+	}
+
+	closest := box.center.Add(box.rotation.Mul3x1(clamped))
+	diff := closest.Sub(sphere.center)
+	distSq := diff.Dot(diff)
+	if distSq > sphere.radius*sphere.radius {
+		return false
+	}
+
+	distance := sqrt32(distSq)
+	normal := safeNormalize(diff)
+	surface := sphere.center.Add(normal.Mul(sphere.radius))
+
+	manifold.Normal = normal
+	manifold.Points = append(manifold.Points, ContactPoint{
+		Position: surface.Add(closest).Mul(0.5),
+		Depth:    sphere.radius - distance,
+	})
+	return true
+}
+
+// collideCapsules тест двух капсул через ближайшие точки осевых отрезков
+func collideCapsules(a, b *collider, manifold *ContactManifold) bool {
+	a0, a1 := a.segment()
+	b0, b1 := b.segment()
+	pa, pb := closestPointsSegments(a0, a1, b0, b1)
+	if !collideSpheres(pa, a.radius, pb, b.radius, manifold) {
+		return false
+	}
+
+	// Почти параллельные капсулы лежат друг на друге - добавляем вторую точку
+	dirA := a1.Sub(a0)
+	dirB := b1.Sub(b0)
+	if dirA.Len() > 1e-6 && dirB.Len() > 1e-6 && abs32(safeNormalize(dirA).Dot(safeNormalize(dirB))) > 0.98 {
+		for _, end := range [2]mgl32.Vec3{a0, a1} {
+			other := closestPointOnSegment(end, b0, b1)
+			var extra ContactManifold
+			if collideSpheres(end, a.radius, other, b.radius, &extra) && extra.Normal.Dot(manifold.Normal) > 0.95 {
+				manifold.Points = append(manifold.Points, extra.Points[0])
+			}
+		}
+		dedupePoints(manifold)
+	}
+	return true
+}
+
+// collideCapsuleBox тест капсулы и параллелепипеда или оболочки. Концы капсулы
+// проверяются как сферы, чтобы лежащая на грани капсула получила две точки опоры
+func collideCapsuleBox(capsule, box *collider, manifold *ContactManifold) bool {
+	if !collideConvex(capsule, box, manifold) {
+		return false
+	}
+
+	p0, p1 := capsule.segment()
+	for _, end := range [2]mgl32.Vec3{p0, p1} {
+		sphere := collider{kind: colliderSphere, center: end, radius: capsule.radius}
+		var extra ContactManifold
+		var ok bool
+		if box.kind == colliderBox {
+			ok = collideSphereBox(&sphere, box, &extra)
+		} else {
+			ok = collideConvex(&sphere, box, &extra)
+		}
+		if ok && extra.Normal.Dot(manifold.Normal) > 0.95 {
+			manifold.Points = append(manifold.Points, extra.Points[0])
+		}
+	}
+	dedupePoints(manifold)
+	return true
+}
+
+// collideBoxes тест двух ориентированных параллелепипедов теоремой
+// о разделяющих осях (15 осей) с отсечением граней для манифолда
+func collideBoxes(a, b *collider, manifold *ContactManifold) bool {
+	d := b.center.Sub(a.center)
+
+	bestDepth := float32(1e30)
+	bestBiased := float32(1e30)
+	var bestAxis mgl32.Vec3
+	bestType := -1 // 0 - грань A, 1 - грань B, 2 - ребра
+	bestA, bestB := 0, 0
+
+	test := func(axis mgl32.Vec3, kind, i, j int) bool {
+		length := axis.Len()
+		if length < 1e-6 {
+			// Параллельные ребра - ось вырождена, ее покрывают оси граней
+			return true
+		}
+		axis = axis.Mul(1 / length)
+
+		ra := projectBox(a, axis)
+		rb := projectBox(b, axis)
+		distance := d.Dot(axis)
+		depth := ra + rb - abs32(distance)
+		if depth < 0 {
+			return false
+		}
+
+		// Оси ребер выбираем, только если они заметно лучше осей граней
+		biased := depth
+		if kind == 2 {
+			biased = depth*1.05 + 0.001
+		}
+		if biased < bestBiased {
+			if distance < 0 {
+				axis = axis.Mul(-1)
+			}
+			bestBiased = biased
+			bestDepth = depth
+			bestAxis = axis
+			bestType = kind
+			bestA, bestB = i, j
+		}
+		return true
+	}
+
+	for i := 0; i < 3; i++ {
+		if !test(a.axis(i), 0, i, 0) {
+			return false
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if !test(b.axis(i), 1, i, 0) {
+			return false
+		}
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if !test(a.axis(i).Cross(b.axis(j)), 2, i, j) {
+				return false
+			}
+		}
+	}
+
+	manifold.Normal = bestAxis
+
+	switch bestType {
+	case 0:
+		clipBoxFaces(a, b, bestAxis, manifold)
+	case 1:
+		clipBoxFaces(b, a, bestAxis.Mul(-1), manifold)
+	default:
+		// Контакт ребро-ребро: ближайшие точки опорных ребер
+		edgeA0, edgeA1 := supportEdge(a, bestAxis, bestA)
+		edgeB0, edgeB1 := supportEdge(b, bestAxis.Mul(-1), bestB)
+		pa, pb := closestPointsSegments(edgeA0, edgeA1, edgeB0, edgeB1)
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: pa.Add(pb).Mul(0.5),
+			Depth:    bestDepth,
+		})
+	}
+
+	if len(manifold.Points) == 0 {
+		// Отсечение не дало точек (численные погрешности) - берем общий тест
+		return collideConvex(a, b, manifold)
+	}
+	return true
+}
+
+// projectBox возвращает полупроекцию параллелепипеда на ось
+func projectBox(box *collider, axis mgl32.Vec3) float32 {
+	return abs32(box.axis(0).Dot(axis))*box.halfExtents[0] +
+		abs32(box.axis(1).Dot(axis))*box.halfExtents[1] +
+		abs32(box.axis(2).Dot(axis))*box.halfExtents[2]
+}
+
+// supportEdge возвращает ребро параллелепипеда вдоль оси edgeAxis, самое дальнее в направлении dir
+func supportEdge(box *collider, dir mgl32.Vec3, edgeAxis int) (mgl32.Vec3, mgl32.Vec3) {
+	point := box.center
+	for i := 0; i < 3; i++ {
+		if i == edgeAxis {
+			continue
+		}
+		axis := box.axis(i)
+		if axis.Dot(dir) >= 0 {
+			point = point.Add(axis.Mul(box.halfExtents[i]))
+		} else {
+			point = point.Sub(axis.Mul(box.halfExtents[i]))
+		}
+	}
+
+	half := box.axis(edgeAxis).Mul(box.halfExtents[edgeAxis])
+	return point.Sub(half), point.Add(half)
+}
+
+// clipBoxFaces строит контакт грань-грань: грань incident отсекается боковыми
+// плоскостями опорной грани reference. normal направлена от reference к incident.
+// Точки симметричны, поэтому reference может быть любым из тел манифолда
+func clipBoxFaces(reference, incident *collider, normal mgl32.Vec3, manifold *ContactManifold) {
+	// Опорная грань: ось reference, наиболее сонаправленная с нормалью
+	refAxis := 0
+	best := float32(-1)
+	for i := 0; i < 3; i++ {
+		if dot := abs32(reference.axis(i).Dot(normal)); dot > best {
+			refAxis, best = i, dot
+		}
+	}
+	refNormal := reference.axis(refAxis)
+	if refNormal.Dot(normal) < 0 {
+		refNormal = refNormal.Mul(-1)
+	}
+	refCenter := reference.center.Add(refNormal.Mul(reference.halfExtents[refAxis]))
+
+	// Падающая грань: грань incident, наиболее противоположная нормали
+	incAxis := 0
+	best = -1
+	for i := 0; i < 3; i++ {
+		if dot := abs32(incident.axis(i).Dot(normal)); dot > best {
+			incAxis, best = i, dot
+		}
+	}
+	incNormal := incident.axis(incAxis)
+	if incNormal.Dot(normal) > 0 {
+		incNormal = incNormal.Mul(-1)
+	}
+	incCenter := incident.center.Add(incNormal.Mul(incident.halfExtents[incAxis]))
+
+	u, v := (incAxis+1)%3, (incAxis+2)%3
+	du := incident.axis(u).Mul(incident.halfExtents[u])
+	dv := incident.axis(v).Mul(incident.halfExtents[v])
+	polygon := []mgl32.Vec3{
+		incCenter.Add(du).Add(dv),
+		incCenter.Sub(du).Add(dv),
+		incCenter.Sub(du).Sub(dv),
+		incCenter.Add(du).Sub(dv),
+	}
+
+	// Отсекаем четырьмя боковыми плоскостями опорной грани
+	for _, side := range [2]int{(refAxis + 1) % 3, (refAxis + 2) % 3} {
+		axis := reference.axis(side)
+		extent := reference.halfExtents[side]
+		offset := axis.Dot(reference.center)
+		polygon = clipPolygon(polygon, axis, offset+extent)
+		polygon = clipPolygon(polygon, axis.Mul(-1), -offset+extent)
+	}
+
+	refOffset := refNormal.Dot(refCenter)
+	for _, point := range polygon {
+		separation := refNormal.Dot(point) - refOffset
+		if separation > contactMargin {
+			continue
+		}
+		// Середина между точкой падающей грани и ее проекцией на опорную
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: point.Sub(refNormal.Mul(separation / 2)),
+			Depth:    -separation,
+		})
+	}
+
+	reducePoints(manifold)
+}
+
+// clipPolygon отсекает многоугольник полупространством normal·p <= offset
+func clipPolygon(polygon []mgl32.Vec3, normal mgl32.Vec3, offset float32) []mgl32.Vec3 {
+	if len(polygon) == 0 {
+		return polygon
+	}
+
+	result := make([]mgl32.Vec3, 0, len(polygon)+2)
+	prev := polygon[len(polygon)-1]
+	prevDist := normal.Dot(prev) - offset
+
+	for _, current := range polygon {
+		dist := normal.Dot(current) - offset
+		if prevDist <= 0 && dist <= 0 {
+			result = append(result, current)
+		} else if prevDist <= 0 && dist > 0 {
+			result = append(result, prev.Add(current.Sub(prev).Mul(prevDist/(prevDist-dist))))
+		} else if prevDist > 0 && dist <= 0 {
+			result = append(result, prev.Add(current.Sub(prev).Mul(prevDist/(prevDist-dist))))
+			result = append(result, current)
+		}
+		prev, prevDist = current, dist
+	}
+	return result
+}
+
+// vertices возвращает 8 вершин параллелепипеда
+func (c *collider) vertices() [8]mgl32.Vec3 {
+	var result [8]mgl32.Vec3
+	ax := c.axis(0).Mul(c.halfExtents[0])
+	ay := c.axis(1).Mul(c.halfExtents[1])
+	az := c.axis(2).Mul(c.halfExtents[2])
+	for i := 0; i < 8; i++ {
+		point := c.center
+		if i&1 != 0 {
+			point = point.Add(ax)
+		} else {
+			point = point.Sub(ax)
+		}
+		if i&2 != 0 {
+			point = point.Add(ay)
+		} else {
+			point = point.Sub(ay)
+		}
+		if i&4 != 0 {
+			point = point.Add(az)
+		} else {
+			point = point.Sub(az)
+		}
+		result[i] = point
+	}
+	return result
+}
+
+// dedupePoints удаляет почти совпадающие точки контакта
+func dedupePoints(manifold *ContactManifold) {
+	const minDistSq = 1e-4
+	points := manifold.Points[:0]
+	for _, point := range manifold.Points {
+		duplicate := false
+		for i, kept := range points {
+			if point.Position.Sub(kept.Position).LenSqr() < minDistSq {
+				if point.Depth > kept.Depth {
+					points[i] = point
+				}
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			points = append(points, point)
+		}
+	}
+	manifold.Points = points
+	reducePoints(manifold)
+}
+
+// reducePoints оставляет не более maxManifoldPoints точек: самую глубокую
+// и затем по очереди самые удаленные от уже выбранных
+func reducePoints(manifold *ContactManifold) {
+	if len(manifold.Points) <= maxManifoldPoints {
+		return
+	}
+
+	points := manifold.Points
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Depth > points[j].Depth
+	})
+
+	selected := []ContactPoint{points[0]}
+	used := make([]bool, len(points))
+	used[0] = true
+
+	for len(selected) < maxManifoldPoints {
+		best, bestDist := -1, float32(-1)
+		for i, candidate := range points {
+			if used[i] {
+				continue
+			}
+			minDist := float32(1e30)
+			for _, chosen := range selected {
+				if dist := candidate.Position.Sub(chosen.Position).LenSqr(); dist < minDist {
+					minDist = dist
+				}
+			}
+			if minDist > bestDist {
+				best, bestDist = i, minDist
+			}
+		}
+		used[best] = true
+		selected = append(selected, points[best])
+	}
+
+	manifold.Points = append(manifold.Points[:0], selected...)
+}
+
+// sqrt32 квадратный корень float32
+func sqrt32(x float32) float32 {
+	return float32(math.Sqrt(float64(x)))
+}
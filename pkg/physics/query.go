@@ -0,0 +1,491 @@
+package physics
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// castBisections количество делений пополам при уточнении момента касания в BoxCast
+const castBisections = 16
+
+// QueryFilter отбирает тела для запросов к миру. nil - подходят все тела
+type QueryFilter func(body *RigidBody) bool
+
+// LayerFilter возвращает фильтр тел, входящих хотя бы в один слой маски
+func LayerFilter(mask uint32) QueryFilter {
+	return func(body *RigidBody) bool {
+		return body.Layer&mask != 0
+	}
+}
+
+// RaycastHit попадание луча или движущейся формы в тело
+type RaycastHit struct {
+	Body     *RigidBody
+	Point    mgl32.Vec3 // Точка на поверхности тела в мировых координатах
+	Normal   mgl32.Vec3 // Нормаль поверхности тела в точке попадания
+	Distance float32    // Расстояние вдоль луча до попадания
+}
+
+// SyncBroadPhase обновляет широкую фазу для запросов. После Step, AddBody и RemoveBody
+// это происходит автоматически; вызывайте вручную, если тела перемещены между запросами
+func (w *PhysicsWorld) SyncBroadPhase() {
+	w.broadPhase.Update(w.Bodies)
+	w.queryDirty = false
+}
+
+// prepareQuery синхронизирует широкую фазу, если тела могли сместиться
+func (w *PhysicsWorld) prepareQuery() {
+	if w.queryDirty {
+		w.SyncBroadPhase()
+	}
+}
+
+// Raycast находит ближайшее тело на луче не дальше maxDistance.
+// Тела, внутри которых начинается луч, не учитываются
+func (w *PhysicsWorld) Raycast(ray customMath.Ray, maxDistance float32, filter QueryFilter) (RaycastHit, bool) {
+	var closest RaycastHit
+	found := false
+
+	w.raycast(ray, maxDistance, filter, func(hit RaycastHit) float32 {
+		closest = hit
+		found = true
+		return hit.Distance
+	})
+	return closest, found
+}
+
+// RaycastAll находит все тела на луче не дальше maxDistance, отсортированные по расстоянию
+func (w *PhysicsWorld) RaycastAll(ray customMath.Ray, maxDistance float32, filter QueryFilter) []RaycastHit {
+	var hits []RaycastHit
+
+	w.raycast(ray, maxDistance, filter, func(hit RaycastHit) float32 {
+		hits = append(hits, hit)
+		return maxDistance
+	})
+
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+	return hits
+}
+
+// raycast обходит широкую фазу лучом и передает точные попадания в report.
+// report возвращает новую длину луча, как callback в BroadPhase.Raycast
+func (w *PhysicsWorld) raycast(ray customMath.Ray, maxDistance float32, filter QueryFilter, report func(hit RaycastHit) float32) {
+	length := ray.Direction.Len()
+	if length < 1e-8 || maxDistance <= 0 {
+		return
+	}
+	ray.Direction = ray.Direction.Mul(1 / length)
+
+	w.prepareQuery()
+	w.broadPhase.Raycast(ray, maxDistance, func(body *RigidBody) float32 {
+		if filter != nil && !filter(body) {
+			return maxDistance
+		}
+
+		c := newCollider(body)
+		normal, distance, ok := c.raycast(ray.Origin, ray.Direction, 0, maxDistance)
+		if !ok {
+			return maxDistance
+		}
+
+		maxDistance = report(RaycastHit{
+			Body:     body,
+			Point:    ray.PointAt(distance),
+			Normal:   normal,
+			Distance: distance,
+		})
+		return maxDistance
+	})
+}
+
+// SphereCast двигает сферу радиуса radius вдоль луча и находит первое тело,
+// которого она коснется. Distance - путь центра сферы, Point - точка касания.
+// Тела, пересекающиеся со сферой в начале пути, не учитываются
+func (w *PhysicsWorld) SphereCast(ray customMath.Ray, radius, maxDistance float32, filter QueryFilter) (RaycastHit, bool) {
+	probe := collider{
+		kind:     colliderSphere,
+		center:   ray.Origin,
+		rotation: mgl32.Ident3(),
+		radius:   radius,
+	}
+
+	return w.shapeCast(&probe, ray, maxDistance, filter, func(target *collider, direction mgl32.Vec3, limit float32) (mgl32.Vec3, mgl32.Vec3, float32, bool) {
+		return castProbe(&probe, target, ray.Origin, direction, limit)
+	})
+}
+
+// BoxCast двигает ориентированный параллелепипед вдоль луча и находит первое тело,
+// которого он коснется. Distance - путь центра, Point - точка касания.
+// Момент касания уточняется делением пополам, поэтому тела тоньше половины
+// наименьшего размера параллелепипеда могут быть пропущены при касании углом
+func (w *PhysicsWorld) BoxCast(ray customMath.Ray, halfExtents mgl32.Vec3, rotation mgl32.Quat, maxDistance float32, filter QueryFilter) (RaycastHit, bool) {
+	probe := collider{
+		kind:        colliderBox,
+		center:      ray.Origin,
+		rotation:    rotation.Normalize().Mat4().Mat3(),
+		halfExtents: halfExtents,
+	}
+
+	return w.shapeCast(&probe, ray, maxDistance, filter, func(target *collider, direction mgl32.Vec3, limit float32) (mgl32.Vec3, mgl32.Vec3, float32, bool) {
+		return castConvex(&probe, target, ray.Origin, direction, limit)
+	})
+}
+
+// shapeCast отбирает кандидатов по AABB всего пути формы и выбирает ближайшее попадание.
+// cast возвращает нормаль поверхности цели, точку касания и путь до нее
+func (w *PhysicsWorld) shapeCast(probe *collider, ray customMath.Ray, maxDistance float32, filter QueryFilter,
+	cast func(target *collider, direction mgl32.Vec3, limit float32) (mgl32.Vec3, mgl32.Vec3, float32, bool)) (RaycastHit, bool) {
+
+	var closest RaycastHit
+	length := ray.Direction.Len()
+	if length < 1e-8 || maxDistance <= 0 {
+		return closest, false
+	}
+	direction := ray.Direction.Mul(1 / length)
+
+	start := probe.aabb()
+	probe.center = ray.Origin.Add(direction.Mul(maxDistance))
+	swept := start.Merge(probe.aabb())
+	probe.center = ray.Origin
+
+	var candidates []*RigidBody
+	w.prepareQuery()
+	w.broadPhase.Query(swept, func(body *RigidBody) bool {
+		if filter == nil || filter(body) {
+			candidates = append(candidates, body)
+		}
+		return true
+	})
+
+	found := false
+	limit := maxDistance
+	for _, body := range candidates {
+		target := newCollider(body)
+		normal, point, distance, ok := cast(&target, direction, limit)
+		if !ok {
+			continue
+		}
+
+		closest = RaycastHit{
+			Body:     body,
+			Point:    point,
+			Normal:   normal,
+			Distance: distance,
+		}
+		found = true
+		limit = distance
+	}
+	return closest, found
+}
+
+// OverlapSphere возвращает тела, пересекающиеся со сферой
+func (w *PhysicsWorld) OverlapSphere(center mgl32.Vec3, radius float32, filter QueryFilter) []*RigidBody {
+	probe := collider{
+		kind:     colliderSphere,
+		center:   center,
+		rotation: mgl32.Ident3(),
+		radius:   radius,
+	}
+	return w.overlap(&probe, filter)
+}
+
+// OverlapBox возвращает тела, пересекающиеся с ориентированным параллелепипедом
+func (w *PhysicsWorld) OverlapBox(center, halfExtents mgl32.Vec3, rotation mgl32.Quat, filter QueryFilter) []*RigidBody {
+	probe := collider{
+		kind:        colliderBox,
+		center:      center,
+		rotation:    rotation.Normalize().Mat4().Mat3(),
+		halfExtents: halfExtents,
+	}
+	return w.overlap(&probe, filter)
+}
+
+// overlap отбирает кандидатов широкой фазой и проверяет их узкой фазой
+func (w *PhysicsWorld) overlap(probe *collider, filter QueryFilter) []*RigidBody {
+	var bodies []*RigidBody
+
+	w.prepareQuery()
+	w.broadPhase.Query(probe.aabb(), func(body *RigidBody) bool {
+		if filter != nil && !filter(body) {
+			return true
+		}
+
+		target := newCollider(body)
+		if _, ok := collide(probe, &target); ok {
+			bodies = append(bodies, body)
+		}
+		return true
+	})
+	return bodies
+}
+
+// raycast пересекает луч с коллайдером, расширенным на inflate (для SphereCast).
+// Возвращает нормаль поверхности и расстояние; начало внутри коллайдера - промах
+func (c *collider) raycast(origin, direction mgl32.Vec3, inflate, maxDistance float32) (mgl32.Vec3, float32, bool) {
+	switch c.kind {
+	case colliderSphere:
+		return raySphere(origin, direction, c.center, c.radius+inflate, maxDistance)
+	case colliderCapsule:
+		p0, p1 := c.segment()
+		return rayCapsule(origin, direction, p0, p1, c.radius+inflate, maxDistance)
+	case colliderPlane:
+		distance := c.normal.Dot(origin) - c.offset - inflate
+		speed := c.normal.Dot(direction)
+		if distance < 0 || speed >= 0 {
+			return mgl32.Vec3{}, 0, false
+		}
+		t := -distance / speed
+		if t > maxDistance {
+			return mgl32.Vec3{}, 0, false
+		}
+		return c.normal, t, true
+	case colliderHull:
+		return rayPolyhedron(c.poly, origin, direction, maxDistance)
+	case colliderMesh, colliderHeightfield:
+		return c.rayConcave(origin, direction, maxDistance)
+	default:
+		// Переходим в локальные координаты параллелепипеда
+		inverse := c.rotation.Transpose()
+		localOrigin := inverse.Mul3x1(origin.Sub(c.center))
+		localDirection := inverse.Mul3x1(direction)
+
+		normal, t, ok := rayRoundedBox(localOrigin, localDirection, c.halfExtents, inflate, maxDistance)
+		if !ok {
+			return mgl32.Vec3{}, 0, false
+		}
+		return c.rotation.Mul3x1(normal), t, true
+	}
+}
+
+// raySphere пересекает луч со сферой
+func raySphere(origin, direction, center mgl32.Vec3, radius, maxDistance float32) (mgl32.Vec3, float32, bool) {
+	m := origin.Sub(center)
+	b := m.Dot(direction)
+	c := m.Dot(m) - radius*radius
+	if c <= 0 || b > 0 {
+		// Начало внутри сферы или сфера позади луча
+		return mgl32.Vec3{}, 0, false
+	}
+
+	discriminant := b*b - c
+	if discriminant < 0 {
+		return mgl32.Vec3{}, 0, false
+	}
+
+	t := -b - sqrt32(discriminant)
+	if t > maxDistance {
+		return mgl32.Vec3{}, 0, false
+	}
+	normal := m.Add(direction.Mul(t)).Mul(1 / radius)
+	return normal, t, true
+}
+
+// rayCapsule пересекает луч с капсулой: боковая поверхность цилиндра и две полусферы
+func rayCapsule(origin, direction, p0, p1 mgl32.Vec3, radius, maxDistance float32) (mgl32.Vec3, float32, bool) {
+	closest := closestPointOnSegment(origin, p0, p1)
+	if origin.Sub(closest).LenSqr() <= radius*radius {
+		return mgl32.Vec3{}, 0, false
+	}
+
+	var bestNormal mgl32.Vec3
+	best := maxDistance
+	found := false
+
+	axis := p1.Sub(p0)
+	height := axis.Len()
+	if height > 1e-6 {
+		axis = axis.Mul(1 / height)
+
+		// Проекции на плоскость, перпендикулярную оси
+		m := origin.Sub(p0)
+		dPerp := direction.Sub(axis.Mul(direction.Dot(axis)))
+		mPerp := m.Sub(axis.Mul(m.Dot(axis)))
+
+		a := dPerp.Dot(dPerp)
+		b := mPerp.Dot(dPerp)
+		c := mPerp.Dot(mPerp) - radius*radius
+		if a > 1e-12 && b*b-a*c >= 0 {
+			t := (-b - sqrt32(b*b-a*c)) / a
+			s := m.Add(direction.Mul(t)).Dot(axis)
+			if t >= 0 && t <= best && s >= 0 && s <= height {
+				point := origin.Add(direction.Mul(t))
+				bestNormal = point.Sub(p0.Add(axis.Mul(s))).Mul(1 / radius)
+				best = t
+				found = true
+			}
+		}
+	}
+
+	for _, end := range [2]mgl32.Vec3{p0, p1} {
+		if normal, t, ok := raySphere(origin, direction, end, radius, best); ok {
+			bestNormal, best, found = normal, t, true
+		}
+	}
+	return bestNormal, best, found
+}
+
+// rayRoundedBox пересекает луч в локальных координатах с параллелепипедом,
+// скругленным на radius: три расширенных по одной оси параллелепипеда
+// и двенадцать капсул вдоль ребер
+func rayRoundedBox(origin, direction, halfExtents mgl32.Vec3, radius, maxDistance float32) (mgl32.Vec3, float32, bool) {
+	// Начало внутри скругленного параллелепипеда - промах
+	var outside mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		outside[i] = max(abs32(origin[i])-halfExtents[i], 0)
+	}
+	if outside.LenSqr() <= radius*radius {
+		return mgl32.Vec3{}, 0, false
+	}
+
+	var bestNormal mgl32.Vec3
+	best := maxDistance
+	found := false
+
+	for axis := 0; axis < 3; axis++ {
+		extents := halfExtents
+		extents[axis] += radius
+		if normal, t, ok := rayBox(origin, direction, extents, best); ok {
+			bestNormal, best, found = normal, t, true
+		}
+	}
+
+	if radius > 0 {
+		for axis := 0; axis < 3; axis++ {
+			u, v := (axis+1)%3, (axis+2)%3
+			for corner := 0; corner < 4; corner++ {
+				var p0 mgl32.Vec3
+				p0[u] = halfExtents[u]
+				p0[v] = halfExtents[v]
+				if corner&1 != 0 {
+					p0[u] = -p0[u]
+				}
+				if corner&2 != 0 {
+					p0[v] = -p0[v]
+				}
+				p1 := p0
+				p0[axis] = -halfExtents[axis]
+				p1[axis] = halfExtents[axis]
+
+				if normal, t, ok := rayCapsule(origin, direction, p0, p1, radius, best); ok {
+					bestNormal, best, found = normal, t, true
+				}
+			}
+		}
+	}
+	return bestNormal, best, found
+}
+
+// rayBox пересекает луч в локальных координатах с параллелепипедом с центром в начале
+// координат. Нормаль - ось грани, через которую луч входит
+func rayBox(origin, direction, halfExtents mgl32.Vec3, maxDistance float32) (mgl32.Vec3, float32, bool) {
+	box := customMath.AABB{Min: halfExtents.Mul(-1), Max: halfExtents}
+	if box.Contains(origin) {
+		return mgl32.Vec3{}, 0, false
+	}
+
+	t, _, ok := rayAABB(origin, direction, &box, maxDistance)
+	if !ok {
+		return mgl32.Vec3{}, 0, false
+	}
+
+	// Грань входа - ось, на которой точка попадания дальше всего от центра
+	point := origin.Add(direction.Mul(t))
+	axis := 0
+	best := abs32(point[0]) - halfExtents[0]
+	for i := 1; i < 3; i++ {
+		if d := abs32(point[i]) - halfExtents[i]; d > best {
+			axis, best = i, d
+		}
+	}
+
+	var normal mgl32.Vec3
+	normal[axis] = 1
+	if point[axis] < 0 {
+		normal[axis] = -1
+	}
+	return normal, t, true
+}
+
+// castConvex двигает выпуклый коллайдер probe и находит момент касания с target.
+// Путь проходится шагами в половину наименьшего размера, затем момент касания
+// уточняется делением пополам; нормаль и точка берутся из манифолда в момент касания
+func castConvex(probe, target *collider, origin, direction mgl32.Vec3, maxDistance float32) (mgl32.Vec3, mgl32.Vec3, float32, bool) {
+	if target.kind == colliderPlane {
+		// Плоскость: ближайшая к ней вершина движется по прямой
+		probe.center = origin
+		lowest := probe.support(target.normal.Mul(-1))
+		distance := target.normal.Dot(lowest) - target.offset
+		speed := target.normal.Dot(direction)
+		if distance <= 0 || speed >= 0 {
+			return mgl32.Vec3{}, mgl32.Vec3{}, 0, false
+		}
+		t := -distance / speed
+		if t > maxDistance {
+			return mgl32.Vec3{}, mgl32.Vec3{}, 0, false
+		}
+		return target.normal, lowest.Add(direction.Mul(t)), t, true
+	}
+
+	// Путь, на котором AABB формы может пересекаться с AABB цели
+	targetBox := target.aabb()
+	probe.center = mgl32.Vec3{}
+	half := probe.aabb().Max
+	expanded := customMath.AABB{Min: targetBox.Min.Sub(half), Max: targetBox.Max.Add(half)}
+	enter, exit, ok := rayAABB(origin, direction, &expanded, maxDistance)
+	if !ok {
+		return mgl32.Vec3{}, mgl32.Vec3{}, 0, false
+	}
+
+	overlapsAt := func(t float32) bool {
+		probe.center = origin.Add(direction.Mul(t))
+		_, hit := collide(probe, target)
+		return hit
+	}
+	if overlapsAt(0) {
+		return mgl32.Vec3{}, mgl32.Vec3{}, 0, false
+	}
+
+	step := max(min(half[0], half[1], half[2]), 1e-3)
+
+	lo, hi := enter, enter
+	hit := false
+	for t := enter; ; t += step {
+		t = min(t, exit)
+		if overlapsAt(t) {
+			hi, hit = t, true
+			break
+		}
+		lo = t
+		if t >= exit {
+			break
+		}
+	}
+	if !hit {
+		return mgl32.Vec3{}, mgl32.Vec3{}, 0, false
+	}
+
+	for i := 0; i < castBisections && hi > lo; i++ {
+		mid := (lo + hi) / 2
+		if overlapsAt(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	probe.center = origin.Add(direction.Mul(hi))
+	manifold, _ := collide(probe, target)
+
+	// Точка - среднее точек контакта; нормаль манифолда направлена от формы к цели
+	var point mgl32.Vec3
+	for _, contact := range manifold.Points {
+		point = point.Add(contact.Position)
+	}
+	point = point.Mul(1 / float32(len(manifold.Points)))
+	return manifold.Normal.Mul(-1), point, lo, true
+}
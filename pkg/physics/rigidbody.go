@@ -2,6 +2,8 @@ package physics
 
 import (
 	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
 )
 
 // RigidBodyType тип физического тела
@@ -20,9 +22,17 @@ const (
 	BoxShape CollisionShape = iota
 	SphereShape
 	CapsuleShape
-	PlaneShape
-	LiquidShape // Жидкость (мягкое тело)
-	ModelShape  // 3D модель (FBX/OBJ)
+	PlaneShape       // Бесконечная плоскость через Position с нормалью локальной оси Y
+	LiquidShape      // Жидкость (мягкое тело)
+	ModelShape       // 3D модель (FBX/OBJ): треугольная сетка Mesh, без нее - параллелепипед
+	ConvexHullShape  // Выпуклая оболочка Hull
+	HeightfieldShape // Ландшафт по карте высот Heightfield
+)
+
+// Слои столкновений: тела сталкиваются, если слой каждого входит в маску другого
+const (
+	DefaultLayer uint32 = 1 << 0     // Слой новых тел
+	AllLayers    uint32 = 0xFFFFFFFF // Маска, пропускающая все слои
 )
 
 // RigidBody физическое тело
@@ -38,6 +48,8 @@ type RigidBody struct {
 	Mass            float32
 	Restitution     float32 // Коэффициент отскока (0-1)
 	Friction        float32 // Коэффициент трения (0-1)
+	LinearDamping   float32 // Затухание линейной скорости, 1/с
+	AngularDamping  float32 // Затухание угловой скорости, 1/с
 
 	// Тип и форма
 	Type  RigidBodyType
@@ -46,38 +58,90 @@ type RigidBody struct {
 	// Размеры (зависит от формы)
 	Dimensions mgl32.Vec3 // для Box: width, height, depth; для Sphere: radius, 0, 0
 
+	// Геометрия сложных форм в локальных координатах, масштабируется Scale.
+	// Сетки и карты высот предназначены для статических тел
+	Mesh        *TriangleMesh
+	Hull        *ConvexHull
+	Heightfield *Heightfield
+
 	// Флаги
 	UseGravity bool
-	IsGrounded bool
+	IsGrounded bool // Стоит на опоре: контакт с нормалью против гравитации на последнем шаге
+	AllowSleep bool // Тело может засыпать в покое
+	CCD        bool // Непрерывное определение столкновений для быстрых тел (сфера, капсула, параллелепипед)
+
+	// Фильтрация столкновений
+	Layer     uint32 // Слои, к которым относится тело
+	Mask      uint32 // Слои, с которыми тело сталкивается
+	IsTrigger bool   // Датчик: сообщает о пересечениях, но не участвует в столкновениях
+
+	// Сущность ECS, связанная с телом (передается в событиях коллизий)
+	EntityID uint64
 
 	// Для отладки
 	ID   int
 	Name string
+
+	// Псевдоскорости коррекции проникновения (split impulse), живут один шаг
+	pseudoVelocity mgl32.Vec3
+	pseudoAngular  mgl32.Vec3
+
+	// Обратные главные моменты инерции: мир считает их раз за шаг, вне шага
+	// (inertiaCached == false) они вычисляются при каждом обращении
+	invInertiaLocal mgl32.Vec3
+	inertiaCached   bool
+
+	// Сон: тело не интегрируется, пока его остров покоится
+	sleeping    bool
+	sleepTime   float32 // Сколько тело покоится, с
+	wokeUp      bool    // Разбужено между шагами, событие еще не отправлено
+	islandIndex int32   // Индекс острова на текущем шаге, -1 вне мира
 }
 
 // NewRigidBody создает новое физическое тело
 func NewRigidBody(bodyType RigidBodyType, shape CollisionShape) *RigidBody {
 	return &RigidBody{
-		Position:    mgl32.Vec3{0, 0, 0},
-		Rotation:    mgl32.QuatIdent(),
-		Scale:       mgl32.Vec3{1, 1, 1},
-		Velocity:    mgl32.Vec3{0, 0, 0},
-		Mass:        1.0,
-		Restitution: 0.5,
-		Friction:    0.5,
-		Type:        bodyType,
-		Shape:       shape,
-		Dimensions:  mgl32.Vec3{1, 1, 1},
-		UseGravity:  true,
-		IsGrounded:  false,
+		Position:       mgl32.Vec3{0, 0, 0},
+		Rotation:       mgl32.QuatIdent(),
+		Scale:          mgl32.Vec3{1, 1, 1},
+		Velocity:       mgl32.Vec3{0, 0, 0},
+		Mass:           1.0,
+		Restitution:    0.5,
+		Friction:       0.5,
+		LinearDamping:  0.05,
+		AngularDamping: 0.05,
+		Type:           bodyType,
+		Shape:          shape,
+		Dimensions:     mgl32.Vec3{1, 1, 1},
+		UseGravity:     true,
+		IsGrounded:     false,
+		AllowSleep:     true,
+		Layer:          DefaultLayer,
+		Mask:           AllLayers,
+		islandIndex:    -1,
 	}
 }
 
-// ApplyForce применяет силу к телу
+// NewPlaneBody создает статическую бесконечную плоскость через point с нормалью normal.
+// Плоскости - обычные статические тела: их может быть сколько угодно (пол, стены, склоны)
+func NewPlaneBody(point, normal mgl32.Vec3) *RigidBody {
+	body := NewRigidBody(Static, PlaneShape)
+	body.Position = point
+	body.Rotation = mgl32.QuatBetweenVectors(mgl32.Vec3{0, 1, 0}, normal.Normalize())
+	return body
+}
+
+// CanCollideWith проверяет слои и маски пары тел
+func (rb *RigidBody) CanCollideWith(other *RigidBody) bool {
+	return rb.Layer&other.Mask != 0 && other.Layer&rb.Mask != 0
+}
+
+// ApplyForce применяет силу к телу. Силы и импульсы будят спящее тело
 func (rb *RigidBody) ApplyForce(force mgl32.Vec3) {
 	if rb.Type != Dynamic {
 		return
 	}
+	rb.WakeUp()
 	// F = ma => a = F/m
 	acceleration := force.Mul(1.0 / rb.Mass)
 	rb.Velocity = rb.Velocity.Add(acceleration)
@@ -88,8 +152,108 @@ func (rb *RigidBody) ApplyImpulse(impulse mgl32.Vec3) {
 	if rb.Type != Dynamic {
 		return
 	}
+	rb.WakeUp()
+	rb.applyImpulse(impulse)
+}
+
+// ApplyImpulseAtPoint применяет импульс в точке мира, меняя и линейную,
+// и угловую скорость
+func (rb *RigidBody) ApplyImpulseAtPoint(impulse, point mgl32.Vec3) {
+	if rb.Type != Dynamic {
+		return
+	}
+	rb.WakeUp()
+	rb.applyImpulseAtPoint(impulse, point)
+}
+
+// applyImpulse применяет импульс, не трогая сон тела
+func (rb *RigidBody) applyImpulse(impulse mgl32.Vec3) {
 	// p = mv => v = p/m
-	rb.Velocity = rb.Velocity.Add(impulse.Mul(1.0 / rb.Mass))
+	rb.Velocity = rb.Velocity.Add(impulse.Mul(rb.inverseMass()))
+}
+
+// applyImpulseAtPoint применяет импульс в точке мира, не трогая сон тела
+func (rb *RigidBody) applyImpulseAtPoint(impulse, point mgl32.Vec3) {
+	rb.Velocity = rb.Velocity.Add(impulse.Mul(rb.inverseMass()))
+	arm := point.Sub(rb.Position)
+	rb.AngularVelocity = rb.AngularVelocity.Add(rb.inverseInertia().Mul3x1(arm.Cross(impulse)))
+}
+
+// GetPointVelocity возвращает скорость точки тела, заданной в мировых координатах
+func (rb *RigidBody) GetPointVelocity(point mgl32.Vec3) mgl32.Vec3 {
+	return rb.Velocity.Add(rb.AngularVelocity.Cross(point.Sub(rb.Position)))
+}
+
+// ApplyAngularImpulse применяет угловой импульс (момент, умноженный на время)
+func (rb *RigidBody) ApplyAngularImpulse(impulse mgl32.Vec3) {
+	if rb.Type != Dynamic {
+		return
+	}
+	rb.WakeUp()
+	rb.AngularVelocity = rb.AngularVelocity.Add(rb.inverseInertia().Mul3x1(impulse))
+}
+
+// GetInertiaTensor возвращает тензор инерции тела в мировых осях.
+// Зависит от формы, размеров и массы
+func (rb *RigidBody) GetInertiaTensor() mgl32.Mat3 {
+	rotation := rb.Rotation.Normalize().Mat4().Mat3()
+	return rotation.Mul3(mgl32.Diag3(rb.localInertia())).Mul3(rotation.Transpose())
+}
+
+// inverseMass возвращает обратную массу; у статических и кинематических тел она нулевая
+func (rb *RigidBody) inverseMass() float32 {
+	if rb.Type != Dynamic || rb.Mass <= 0 {
+		return 0
+	}
+	return 1 / rb.Mass
+}
+
+// inverseInertia возвращает обратный тензор инерции в мировых осях
+func (rb *RigidBody) inverseInertia() mgl32.Mat3 {
+	if rb.Type != Dynamic || rb.Mass <= 0 {
+		return mgl32.Mat3{}
+	}
+
+	inverse := rb.invInertiaLocal
+	if !rb.inertiaCached {
+		inverse = rb.localInverseInertia()
+	}
+	rotation := rb.Rotation.Normalize().Mat4().Mat3()
+	return rotation.Mul3(mgl32.Diag3(inverse)).Mul3(rotation.Transpose())
+}
+
+// localInertia возвращает диагональ тензора инерции в локальных осях тела.
+// Вершины оболочки не преобразуются: моментам нужны только размеры формы
+func (rb *RigidBody) localInertia() mgl32.Vec3 {
+	if rb.Shape == ConvexHullShape && rb.Hull != nil {
+		c := collider{kind: colliderHull, body: rb}
+		return c.localInertia(rb.Mass)
+	}
+	c := newCollider(rb)
+	return c.localInertia(rb.Mass)
+}
+
+// localInverseInertia возвращает обратные главные моменты инерции
+func (rb *RigidBody) localInverseInertia() mgl32.Vec3 {
+	inertia := rb.localInertia()
+	var inverse mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		if inertia[i] > 1e-12 {
+			inverse[i] = 1 / inertia[i]
+		}
+	}
+	return inverse
+}
+
+// pointMass возвращает эффективную массу тела в точке мира вдоль направления dir:
+// массу, которую почувствует импульс, приложенный в этой точке
+func (rb *RigidBody) pointMass(point, dir mgl32.Vec3) float32 {
+	arm := point.Sub(rb.Position).Cross(dir)
+	k := rb.inverseMass() + rb.inverseInertia().Mul3x1(arm).Dot(arm)
+	if k <= 1e-12 {
+		return 0
+	}
+	return 1 / k
 }
 
 // GetModelMatrix возвращает матрицу модели для рендеринга
@@ -99,3 +263,10 @@ func (rb *RigidBody) GetModelMatrix() mgl32.Mat4 {
 	scale := mgl32.Scale3D(rb.Scale.X(), rb.Scale.Y(), rb.Scale.Z())
 	return translation.Mul4(rotation).Mul4(scale)
 }
+
+// GetAABB возвращает ограничивающий объем тела в мировых координатах
+// с учетом вращения. Для плоскости объем очень большой, но конечный
+func (rb *RigidBody) GetAABB() customMath.AABB {
+	c := newCollider(rb)
+	return c.aabb()
+}
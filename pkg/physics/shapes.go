@@ -0,0 +1,354 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// planeExtent размер AABB бесконечной плоскости для широкой фазы
+const planeExtent = 1e5
+
+// colliderKind геометрия коллайдера для узкой фазы.
+// Порядок важен: пары упорядочиваются по возрастанию вида
+type colliderKind int
+
+const (
+	colliderPlane colliderKind = iota
+	colliderSphere
+	colliderCapsule
+	colliderBox
+	colliderHull
+	colliderMesh // Невыпуклые виды идут последними
+	colliderHeightfield
+)
+
+// collider геометрия тела в мировых координатах на текущем шаге
+type collider struct {
+	kind        colliderKind
+	body        *RigidBody
+	center      mgl32.Vec3
+	rotation    mgl32.Mat3  // Столбцы - локальные оси в мире
+	halfExtents mgl32.Vec3  // Box
+	radius      float32     // Sphere, Capsule
+	halfHeight  float32     // Capsule: половина длины отрезка вдоль локальной Y
+	normal      mgl32.Vec3  // Plane
+	offset      float32     // Plane: normal·p для точек плоскости
+	poly        *polyhedron // Hull: вершины и грани в мире
+
+	// Mesh, Heightfield: геометрия и переходы между локальными координатами и миром
+	mesh        *TriangleMesh
+	heightfield *Heightfield
+	transform   mgl32.Mat4
+	inverse     mgl32.Mat4
+}
+
+// newCollider строит коллайдер по форме и размерам тела
+func newCollider(body *RigidBody) collider {
+	c := collider{
+		body:     body,
+		center:   body.Position,
+		rotation: body.Rotation.Normalize().Mat4().Mat3(),
+	}
+
+	switch body.Shape {
+	case SphereShape:
+		c.kind = colliderSphere
+		c.radius = body.Dimensions.X() * body.Scale.X()
+	case LiquidShape:
+		// Жидкость как сфера, но немного меньше
+		c.kind = colliderSphere
+		c.radius = body.Dimensions.X() * body.Scale.X() * 0.8
+	case CapsuleShape:
+		c.kind = colliderCapsule
+		c.radius = body.Dimensions.X() * body.Scale.X()
+		c.halfHeight = body.Dimensions.Y() * body.Scale.Y() / 2
+	case PlaneShape:
+		// Бесконечная плоскость через Position с нормалью локальной оси Y
+		c.kind = colliderPlane
+		c.normal = c.rotation.Col(1).Normalize()
+		c.offset = c.normal.Dot(body.Position)
+	case ConvexHullShape:
+		if body.Hull == nil {
+			c.setBox(body)
+			break
+		}
+		c.kind = colliderHull
+		c.poly = newHullPolyhedron(body.Hull, c.center, c.rotation, body.Scale)
+	case ModelShape:
+		if body.Mesh == nil {
+			c.setBox(body)
+			break
+		}
+		c.kind = colliderMesh
+		c.mesh = body.Mesh
+		c.setTransform(body)
+	case HeightfieldShape:
+		if body.Heightfield == nil {
+			c.setBox(body)
+			break
+		}
+		c.kind = colliderHeightfield
+		c.heightfield = body.Heightfield
+		c.setTransform(body)
+	default:
+		c.setBox(body)
+	}
+
+	return c
+}
+
+// setBox делает коллайдер ориентированным параллелепипедом по Dimensions.
+// Так же ведут себя сложные формы без заданной геометрии
+func (c *collider) setBox(body *RigidBody) {
+	c.kind = colliderBox
+	c.halfExtents = mgl32.Vec3{
+		body.Dimensions.X() * body.Scale.X() / 2,
+		body.Dimensions.Y() * body.Scale.Y() / 2,
+		body.Dimensions.Z() * body.Scale.Z() / 2,
+	}
+}
+
+// setTransform запоминает матрицу модели тела для невыпуклых форм
+func (c *collider) setTransform(body *RigidBody) {
+	c.transform = body.GetModelMatrix()
+	c.inverse = c.transform.Inv()
+}
+
+// isConcave проверяет, является ли коллайдер набором треугольников
+func (c *collider) isConcave() bool {
+	return c.kind >= colliderMesh
+}
+
+// toWorld переводит локальную точку невыпуклого коллайдера в мир
+func (c *collider) toWorld(p mgl32.Vec3) mgl32.Vec3 {
+	return c.transform.Mul4x1(p.Vec4(1)).Vec3()
+}
+
+// convexVertices возвращает вершины многогранного коллайдера
+func (c *collider) convexVertices() []mgl32.Vec3 {
+	if c.kind == colliderHull {
+		return c.poly.vertices
+	}
+	vertices := c.vertices()
+	return vertices[:]
+}
+
+// polyhedron возвращает многогранник параллелепипеда или оболочки
+func (c *collider) polyhedron() *polyhedron {
+	if c.kind == colliderHull {
+		return c.poly
+	}
+	return boxPolyhedron(c)
+}
+
+// axis возвращает локальную ось коллайдера в мире
+func (c *collider) axis(i int) mgl32.Vec3 {
+	return c.rotation.Col(i)
+}
+
+// segment возвращает концы осевого отрезка капсулы
+func (c *collider) segment() (mgl32.Vec3, mgl32.Vec3) {
+	up := c.axis(1).Mul(c.halfHeight)
+	return c.center.Sub(up), c.center.Add(up)
+}
+
+// support возвращает самую дальнюю точку коллайдера в направлении dir
+func (c *collider) support(dir mgl32.Vec3) mgl32.Vec3 {
+	switch c.kind {
+	case colliderSphere:
+		return c.center.Add(safeNormalize(dir).Mul(c.radius))
+	case colliderCapsule:
+		p0, p1 := c.segment()
+		point := p1
+		if dir.Dot(p0) > dir.Dot(p1) {
+			point = p0
+		}
+		return point.Add(safeNormalize(dir).Mul(c.radius))
+	case colliderBox:
+		point := c.center
+		for i := 0; i < 3; i++ {
+			axis := c.axis(i)
+			if dir.Dot(axis) >= 0 {
+				point = point.Add(axis.Mul(c.halfExtents[i]))
+			} else {
+				point = point.Sub(axis.Mul(c.halfExtents[i]))
+			}
+		}
+		return point
+	case colliderHull:
+		return c.poly.support(dir)
+	default:
+		// Плоскость и сетки не выпуклы в смысле support-функции
+		return c.center
+	}
+}
+
+// aabb возвращает ограничивающий объем коллайдера
+func (c *collider) aabb() customMath.AABB {
+	var half mgl32.Vec3
+
+	switch c.kind {
+	case colliderSphere:
+		half = mgl32.Vec3{c.radius, c.radius, c.radius}
+	case colliderCapsule:
+		axis := c.axis(1)
+		for i := 0; i < 3; i++ {
+			half[i] = abs32(axis[i])*c.halfHeight + c.radius
+		}
+	case colliderBox:
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				half[i] += abs32(c.rotation.At(i, j)) * c.halfExtents[j]
+			}
+		}
+	case colliderPlane:
+		// Плоскость, перпендикулярная оси, занимает по ней тонкий слой,
+		// чтобы широкая фаза не сводила ее в пары со всеми телами
+		half = mgl32.Vec3{planeExtent, planeExtent, planeExtent}
+		for i := 0; i < 3; i++ {
+			if abs32(c.normal[i]) > 0.9999 {
+				half[i] = contactMargin
+			}
+		}
+	case colliderHull:
+		lo, hi := c.poly.vertices[0], c.poly.vertices[0]
+		for _, v := range c.poly.vertices[1:] {
+			for i := 0; i < 3; i++ {
+				lo[i] = min(lo[i], v[i])
+				hi[i] = max(hi[i], v[i])
+			}
+		}
+		return customMath.NewAABB(lo, hi)
+	case colliderMesh:
+		return c.mesh.GetBounds().Transform(c.transform)
+	case colliderHeightfield:
+		return c.heightfield.bounds().Transform(c.transform)
+	}
+
+	return customMath.NewAABBFromCenter(c.center, half)
+}
+
+// localInertia возвращает диагональ тензора инерции сплошного тела
+// заданной массы в локальных осях коллайдера
+func (c *collider) localInertia(mass float32) mgl32.Vec3 {
+	switch c.kind {
+	case colliderSphere:
+		i := 0.4 * mass * c.radius * c.radius
+		return mgl32.Vec3{i, i, i}
+	case colliderCapsule:
+		// Цилиндр вдоль Y плюс две полусферы, масса делится пропорционально объему
+		r := c.radius
+		h := 2 * c.halfHeight
+		cylinder := math.Pi * r * r * h
+		sphere := 4.0 / 3.0 * math.Pi * r * r * r
+		mc := mass * cylinder / (cylinder + sphere)
+		ms := mass - mc
+
+		iy := mc*r*r/2 + ms*0.4*r*r
+		ixz := mc*(h*h/12+r*r/4) + ms*(0.4*r*r+h*h/4+3*h*r/8)
+		return mgl32.Vec3{ixz, iy, ixz}
+	case colliderBox:
+		x := 2 * c.halfExtents.X()
+		y := 2 * c.halfExtents.Y()
+		z := 2 * c.halfExtents.Z()
+		return mgl32.Vec3{
+			mass / 12 * (y*y + z*z),
+			mass / 12 * (x*x + z*z),
+			mass / 12 * (x*x + y*y),
+		}
+	case colliderHull:
+		// Приближение: параллелепипед по локальному объему оболочки
+		size := mulElem(c.body.Hull.max.Sub(c.body.Hull.min), c.body.Scale)
+		x, y, z := size.X(), size.Y(), size.Z()
+		return mgl32.Vec3{
+			mass / 12 * (y*y + z*z),
+			mass / 12 * (x*x + z*z),
+			mass / 12 * (x*x + y*y),
+		}
+	default:
+		return mgl32.Vec3{}
+	}
+}
+
+// safeNormalize нормализует вектор, возвращая ось X для нулевого
+func safeNormalize(v mgl32.Vec3) mgl32.Vec3 {
+	length := v.Len()
+	if length < 1e-8 {
+		return mgl32.Vec3{1, 0, 0}
+	}
+	return v.Mul(1 / length)
+}
+
+// abs32 модуль float32
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// clamp32 ограничивает значение отрезком
+func clamp32(x, lo, hi float32) float32 {
+	if x < lo {
+		return lo
+	}
+	if x > hi {
+		return hi
+	}
+	return x
+}
+
+// closestPointOnSegment возвращает ближайшую к point точку отрезка [a, b]
+func closestPointOnSegment(point, a, b mgl32.Vec3) mgl32.Vec3 {
+	ab := b.Sub(a)
+	lengthSq := ab.Dot(ab)
+	if lengthSq < 1e-12 {
+		return a
+	}
+	t := clamp32(point.Sub(a).Dot(ab)/lengthSq, 0, 1)
+	return a.Add(ab.Mul(t))
+}
+
+// closestPointsSegments возвращает ближайшие точки двух отрезков [p1, q1] и [p2, q2]
+func closestPointsSegments(p1, q1, p2, q2 mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	d1 := q1.Sub(p1)
+	d2 := q2.Sub(p2)
+	r := p1.Sub(p2)
+	a := d1.Dot(d1)
+	e := d2.Dot(d2)
+	f := d2.Dot(r)
+
+	const eps = 1e-12
+	var s, t float32
+
+	switch {
+	case a <= eps && e <= eps:
+		return p1, p2
+	case a <= eps:
+		t = clamp32(f/e, 0, 1)
+	default:
+		c := d1.Dot(r)
+		if e <= eps {
+			s = clamp32(-c/a, 0, 1)
+		} else {
+			b := d1.Dot(d2)
+			denom := a*e - b*b
+			if denom > eps {
+				s = clamp32((b*f-c*e)/denom, 0, 1)
+			}
+			t = (b*s + f) / e
+			if t < 0 {
+				t = 0
+				s = clamp32(-c/a, 0, 1)
+			} else if t > 1 {
+				t = 1
+				s = clamp32((b-c)/a, 0, 1)
+			}
+		}
+	}
+
+	return p1.Add(d1.Mul(s)), p2.Add(d2.Mul(t))
+}
@@ -0,0 +1,235 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
+)
+
+// Параметры засыпания по умолчанию
+const (
+	defaultSleepLinearVelocity  = 0.05 // м/с
+	defaultSleepAngularVelocity = 0.05 // рад/с
+	defaultSleepTime            = 0.5  // с
+)
+
+// islandSet разбиение динамических тел на острова: тела, связанные контактами
+// или соединениями, засыпают и просыпаются вместе. Система непересекающихся
+// множеств по индексам тел в PhysicsWorld.Bodies
+type islandSet struct {
+	parent   []int32
+	awake    []bool    // В острове есть бодрствующее тело (по корню)
+	minSleep []float32 // Наименьшее время покоя тел острова (по корню)
+}
+
+// reset создает по острову на каждое тело и запоминает индексы тел
+func (s *islandSet) reset(bodies []*RigidBody) {
+	s.parent = s.parent[:0]
+	for i, body := range bodies {
+		body.islandIndex = int32(i)
+		s.parent = append(s.parent, int32(i))
+	}
+}
+
+// find возвращает корень острова тела со сжатием путей
+func (s *islandSet) find(i int32) int32 {
+	for s.parent[i] != i {
+		s.parent[i] = s.parent[s.parent[i]]
+		i = s.parent[i]
+	}
+	return i
+}
+
+// union объединяет острова двух динамических тел. Тела вне мира
+// (islandIndex -1 после RemoveBody) пропускаются
+func (s *islandSet) union(a, b *RigidBody) {
+	if a.Type != Dynamic || b.Type != Dynamic {
+		return
+	}
+	if a.islandIndex < 0 || b.islandIndex < 0 {
+		return
+	}
+	rootA, rootB := s.find(a.islandIndex), s.find(b.islandIndex)
+	if rootA != rootB {
+		s.parent[rootB] = rootA
+	}
+}
+
+// IsSleeping сообщает, спит ли тело
+func (rb *RigidBody) IsSleeping() bool {
+	return rb.sleeping
+}
+
+// WakeUp будит тело; остров тела проснется на следующем шаге.
+// Нужно вызывать после ручного изменения Position или Velocity спящего тела
+func (rb *RigidBody) WakeUp() {
+	rb.sleepTime = 0
+	if rb.sleeping {
+		rb.sleeping = false
+		rb.wokeUp = true
+	}
+}
+
+// isAwake сообщает, может ли тело сдвинуть соседей: бодрствующее динамическое
+// или кинематическое с ненулевой скоростью
+func (rb *RigidBody) isAwake() bool {
+	switch rb.Type {
+	case Dynamic:
+		return !rb.sleeping
+	case Kinematic:
+		return rb.Velocity.LenSqr() > 0 || rb.AngularVelocity.LenSqr() > 0
+	}
+	return false
+}
+
+// wakeIslands будит острова, в которых есть бодрствующее тело, и тела,
+// которых касается движущееся кинематическое тело. Вызывается после
+// построения контактов, до решателя
+func (w *PhysicsWorld) wakeIslands() {
+	for i := range w.contacts {
+		manifold := &w.contacts[i]
+		a, b := manifold.BodyA, manifold.BodyB
+		if a.Type == Kinematic && b.Type == Dynamic && a.isAwake() {
+			w.wakeBody(b)
+			b.sleepTime = 0
+		} else if b.Type == Kinematic && a.Type == Dynamic && b.isAwake() {
+			w.wakeBody(a)
+			a.sleepTime = 0
+		}
+		w.islands.union(a, b)
+	}
+	for _, joint := range w.joints {
+		base := joint.base()
+		if !base.broken {
+			w.islands.union(base.bodyA, base.bodyB)
+		}
+	}
+
+	awake := w.islands.awake[:0]
+	for range w.Bodies {
+		awake = append(awake, false)
+	}
+	w.islands.awake = awake
+
+	for i, body := range w.Bodies {
+		if body.Type == Dynamic && !body.sleeping {
+			awake[w.islands.find(int32(i))] = true
+		}
+	}
+	for i, body := range w.Bodies {
+		if body.sleeping && awake[w.islands.find(int32(i))] {
+			w.wakeBody(body)
+		}
+	}
+}
+
+// updateSleep копит время покоя бодрствующих тел и усыпляет острова,
+// все тела которых покоились дольше SleepTime. Вызывается после интегрирования
+func (w *PhysicsWorld) updateSleep(dt float32) {
+	linear := w.SleepLinearVelocity * w.SleepLinearVelocity
+	angular := w.SleepAngularVelocity * w.SleepAngularVelocity
+
+	minSleep := w.islands.minSleep[:0]
+	for range w.Bodies {
+		minSleep = append(minSleep, w.SleepTime)
+	}
+	w.islands.minSleep = minSleep
+
+	for i, body := range w.Bodies {
+		if body.Type != Dynamic || body.sleeping {
+			continue
+		}
+
+		if !w.AllowSleep || !body.AllowSleep ||
+			body.Velocity.LenSqr() > linear || body.AngularVelocity.LenSqr() > angular {
+			body.sleepTime = 0
+		} else {
+			body.sleepTime += dt
+		}
+
+		root := w.islands.find(int32(i))
+		if body.sleepTime < minSleep[root] {
+			minSleep[root] = body.sleepTime
+		}
+	}
+
+	if !w.AllowSleep {
+		return
+	}
+	for i, body := range w.Bodies {
+		if body.Type != Dynamic || body.sleeping {
+			continue
+		}
+		if minSleep[w.islands.find(int32(i))] >= w.SleepTime {
+			body.sleeping = true
+			body.Velocity = mgl32.Vec3{}
+			body.AngularVelocity = mgl32.Vec3{}
+			w.queueBodyEvent(event.EventBodySleep, body)
+		}
+	}
+}
+
+// collectManualWakes отправляет события телам, разбуженным вызовом WakeUp
+// или приложенной силой между шагами
+func (w *PhysicsWorld) collectManualWakes() {
+	for _, body := range w.Bodies {
+		if body.wokeUp {
+			body.wokeUp = false
+			w.queueBodyEvent(event.EventBodyWake, body)
+		}
+	}
+}
+
+// wakeBody будит тело внутри шага
+func (w *PhysicsWorld) wakeBody(body *RigidBody) {
+	if !body.sleeping {
+		return
+	}
+	body.sleeping = false
+	body.sleepTime = 0
+	w.queueBodyEvent(event.EventBodyWake, body)
+}
+
+// wakeTouching будит спящие тела, AABB которых пересекает AABB тела.
+// Используется, когда тело исчезает и соседи теряют опору
+func (w *PhysicsWorld) wakeTouching(body *RigidBody) {
+	w.broadPhase.Query(body.GetAABB(), func(other *RigidBody) bool {
+		other.WakeUp()
+		return true
+	})
+}
+
+// activeJoints возвращает соединения, у которых есть бодрствующее тело
+func (w *PhysicsWorld) activeJoints() []Joint {
+	w.awakeJoints = w.awakeJoints[:0]
+	for _, joint := range w.joints {
+		base := joint.base()
+		if base.bodyA.isAwake() || base.bodyB.isAwake() {
+			w.awakeJoints = append(w.awakeJoints, joint)
+		}
+	}
+	return w.awakeJoints
+}
+
+// queueBodyEvent откладывает событие сна до конца шага
+func (w *PhysicsWorld) queueBodyEvent(eventType event.EventType, body *RigidBody) {
+	if w.eventBus == nil {
+		return
+	}
+	w.bodyEvents = append(w.bodyEvents, event.NewEvent(eventType, &event.BodySleepData{
+		Entity: body.EntityID,
+		Body:   body,
+	}))
+}
+
+// emitBodyEvents отправляет накопленные за шаг события сна и пробуждения
+func (w *PhysicsWorld) emitBodyEvents() {
+	if len(w.bodyEvents) == 0 {
+		return
+	}
+	pending := w.bodyEvents
+	w.bodyEvents = nil
+	for _, e := range pending {
+		w.eventBus.EmitSync(e)
+	}
+}
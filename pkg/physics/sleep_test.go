@@ -0,0 +1,36 @@
+package physics
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TestRemoveJointedBody проверяет, что шаг после удаления тела с соединением
+// не обращается к его старому острову, а второе тело просыпается
+func TestRemoveJointedBody(t *testing.T) {
+	world := NewPhysicsWorld()
+	a := world.AddBody(NewRigidBody(Dynamic, BoxShape))
+	b := world.AddBody(NewRigidBody(Dynamic, BoxShape))
+	b.Position = mgl32.Vec3{0, 2, 0}
+	world.AddJoint(NewBallJoint(a, b, mgl32.Vec3{0, 1, 0}))
+
+	// Даем телам уснуть, чтобы проверить пробуждение
+	a.UseGravity, b.UseGravity = false, false
+	for i := 0; i < 120 && !a.IsSleeping(); i++ {
+		world.Step(1.0 / 60.0)
+	}
+	if !a.IsSleeping() {
+		t.Fatal("тела не уснули")
+	}
+
+	world.RemoveBody(b)
+	world.Step(1.0 / 60.0)
+
+	if len(world.GetJoints()) != 0 {
+		t.Fatalf("после RemoveBody осталось соединений: %d", len(world.GetJoints()))
+	}
+	if a.IsSleeping() {
+		t.Fatal("второе тело соединения не проснулось")
+	}
+}
@@ -0,0 +1,329 @@
+// Package softbody ткань и мягкие тела на позиционной динамике (XPBD),
+// сталкивающиеся с телами physics.PhysicsWorld
+package softbody
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/physics"
+)
+
+// Ошибки построения мягких тел
+var (
+	// ErrInvalidIndices индексы не образуют треугольники из вершин сетки
+	ErrInvalidIndices = errors.New("invalid soft body mesh indices")
+	// ErrOpenMesh сетка объемного тела не замкнута: ребро принадлежит не двум треугольникам
+	ErrOpenMesh = errors.New("soft body mesh is not closed")
+)
+
+// Body ткань или мягкое тело: вершины сетки, связанные ограничениями.
+// Positions и Indices можно каждый кадр передавать в буферы отрисовки
+type Body struct {
+	Positions  []mgl32.Vec3 // Вершины в мировых координатах в порядке исходной сетки
+	Velocities []mgl32.Vec3
+	Indices    []uint32 // Треугольники сетки, по три индекса
+
+	// Материал. Податливость - величина, обратная жесткости; 0 - абсолютно жесткая связь
+	StretchCompliance float32 // Податливость растяжения ребер
+	BendCompliance    float32 // Податливость изгиба по соседним треугольникам
+	VolumeCompliance  float32 // Податливость объема замкнутого тела
+	Pressure          float32 // Доля исходного объема, которую держит замкнутое тело
+	Damping           float32 // Затухание скорости вершин, 1/с
+
+	// Столкновения с телами мира
+	Thickness     float32 // Радиус вершины при столкновениях
+	Friction      float32 // Трение о тела (0-1)
+	CollisionMask uint32  // Слои тел, с которыми сталкивается мягкое тело
+
+	Mass float32 // Масса всего тела, делится поровну между вершинами
+
+	inverseMasses []float32 // 0 у закрепленных вершин
+	previous      []mgl32.Vec3
+	normals       []mgl32.Vec3
+	gradients     []mgl32.Vec3 // Градиенты объема по вершинам
+
+	stretch     []distanceConstraint
+	bend        []distanceConstraint
+	attachments []attachment
+	closed      bool    // Замкнутая поверхность с ограничением объема
+	restVolume  float32 // Объем замкнутого тела при создании
+}
+
+// attachment вершина, прикрепленная к точке твердого тела
+type attachment struct {
+	vertex   int
+	body     *physics.RigidBody
+	local    mgl32.Vec3 // Точка в системе координат тела
+	previous mgl32.Vec3 // Точка крепления на прошлом шаге
+}
+
+// NewCloth создает прямоугольную ткань rows×cols вершин. Вершина (r, c) лежит
+// в origin + right·c/(cols-1) + down·r/(rows-1) и имеет индекс r·cols + c,
+// поэтому верхние углы - вершины 0 и cols-1
+func NewCloth(origin, right, down mgl32.Vec3, rows, cols int, mass float32) *Body {
+	rows, cols = max(rows, 2), max(cols, 2)
+	vertices := make([]mgl32.Vec3, 0, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			u := float32(c) / float32(cols-1)
+			v := float32(r) / float32(rows-1)
+			vertices = append(vertices, origin.Add(right.Mul(u)).Add(down.Mul(v)))
+		}
+	}
+
+	// Диагонали чередуются, чтобы ткань не складывалась в одну сторону
+	indices := make([]uint32, 0, (rows-1)*(cols-1)*6)
+	for r := 0; r < rows-1; r++ {
+		for c := 0; c < cols-1; c++ {
+			a := uint32(r*cols + c)
+			b, d, e := a+1, a+uint32(cols), a+uint32(cols)+1
+			if (r+c)%2 == 0 {
+				indices = append(indices, a, d, e, a, e, b)
+			} else {
+				indices = append(indices, a, d, b, b, d, e)
+			}
+		}
+	}
+
+	body := newBody(vertices, indices, mass)
+	body.buildConstraints()
+	return body
+}
+
+// NewClothMesh создает ткань по произвольной треугольной сетке
+func NewClothMesh(vertices []mgl32.Vec3, indices []uint32, mass float32) (*Body, error) {
+	if err := validateMesh(vertices, indices); err != nil {
+		return nil, err
+	}
+	body := newBody(vertices, indices, mass)
+	body.buildConstraints()
+	return body, nil
+}
+
+// NewSoftBody создает объемное мягкое тело по замкнутой треугольной сетке с
+// треугольниками против часовой стрелки снаружи. Кроме ребер и изгиба тело
+// держит объем, как надутая оболочка. Каждое ребро должно принадлежать ровно
+// двум треугольникам
+func NewSoftBody(vertices []mgl32.Vec3, indices []uint32, mass float32) (*Body, error) {
+	if err := validateMesh(vertices, indices); err != nil {
+		return nil, err
+	}
+	body := newBody(vertices, indices, mass)
+	if !body.buildConstraints() {
+		return nil, ErrOpenMesh
+	}
+	body.closed = true
+	body.restVolume = body.Volume()
+	return body, nil
+}
+
+// NewSoftSphere создает мягкую сферу из икосаэдра, каждая грань которого
+// разбита subdivisions раз
+func NewSoftSphere(center mgl32.Vec3, radius float32, subdivisions int, mass float32) *Body {
+	vertices, indices := icosphere(subdivisions)
+	for i := range vertices {
+		vertices[i] = center.Add(vertices[i].Mul(radius))
+	}
+	body, err := NewSoftBody(vertices, indices, mass)
+	if err != nil {
+		// Икосфера всегда замкнута
+		panic(err)
+	}
+	return body
+}
+
+// newBody копирует сетку и задает материал по умолчанию
+func newBody(vertices []mgl32.Vec3, indices []uint32, mass float32) *Body {
+	n := len(vertices)
+	body := &Body{
+		Positions:  append([]mgl32.Vec3(nil), vertices...),
+		Velocities: make([]mgl32.Vec3, n),
+		Indices:    append([]uint32(nil), indices...),
+
+		BendCompliance:   0.01,
+		VolumeCompliance: 0,
+		Pressure:         1,
+		Damping:          0.1,
+
+		Thickness:     0.02,
+		Friction:      0.3,
+		CollisionMask: physics.AllLayers,
+
+		Mass: mass,
+
+		inverseMasses: make([]float32, n),
+		previous:      make([]mgl32.Vec3, n),
+		normals:       make([]mgl32.Vec3, n),
+		gradients:     make([]mgl32.Vec3, n),
+	}
+	inverse := float32(0)
+	if mass > 0 {
+		inverse = float32(n) / mass
+	}
+	for i := range body.inverseMasses {
+		body.inverseMasses[i] = inverse
+	}
+	return body
+}
+
+// validateMesh проверяет, что индексы образуют треугольники из существующих вершин
+func validateMesh(vertices []mgl32.Vec3, indices []uint32) error {
+	if len(indices) == 0 || len(indices)%3 != 0 {
+		return fmt.Errorf("%w: index count %d is not a multiple of 3", ErrInvalidIndices, len(indices))
+	}
+	for _, index := range indices {
+		if int(index) >= len(vertices) {
+			return fmt.Errorf("%w: index %d out of range", ErrInvalidIndices, index)
+		}
+	}
+	return nil
+}
+
+// Pin закрепляет вершину на месте: она перестает двигаться, пока ее не
+// переставят вручную через Positions или не вызовут Unpin
+func (b *Body) Pin(vertex int) {
+	b.inverseMasses[vertex] = 0
+	b.Velocities[vertex] = mgl32.Vec3{}
+}
+
+// Unpin освобождает закрепленную или прикрепленную к телу вершину
+func (b *Body) Unpin(vertex int) {
+	if b.Mass > 0 {
+		b.inverseMasses[vertex] = float32(len(b.Positions)) / b.Mass
+	}
+	for i, a := range b.attachments {
+		if a.vertex == vertex {
+			b.attachments = append(b.attachments[:i], b.attachments[i+1:]...)
+			break
+		}
+	}
+}
+
+// IsPinned проверяет, закреплена ли вершина
+func (b *Body) IsPinned(vertex int) bool {
+	return b.inverseMasses[vertex] == 0
+}
+
+// Attach прикрепляет вершину к твердому телу в ее текущем положении:
+// вершина повторяет движение тела, тело не чувствует ткани
+func (b *Body) Attach(vertex int, body *physics.RigidBody) {
+	b.Unpin(vertex)
+	b.Pin(vertex)
+	inverse := body.Rotation.Normalize().Inverse()
+	local := inverse.Rotate(b.Positions[vertex].Sub(body.Position))
+	b.attachments = append(b.attachments, attachment{
+		vertex:   vertex,
+		body:     body,
+		local:    local,
+		previous: b.Positions[vertex],
+	})
+}
+
+// IsClosed проверяет, держит ли тело объем
+func (b *Body) IsClosed() bool {
+	return b.closed
+}
+
+// Volume возвращает объем, ограниченный сеткой (для замкнутых тел)
+func (b *Body) Volume() float32 {
+	volume := float32(0)
+	for t := 0; t+2 < len(b.Indices); t += 3 {
+		p0 := b.Positions[b.Indices[t]]
+		p1 := b.Positions[b.Indices[t+1]]
+		p2 := b.Positions[b.Indices[t+2]]
+		volume += p0.Cross(p1).Dot(p2)
+	}
+	return volume / 6
+}
+
+// Normals возвращает нормали вершин, усредненные по площадям треугольников.
+// Срез переиспользуется между вызовами
+func (b *Body) Normals() []mgl32.Vec3 {
+	clear(b.normals)
+	for t := 0; t+2 < len(b.Indices); t += 3 {
+		i0, i1, i2 := b.Indices[t], b.Indices[t+1], b.Indices[t+2]
+		p0 := b.Positions[i0]
+		normal := b.Positions[i1].Sub(p0).Cross(b.Positions[i2].Sub(p0))
+		b.normals[i0] = b.normals[i0].Add(normal)
+		b.normals[i1] = b.normals[i1].Add(normal)
+		b.normals[i2] = b.normals[i2].Add(normal)
+	}
+	for i, normal := range b.normals {
+		if length := normal.Len(); length > 1e-12 {
+			b.normals[i] = normal.Mul(1 / length)
+		}
+	}
+	return b.normals
+}
+
+// AppendVertices дописывает в dst вершины для буфера отрисовки: позиция и нормаль
+// подряд, по шесть float32 на вершину в порядке Positions. Треугольники задает Indices
+func (b *Body) AppendVertices(dst []float32) []float32 {
+	normals := b.Normals()
+	for i, p := range b.Positions {
+		n := normals[i]
+		dst = append(dst, p.X(), p.Y(), p.Z(), n.X(), n.Y(), n.Z())
+	}
+	return dst
+}
+
+// GetAABB возвращает границы вершин, расширенные на Thickness
+func (b *Body) GetAABB() (mgl32.Vec3, mgl32.Vec3) {
+	inf := float32(math.Inf(1))
+	lo := mgl32.Vec3{inf, inf, inf}
+	hi := lo.Mul(-1)
+	for _, p := range b.Positions {
+		for axis := 0; axis < 3; axis++ {
+			lo[axis] = min(lo[axis], p[axis])
+			hi[axis] = max(hi[axis], p[axis])
+		}
+	}
+	margin := mgl32.Vec3{b.Thickness, b.Thickness, b.Thickness}
+	return lo.Sub(margin), hi.Add(margin)
+}
+
+// icosphere строит единичную сферу делением граней икосаэдра
+func icosphere(subdivisions int) ([]mgl32.Vec3, []uint32) {
+	t := float32((1 + math.Sqrt(5)) / 2)
+	vertices := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range vertices {
+		vertices[i] = vertices[i].Normalize()
+	}
+	indices := []uint32{
+		0, 11, 5, 0, 5, 1, 0, 1, 7, 0, 7, 10, 0, 10, 11,
+		1, 5, 9, 5, 11, 4, 11, 10, 2, 10, 7, 6, 7, 1, 8,
+		3, 9, 4, 3, 4, 2, 3, 2, 6, 3, 6, 8, 3, 8, 9,
+		4, 9, 5, 2, 4, 11, 6, 2, 10, 8, 6, 7, 9, 8, 1,
+	}
+
+	for s := 0; s < subdivisions; s++ {
+		midpoints := make(map[edge]uint32)
+		midpoint := func(a, b uint32) uint32 {
+			key := newEdge(a, b)
+			if index, ok := midpoints[key]; ok {
+				return index
+			}
+			index := uint32(len(vertices))
+			vertices = append(vertices, vertices[a].Add(vertices[b]).Normalize())
+			midpoints[key] = index
+			return index
+		}
+
+		next := make([]uint32, 0, len(indices)*4)
+		for t := 0; t < len(indices); t += 3 {
+			a, b, c := indices[t], indices[t+1], indices[t+2]
+			ab, bc, ca := midpoint(a, b), midpoint(b, c), midpoint(c, a)
+			next = append(next, a, ab, ca, b, bc, ab, c, ca, bc, ab, bc, ca)
+		}
+		indices = next
+	}
+	return vertices, indices
+}
@@ -0,0 +1,81 @@
+package softbody
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/physics"
+)
+
+// TestClothPinnedBounded проверяет, что ткань, закрепленная за верхние углы,
+// провисает под гравитацией и на каждом шаге остается в пределах своих размеров
+func TestClothPinnedBounded(t *testing.T) {
+	origin := mgl32.Vec3{-1, 3, 0}
+	cloth := NewCloth(origin, mgl32.Vec3{2, 0, 0}, mgl32.Vec3{0, 0, 2}, 11, 11, 1)
+	cloth.Pin(0)
+	cloth.Pin(10)
+
+	system := NewSystem(nil)
+	system.AddBody(cloth)
+	for step := 0; step < 300; step++ {
+		system.Step(1.0 / 60.0)
+
+		// Ткань 2×2 м не уходит от углов дальше своей диагонали
+		for i, p := range cloth.Positions {
+			if p.Sub(origin).Len() > 2.9 || math.IsNaN(float64(p.Len())) {
+				t.Fatalf("шаг %d: вершина %d вне ткани: %v", step, i, p)
+			}
+		}
+	}
+
+	if cloth.Positions[0] != origin || cloth.Positions[10] != origin.Add(mgl32.Vec3{2, 0, 0}) {
+		t.Fatal("закрепленные вершины сдвинулись")
+	}
+	if bottom := cloth.Positions[len(cloth.Positions)-1]; bottom.Y() > origin.Y()-1 {
+		t.Fatalf("ткань не провисла: нижний угол %v", bottom)
+	}
+}
+
+// TestSoftSphereRestsOnPlane проверяет, что мягкая сфера ложится на плоскость
+// и сохраняет объем около исходного
+func TestSoftSphereRestsOnPlane(t *testing.T) {
+	world := physics.NewPhysicsWorld()
+	world.AddBody(physics.NewPlaneBody(mgl32.Vec3{}, mgl32.Vec3{0, 1, 0}))
+
+	sphere := NewSoftSphere(mgl32.Vec3{0, 1, 0}, 0.5, 2, 1)
+	system := NewSystem(world)
+	system.AddBody(sphere)
+	for i := 0; i < 300; i++ {
+		world.Step(1.0 / 60.0)
+		system.Step(1.0 / 60.0)
+	}
+
+	min, max := sphere.GetAABB()
+	if min.Y() < -sphere.Thickness-0.01 {
+		t.Fatalf("сфера провалилась под плоскость: низ %v", min.Y())
+	}
+	if max.Y() > 1.2 {
+		t.Fatalf("сфера не упала на плоскость: верх %v", max.Y())
+	}
+	volume := sphere.Volume()
+	if math.Abs(float64(volume-sphere.restVolume)) > 0.02*float64(sphere.restVolume) {
+		t.Fatalf("объем %v, исходный %v", volume, sphere.restVolume)
+	}
+}
+
+// TestSoftBodyMeshErrors проверяет ошибки построения по неверным сеткам
+func TestSoftBodyMeshErrors(t *testing.T) {
+	vertices := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	if _, err := NewClothMesh(vertices, []uint32{0, 1}, 1); !errors.Is(err, ErrInvalidIndices) {
+		t.Fatalf("неполный треугольник: %v", err)
+	}
+	if _, err := NewClothMesh(vertices, []uint32{0, 1, 3}, 1); !errors.Is(err, ErrInvalidIndices) {
+		t.Fatalf("индекс вне сетки: %v", err)
+	}
+	if _, err := NewSoftBody(vertices, []uint32{0, 1, 2}, 1); !errors.Is(err, ErrOpenMesh) {
+		t.Fatalf("открытая сетка: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package softbody
+
+// edge ребро сетки с упорядоченными вершинами
+type edge struct {
+	a, b uint32
+}
+
+// newEdge возвращает ребро с меньшей вершиной первой
+func newEdge(a, b uint32) edge {
+	if a > b {
+		a, b = b, a
+	}
+	return edge{a, b}
+}
+
+// distanceConstraint связь двух вершин, держащая длину покоя
+type distanceConstraint struct {
+	a, b   int
+	length float32
+}
+
+// buildConstraints строит связи растяжения по ребрам и изгиба между
+// противоположными вершинами соседних треугольников. Связи идут в порядке
+// треугольников, поэтому решение воспроизводимо. Возвращает true, если каждое
+// ребро принадлежит ровно двум треугольникам (сетка замкнута)
+func (b *Body) buildConstraints() bool {
+	type edgeInfo struct {
+		opposite uint32 // Противоположная вершина первого треугольника ребра
+		count    int
+	}
+	edges := make(map[edge]*edgeInfo)
+	b.stretch = b.stretch[:0]
+	b.bend = b.bend[:0]
+
+	for t := 0; t+2 < len(b.Indices); t += 3 {
+		triangle := [3]uint32{b.Indices[t], b.Indices[t+1], b.Indices[t+2]}
+		for i := 0; i < 3; i++ {
+			v0, v1, opposite := triangle[i], triangle[(i+1)%3], triangle[(i+2)%3]
+			key := newEdge(v0, v1)
+			info, ok := edges[key]
+			if !ok {
+				edges[key] = &edgeInfo{opposite: opposite, count: 1}
+				b.stretch = append(b.stretch, b.newDistance(int(v0), int(v1)))
+				continue
+			}
+			info.count++
+			if info.count == 2 && info.opposite != opposite {
+				b.bend = append(b.bend, b.newDistance(int(info.opposite), int(opposite)))
+			}
+		}
+	}
+
+	for _, info := range edges {
+		if info.count != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// newDistance создает связь с длиной покоя по текущим положениям
+func (b *Body) newDistance(a, c int) distanceConstraint {
+	return distanceConstraint{a: a, b: c, length: b.Positions[a].Sub(b.Positions[c]).Len()}
+}
+
+// solveDistances проецирует связи длины (XPBD с одной итерацией на подшаг)
+func (b *Body) solveDistances(constraints []distanceConstraint, compliance, dt float32) {
+	alpha := compliance / (dt * dt)
+	for _, c := range constraints {
+		wa, wb := b.inverseMasses[c.a], b.inverseMasses[c.b]
+		w := wa + wb
+		if w == 0 {
+			continue
+		}
+		diff := b.Positions[c.a].Sub(b.Positions[c.b])
+		length := diff.Len()
+		if length < 1e-9 {
+			continue
+		}
+		lambda := -(length - c.length) / (w + alpha)
+		correction := diff.Mul(lambda / length)
+		b.Positions[c.a] = b.Positions[c.a].Add(correction.Mul(wa))
+		b.Positions[c.b] = b.Positions[c.b].Sub(correction.Mul(wb))
+	}
+}
+
+// solveVolume проецирует ограничение объема замкнутой сетки: все вершины
+// сдвигаются вдоль градиента объема, пока он не станет Pressure·restVolume
+func (b *Body) solveVolume(dt float32) {
+	gradients := b.gradients
+	clear(gradients)
+	volume := float32(0)
+	for t := 0; t+2 < len(b.Indices); t += 3 {
+		i0, i1, i2 := b.Indices[t], b.Indices[t+1], b.Indices[t+2]
+		p0, p1, p2 := b.Positions[i0], b.Positions[i1], b.Positions[i2]
+		volume += p0.Cross(p1).Dot(p2)
+		gradients[i0] = gradients[i0].Add(p1.Cross(p2))
+		gradients[i1] = gradients[i1].Add(p2.Cross(p0))
+		gradients[i2] = gradients[i2].Add(p0.Cross(p1))
+	}
+	volume /= 6
+
+	w := float32(0)
+	for i, gradient := range gradients {
+		gradients[i] = gradient.Mul(1.0 / 6)
+		w += b.inverseMasses[i] * gradients[i].Dot(gradients[i])
+	}
+	alpha := b.VolumeCompliance / (dt * dt)
+	if w+alpha == 0 {
+		return
+	}
+
+	lambda := -(volume - b.Pressure*b.restVolume) / (w + alpha)
+	for i, gradient := range gradients {
+		b.Positions[i] = b.Positions[i].Add(gradient.Mul(lambda * b.inverseMasses[i]))
+	}
+}
@@ -0,0 +1,184 @@
+package softbody
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/physics"
+)
+
+// System мягкие тела, сталкивающиеся с телами мира. Step вызывается после
+// PhysicsWorld.Step: твердые тела для мягких - препятствия в их новом положении
+type System struct {
+	Bodies   []*Body
+	Gravity  mgl32.Vec3
+	Substeps int // Подшаги за Step: больше - жестче связи и точнее столкновения
+
+	world *physics.PhysicsWorld
+}
+
+// NewSystem создает систему мягких тел с гравитацией мира. world может быть nil:
+// тогда мягкие тела ни с чем не сталкиваются
+func NewSystem(world *physics.PhysicsWorld) *System {
+	gravity := mgl32.Vec3{0, -9.81, 0}
+	if world != nil {
+		gravity = world.Gravity
+	}
+	return &System{
+		Bodies:   make([]*Body, 0),
+		Gravity:  gravity,
+		Substeps: 10,
+		world:    world,
+	}
+}
+
+// AddBody добавляет мягкое тело
+func (s *System) AddBody(body *Body) *Body {
+	s.Bodies = append(s.Bodies, body)
+	return body
+}
+
+// RemoveBody удаляет мягкое тело, сохраняя порядок остальных
+func (s *System) RemoveBody(body *Body) {
+	for i, b := range s.Bodies {
+		if b == body {
+			s.Bodies = append(s.Bodies[:i], s.Bodies[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetWorld возвращает мир, с телами которого сталкиваются мягкие тела
+func (s *System) GetWorld() *physics.PhysicsWorld {
+	return s.world
+}
+
+// Step продвигает мягкие тела на dt. Каждый подшаг XPBD предсказывает положения,
+// по одному разу проецирует растяжение, изгиб и объем, выталкивает вершины
+// из тел мира и берет скорости из смещений
+func (s *System) Step(dt float32) {
+	if dt <= 0 {
+		return
+	}
+	substeps := max(s.Substeps, 1)
+	h := dt / float32(substeps)
+
+	for _, body := range s.Bodies {
+		obstacles := s.findObstacles(body, dt)
+		for step := 0; step < substeps; step++ {
+			body.integrate(s.Gravity, h)
+			body.moveAttachments(float32(step+1) / float32(substeps))
+			body.solveDistances(body.stretch, body.StretchCompliance, h)
+			body.solveDistances(body.bend, body.BendCompliance, h)
+			if body.closed {
+				body.solveVolume(h)
+			}
+			s.collide(body, obstacles, h)
+			body.updateVelocities(h)
+		}
+		for i := range body.attachments {
+			body.attachments[i].previous = body.attachments[i].target()
+		}
+	}
+}
+
+// findObstacles отбирает тела мира рядом с мягким телом с запасом на движение за шаг
+func (s *System) findObstacles(body *Body, dt float32) []*physics.RigidBody {
+	if s.world == nil {
+		return nil
+	}
+
+	speed := float32(0)
+	for _, v := range body.Velocities {
+		speed = max(speed, v.Len())
+	}
+	margin := speed*dt + s.Gravity.Len()*dt*dt
+	lo, hi := body.GetAABB()
+	lo = lo.Sub(mgl32.Vec3{margin, margin, margin})
+	hi = hi.Add(mgl32.Vec3{margin, margin, margin})
+
+	mask := body.CollisionMask
+	return s.world.OverlapBox(lo.Add(hi).Mul(0.5), hi.Sub(lo).Mul(0.5), mgl32.QuatIdent(), func(rb *physics.RigidBody) bool {
+		return rb.Layer&mask != 0 && !rb.IsTrigger && rb.Shape != physics.LiquidShape
+	})
+}
+
+// collide выталкивает вершины из тел мира и гасит их касательное смещение
+// трением. Динамические тела получают импульс вершин, которые в них врезались
+func (s *System) collide(body *Body, obstacles []*physics.RigidBody, h float32) {
+	vertexMass := float32(0)
+	if n := len(body.Positions); n > 0 {
+		vertexMass = body.Mass / float32(n)
+	}
+
+	for _, obstacle := range obstacles {
+		physics.CollideSpheres(body.Positions, body.Thickness, obstacle, func(i int, manifold physics.ContactManifold) {
+			if body.inverseMasses[i] == 0 {
+				return
+			}
+			depth := manifold.MaxDepth()
+			if depth <= 0 {
+				return
+			}
+
+			// Нормаль направлена от вершины к телу
+			normal := manifold.Normal
+			position := body.Positions[i]
+			surfaceVelocity := obstacle.GetPointVelocity(position)
+			approach := position.Sub(body.previous[i]).Mul(1 / h).Sub(surfaceVelocity).Dot(normal)
+			position = position.Sub(normal.Mul(depth))
+
+			// Трение: касательный сдвиг относительно поверхности гасится тем сильнее,
+			// чем глубже вершина вошла в тело
+			slide := position.Sub(body.previous[i]).Sub(surfaceVelocity.Mul(h))
+			tangent := slide.Sub(normal.Mul(slide.Dot(normal)))
+			if length := tangent.Len(); length > 1e-9 {
+				position = position.Sub(tangent.Mul(min(body.Friction*depth/length, 1)))
+			}
+			body.Positions[i] = position
+
+			if obstacle.Type != physics.Dynamic || approach <= 0 {
+				return
+			}
+			impulse := normal.Mul(vertexMass * approach)
+			if obstacle.IsSleeping() && s.world != nil && impulse.Len() < obstacle.Mass*s.world.SleepLinearVelocity {
+				// Спящее тело будит только заметный удар
+				return
+			}
+			obstacle.ApplyImpulseAtPoint(impulse, position)
+		})
+	}
+}
+
+// integrate предсказывает положения свободных вершин под действием гравитации
+func (b *Body) integrate(gravity mgl32.Vec3, h float32) {
+	damping := max(1-b.Damping*h, 0)
+	for i, w := range b.inverseMasses {
+		b.previous[i] = b.Positions[i]
+		if w == 0 {
+			continue
+		}
+		b.Velocities[i] = b.Velocities[i].Add(gravity.Mul(h)).Mul(damping)
+		b.Positions[i] = b.Positions[i].Add(b.Velocities[i].Mul(h))
+	}
+}
+
+// moveAttachments ставит прикрепленные вершины между положением на прошлом
+// шаге и текущим положением тела, чтобы ткань не дергалась на подшагах
+func (b *Body) moveAttachments(fraction float32) {
+	for _, a := range b.attachments {
+		target := a.target()
+		b.Positions[a.vertex] = a.previous.Add(target.Sub(a.previous).Mul(fraction))
+	}
+}
+
+// updateVelocities берет скорости вершин из смещения за подшаг
+func (b *Body) updateVelocities(h float32) {
+	for i := range b.Positions {
+		b.Velocities[i] = b.Positions[i].Sub(b.previous[i]).Mul(1 / h)
+	}
+}
+
+// target возвращает текущую точку крепления в мировых координатах
+func (a *attachment) target() mgl32.Vec3 {
+	return a.body.Position.Add(a.body.Rotation.Normalize().Rotate(a.local))
+}
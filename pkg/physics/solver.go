@@ -0,0 +1,364 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Параметры решателя контактов
+const (
+	contactSlop          = 0.005 // Допустимое проникновение, которое не исправляется
+	contactBaumgarte     = 0.2   // Доля проникновения, устраняемая за шаг
+	restitutionThreshold = 1.0   // Скорость удара, ниже которой отскок не применяется
+	warmStartDistance    = 0.05  // Радиус сопоставления точек контакта между шагами
+)
+
+// contactKey идентифицирует пару тел между шагами. feature различает
+// несколько манифолдов одной пары (с разными группами треугольников сетки)
+type contactKey struct {
+	a, b    int
+	feature int32
+}
+
+// cachedImpulse накопленные импульсы точки контакта с прошлого шага
+type cachedImpulse struct {
+	local    mgl32.Vec3 // Точка в локальных координатах тела A
+	normal   float32
+	tangent1 float32
+	tangent2 float32
+}
+
+// cachedManifold импульсы всех точек манифолда пары
+type cachedManifold struct {
+	points [maxManifoldPoints]cachedImpulse
+	count  int
+}
+
+// solverPoint точка контакта, подготовленная для решателя
+type solverPoint struct {
+	rA, rB mgl32.Vec3 // Плечи от центров тел
+	local  mgl32.Vec3 // Точка в локальных координатах тела A
+	depth  float32
+
+	normalMass   float32
+	tangentMass1 float32
+	tangentMass2 float32
+	velocityBias float32 // Целевая скорость расхождения при отскоке
+
+	normalImpulse   float32
+	tangentImpulse1 float32
+	tangentImpulse2 float32
+	pseudoImpulse   float32
+}
+
+// contactConstraint ограничение непроникновения для пары тел
+type contactConstraint struct {
+	bodyA, bodyB *RigidBody
+	key          contactKey
+
+	invMassA, invMassB       float32
+	invInertiaA, invInertiaB mgl32.Mat3
+
+	normal   mgl32.Vec3
+	tangent1 mgl32.Vec3
+	tangent2 mgl32.Vec3
+
+	friction    float32
+	restitution float32
+
+	points [maxManifoldPoints]solverPoint
+	count  int
+}
+
+// constraintSolver решатель контактов и соединений последовательными импульсами.
+// Скорости решаются итерациями Гаусса-Зейделя с трением Кулона и отскоком,
+// проникновение устраняется отдельными псевдоскоростями, не добавляющими энергии
+type constraintSolver struct {
+	constraints []contactConstraint
+	joints      []*jointBase
+	cache       map[contactKey]cachedManifold
+	nextCache   map[contactKey]cachedManifold
+}
+
+// newConstraintSolver создает решатель
+func newConstraintSolver() *constraintSolver {
+	return &constraintSolver{
+		cache:     make(map[contactKey]cachedManifold),
+		nextCache: make(map[contactKey]cachedManifold),
+	}
+}
+
+// solve разрешает контакты и соединения шага: подготовка, прогрев накопленными
+// импульсами, итерации по скоростям и по положению
+func (s *constraintSolver) solve(contacts []ContactManifold, joints []Joint, dt float32, velocityIterations, positionIterations int, warmStarting bool) {
+	s.prepare(contacts, dt, warmStarting)
+	s.prepareJoints(joints, dt, warmStarting)
+
+	if warmStarting {
+		for _, joint := range s.joints {
+			joint.warmStart()
+		}
+		s.warmStart()
+	}
+	for i := 0; i < velocityIterations; i++ {
+		for _, joint := range s.joints {
+			joint.solveVelocities()
+		}
+		s.solveVelocities(i%2 == 1)
+	}
+	for i := 0; i < positionIterations; i++ {
+		for _, joint := range s.joints {
+			joint.solvePositions(dt)
+		}
+		s.solvePositions(dt)
+	}
+
+	s.storeImpulses()
+	for _, joint := range s.joints {
+		joint.end(dt)
+	}
+}
+
+// prepareJoints строит строки неразрушенных соединений
+func (s *constraintSolver) prepareJoints(joints []Joint, dt float32, warmStarting bool) {
+	s.joints = s.joints[:0]
+	for _, joint := range joints {
+		if joint.IsBroken() {
+			continue
+		}
+		joint.buildRows(dt)
+		base := joint.base()
+		base.finish(warmStarting)
+		s.joints = append(s.joints, base)
+	}
+}
+
+// prepare строит ограничения по манифолдам и подхватывает импульсы прошлого шага
+func (s *constraintSolver) prepare(contacts []ContactManifold, dt float32, warmStarting bool) {
+	s.constraints = s.constraints[:0]
+
+	for i := range contacts {
+		manifold := &contacts[i]
+		if len(manifold.Points) == 0 {
+			continue
+		}
+
+		a, b := manifold.BodyA, manifold.BodyB
+		c := contactConstraint{
+			bodyA:       a,
+			bodyB:       b,
+			key:         contactKey{a.ID, b.ID, manifold.feature},
+			invMassA:    a.inverseMass(),
+			invMassB:    b.inverseMass(),
+			invInertiaA: a.inverseInertia(),
+			invInertiaB: b.inverseInertia(),
+			normal:      manifold.Normal,
+			friction:    float32(math.Sqrt(float64(a.Friction * b.Friction))),
+			restitution: float32(math.Min(float64(a.Restitution), float64(b.Restitution))),
+		}
+		if c.invMassA == 0 && c.invMassB == 0 {
+			continue
+		}
+		c.tangent1, c.tangent2 = tangentBasis(c.normal)
+
+		cached, hasCache := s.cache[c.key]
+		inverseRotationA := a.Rotation.Normalize().Conjugate()
+
+		for _, point := range manifold.Points {
+			if c.count == maxManifoldPoints {
+				break
+			}
+
+			sp := solverPoint{
+				rA:    point.Position.Sub(a.Position),
+				rB:    point.Position.Sub(b.Position),
+				depth: point.Depth,
+			}
+			sp.local = inverseRotationA.Rotate(sp.rA)
+			sp.normalMass = c.effectiveMass(sp.rA, sp.rB, c.normal)
+			sp.tangentMass1 = c.effectiveMass(sp.rA, sp.rB, c.tangent1)
+			sp.tangentMass2 = c.effectiveMass(sp.rA, sp.rB, c.tangent2)
+
+			// Точка с зазором (спекулятивный контакт) разрешает сближение ровно
+			// до касания за шаг. Отскок только для заметных ударов, иначе стопки дрожат
+			approach := c.relativeVelocity(sp.rA, sp.rB).Dot(c.normal)
+			if sp.depth < 0 {
+				sp.velocityBias = sp.depth / dt
+			} else if approach < -restitutionThreshold {
+				sp.velocityBias = -c.restitution * approach
+			}
+
+			if warmStarting && hasCache {
+				for j := 0; j < cached.count; j++ {
+					old := cached.points[j]
+					offset := old.local.Sub(sp.local)
+					if offset.Dot(offset) < warmStartDistance*warmStartDistance {
+						sp.normalImpulse = old.normal
+						sp.tangentImpulse1 = old.tangent1
+						sp.tangentImpulse2 = old.tangent2
+						break
+					}
+				}
+			}
+
+			c.points[c.count] = sp
+			c.count++
+		}
+
+		s.constraints = append(s.constraints, c)
+	}
+}
+
+// warmStart применяет импульсы, накопленные на прошлом шаге
+func (s *constraintSolver) warmStart() {
+	for i := range s.constraints {
+		c := &s.constraints[i]
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			impulse := c.normal.Mul(p.normalImpulse).
+				Add(c.tangent1.Mul(p.tangentImpulse1)).
+				Add(c.tangent2.Mul(p.tangentImpulse2))
+			c.applyImpulse(p.rA, p.rB, impulse)
+		}
+	}
+}
+
+// solveVelocities выполняет одну итерацию по скоростям: сначала трение,
+// затем непроникновение. Итерации чередуют направление обхода: при обходе
+// всегда в одном порядке погрешность копится в одну сторону и высокие стопки раскачиваются
+func (s *constraintSolver) solveVelocities(reverse bool) {
+	for k := range s.constraints {
+		i := k
+		if reverse {
+			i = len(s.constraints) - 1 - k
+		}
+		c := &s.constraints[i]
+
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			dv := c.relativeVelocity(p.rA, p.rB)
+
+			// Конус трения Кулона аппроксимируется кругом радиуса mu * Pn
+			lambda1 := -dv.Dot(c.tangent1) * p.tangentMass1
+			lambda2 := -dv.Dot(c.tangent2) * p.tangentMass2
+			old1, old2 := p.tangentImpulse1, p.tangentImpulse2
+			p.tangentImpulse1 += lambda1
+			p.tangentImpulse2 += lambda2
+
+			maxFriction := c.friction * p.normalImpulse
+			length := float32(math.Sqrt(float64(p.tangentImpulse1*p.tangentImpulse1 + p.tangentImpulse2*p.tangentImpulse2)))
+			if length > maxFriction && length > 0 {
+				scale := maxFriction / length
+				p.tangentImpulse1 *= scale
+				p.tangentImpulse2 *= scale
+			}
+
+			impulse := c.tangent1.Mul(p.tangentImpulse1 - old1).Add(c.tangent2.Mul(p.tangentImpulse2 - old2))
+			c.applyImpulse(p.rA, p.rB, impulse)
+		}
+
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			vn := c.relativeVelocity(p.rA, p.rB).Dot(c.normal)
+
+			lambda := -p.normalMass * (vn - p.velocityBias)
+			old := p.normalImpulse
+			p.normalImpulse = float32(math.Max(float64(old+lambda), 0))
+
+			c.applyImpulse(p.rA, p.rB, c.normal.Mul(p.normalImpulse-old))
+		}
+	}
+}
+
+// solvePositions выполняет одну итерацию коррекции проникновения через псевдоскорости
+func (s *constraintSolver) solvePositions(dt float32) {
+	for i := range s.constraints {
+		c := &s.constraints[i]
+
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			if p.depth <= contactSlop {
+				continue
+			}
+
+			dv := c.bodyB.pseudoVelocity.Add(c.bodyB.pseudoAngular.Cross(p.rB)).
+				Sub(c.bodyA.pseudoVelocity).Sub(c.bodyA.pseudoAngular.Cross(p.rA))
+			vn := dv.Dot(c.normal)
+			bias := contactBaumgarte / dt * (p.depth - contactSlop)
+
+			lambda := p.normalMass * (bias - vn)
+			old := p.pseudoImpulse
+			p.pseudoImpulse = float32(math.Max(float64(old+lambda), 0))
+
+			impulse := c.normal.Mul(p.pseudoImpulse - old)
+			c.bodyA.pseudoVelocity = c.bodyA.pseudoVelocity.Sub(impulse.Mul(c.invMassA))
+			c.bodyA.pseudoAngular = c.bodyA.pseudoAngular.Sub(c.invInertiaA.Mul3x1(p.rA.Cross(impulse)))
+			c.bodyB.pseudoVelocity = c.bodyB.pseudoVelocity.Add(impulse.Mul(c.invMassB))
+			c.bodyB.pseudoAngular = c.bodyB.pseudoAngular.Add(c.invInertiaB.Mul3x1(p.rB.Cross(impulse)))
+		}
+	}
+}
+
+// storeImpulses сохраняет накопленные импульсы для прогрева на следующем шаге
+func (s *constraintSolver) storeImpulses() {
+	clear(s.nextCache)
+	for i := range s.constraints {
+		c := &s.constraints[i]
+		var cached cachedManifold
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			cached.points[j] = cachedImpulse{
+				local:    p.local,
+				normal:   p.normalImpulse,
+				tangent1: p.tangentImpulse1,
+				tangent2: p.tangentImpulse2,
+			}
+		}
+		cached.count = c.count
+		s.nextCache[c.key] = cached
+	}
+	s.cache, s.nextCache = s.nextCache, s.cache
+}
+
+// relativeVelocity возвращает скорость точки тела B относительно тела A
+func (c *contactConstraint) relativeVelocity(rA, rB mgl32.Vec3) mgl32.Vec3 {
+	va := c.bodyA.Velocity.Add(c.bodyA.AngularVelocity.Cross(rA))
+	vb := c.bodyB.Velocity.Add(c.bodyB.AngularVelocity.Cross(rB))
+	return vb.Sub(va)
+}
+
+// effectiveMass возвращает эффективную массу пары тел вдоль направления dir
+func (c *contactConstraint) effectiveMass(rA, rB, dir mgl32.Vec3) float32 {
+	raxd := rA.Cross(dir)
+	rbxd := rB.Cross(dir)
+	k := c.invMassA + c.invMassB +
+		c.invInertiaA.Mul3x1(raxd).Dot(raxd) +
+		c.invInertiaB.Mul3x1(rbxd).Dot(rbxd)
+	if k <= 1e-12 {
+		return 0
+	}
+	return 1 / k
+}
+
+// applyImpulse применяет импульс к телу B и противоположный к телу A
+func (c *contactConstraint) applyImpulse(rA, rB, impulse mgl32.Vec3) {
+	a, b := c.bodyA, c.bodyB
+	a.Velocity = a.Velocity.Sub(impulse.Mul(c.invMassA))
+	a.AngularVelocity = a.AngularVelocity.Sub(c.invInertiaA.Mul3x1(rA.Cross(impulse)))
+	b.Velocity = b.Velocity.Add(impulse.Mul(c.invMassB))
+	b.AngularVelocity = b.AngularVelocity.Add(c.invInertiaB.Mul3x1(rB.Cross(impulse)))
+}
+
+// tangentBasis строит две касательные, ортогональные нормали. Базис зависит
+// только от нормали, поэтому накопленные импульсы трения переносятся между шагами
+func tangentBasis(normal mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	var tangent mgl32.Vec3
+	if abs32(normal.X()) >= 0.57735 {
+		tangent = mgl32.Vec3{normal.Y(), -normal.X(), 0}
+	} else {
+		tangent = mgl32.Vec3{0, normal.Z(), -normal.Y()}
+	}
+	tangent = tangent.Normalize()
+	return tangent, normal.Cross(tangent)
+}
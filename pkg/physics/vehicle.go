@@ -0,0 +1,510 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	customMath "github.com/Salamander5876/AnimoEngine/pkg/core/math"
+)
+
+// Параметры модели машины
+const (
+	vehicleLowSpeed  = 1.0 // м/с: ниже этой скорости проскальзывание шин считается от нее
+	vehicleGearSpeed = 0.5 // м/с: автомат переключается между первой и задней почти на месте
+	radPerSecToRPM   = 60 / (2 * math.Pi)
+)
+
+// TireCurve кривая сцепления шины по упрощенной формуле Пасейки
+// Peak*sin(Shape*atan(B*x - E*(B*x - atan(B*x)))), где x - проскальзывание
+// (доля для продольной силы, радианы для боковой). Результат - доля нагрузки на колесо
+type TireCurve struct {
+	Stiffness float32 // B: крутизна кривой у нуля
+	Shape     float32 // C: форма, определяет спад после пика
+	Peak      float32 // D: пиковое сцепление
+	Curvature float32 // E: кривизна у пика
+}
+
+// DefaultLongitudinalCurve кривая продольного сцепления с пиком около 10% проскальзывания
+func DefaultLongitudinalCurve() TireCurve {
+	return TireCurve{Stiffness: 12, Shape: 1.65, Peak: 1, Curvature: 0}
+}
+
+// DefaultLateralCurve кривая бокового сцепления с пиком около 8° увода
+func DefaultLateralCurve() TireCurve {
+	return TireCurve{Stiffness: 14, Shape: 1.3, Peak: 1, Curvature: 0}
+}
+
+// Evaluate возвращает долю нагрузки, которую шина передает при проскальзывании slip
+func (t TireCurve) Evaluate(slip float32) float32 {
+	x := float64(t.Stiffness * slip)
+	return t.Peak * float32(math.Sin(float64(t.Shape)*math.Atan(x-float64(t.Curvature)*(x-math.Atan(x)))))
+}
+
+// TorquePoint точка кривой момента двигателя
+type TorquePoint struct {
+	RPM    float32
+	Torque float32 // Н·м
+}
+
+// Engine двигатель: момент по кривой от оборотов, умноженный на газ
+type Engine struct {
+	TorqueCurve []TorquePoint // Точки по возрастанию оборотов
+	IdleRPM     float32       // Обороты холостого хода
+	MaxRPM      float32       // Отсечка: выше момент не подается
+}
+
+// Torque возвращает момент на полном газу при оборотах rpm.
+// Между точками кривой момент интерполируется линейно
+func (e *Engine) Torque(rpm float32) float32 {
+	curve := e.TorqueCurve
+	if len(curve) == 0 || rpm > e.MaxRPM {
+		return 0
+	}
+	if rpm <= curve[0].RPM {
+		return curve[0].Torque
+	}
+	for i := 1; i < len(curve); i++ {
+		if rpm <= curve[i].RPM {
+			a, b := curve[i-1], curve[i]
+			t := (rpm - a.RPM) / (b.RPM - a.RPM)
+			return a.Torque + (b.Torque-a.Torque)*t
+		}
+	}
+	return curve[len(curve)-1].Torque
+}
+
+// Gearbox коробка передач. Передача 0 - нейтраль, -1 - задняя
+type Gearbox struct {
+	Ratios       []float32 // Передаточные числа передач вперед, начиная с первой
+	Reverse      float32   // Передаточное число задней передачи
+	FinalDrive   float32   // Главная передача
+	Efficiency   float32   // Доля момента, доходящая до колес
+	Automatic    bool      // Переключать передачи по оборотам
+	ShiftUpRPM   float32
+	ShiftDownRPM float32
+	ShiftTime    float32 // Время переключения, пока момент не передается, с
+}
+
+// ratio возвращает полное передаточное число передачи gear со знаком направления
+func (g *Gearbox) ratio(gear int) float32 {
+	switch {
+	case gear < 0:
+		return -g.Reverse * g.FinalDrive
+	case gear == 0 || gear > len(g.Ratios):
+		return 0
+	}
+	return g.Ratios[gear-1] * g.FinalDrive
+}
+
+// Wheel колесо машины на луче: подвеска - пружина с демпфером вдоль локальной
+// оси -Y кузова, шина передает силы по кривым сцепления машины
+type Wheel struct {
+	Position   mgl32.Vec3 // Крепление подвески в локальных координатах кузова
+	Radius     float32
+	RestLength float32 // Ход подвески: длина пружины без нагрузки
+	Stiffness  float32 // Жесткость пружины, Н/м
+	Damping    float32 // Демпфер, Н·с/м
+	Inertia    float32 // Момент инерции колеса, кг·м²
+	Friction   float32 // Сцепление шины; игра может менять его по покрытию под колесом
+	Steer      bool    // Колесо поворачивается рулем
+	Drive      bool    // Колесо ведущее
+	Handbrake  bool    // Колесо блокируется ручником
+
+	// Состояние после последнего шага
+	Grounded      bool
+	Compression   float32 // Сжатие подвески, м
+	Load          float32 // Сила подвески, Н
+	ContactPoint  mgl32.Vec3
+	ContactNormal mgl32.Vec3
+	GroundBody    *RigidBody
+	SteerAngle    float32 // рад
+	SpinSpeed     float32 // Угловая скорость вращения, рад/с
+	SpinAngle     float32 // Угол поворота колеса для отрисовки, рад
+	SlipRatio     float32 // Продольное проскальзывание
+	SlipAngle     float32 // Угол увода, рад
+}
+
+// NewWheel создает колесо с подвеской, рассчитанной на машину около 1200 кг на четырех колесах
+func NewWheel(position mgl32.Vec3, radius float32) *Wheel {
+	return &Wheel{
+		Position:   position,
+		Radius:     radius,
+		RestLength: 0.3,
+		Stiffness:  35000,
+		Damping:    3000,
+		Inertia:    1,
+		Friction:   1,
+	}
+}
+
+// Vehicle машина на лучах: динамическое тело-кузов и колеса, каждое из которых
+// ищет опору лучом вниз. Подвеска держит кузов, шины разгоняют, тормозят и
+// поворачивают его по кривым сцепления, двигатель передает момент на ведущие
+// колеса через коробку передач. Локальные оси кузова: вперед -Z, вправо X, вверх Y.
+// Для вида сверху машина ездит по плоскости мира, а игра читает X, Z и GetHeading
+type Vehicle struct {
+	Chassis *RigidBody
+	Wheels  []*Wheel
+	Engine  Engine
+	Gearbox Gearbox
+
+	// Управление
+	Throttle  float32 // Газ 0..1
+	Brake     float32 // Тормоз 0..1; автомат на месте включает заднюю и газует тормозом
+	Steering  float32 // Руль -1..1, положительный - вправо
+	Handbrake bool
+
+	MaxSteerAngle     float32 // Наибольший угол поворота колес, рад
+	BrakeTorque       float32 // Момент тормоза на колесо, Н·м
+	HandbrakeTorque   float32 // Момент ручника на колесо, Н·м
+	HandbrakeGrip     float32 // Доля бокового сцепления колес на ручнике (занос)
+	LongitudinalCurve TireCurve
+	LateralCurve      TireCurve
+	Drag              float32 // Аэродинамическое сопротивление, Н/(м/с)²
+	RollingResistance float32 // Сопротивление качению, доля нагрузки
+	RollInfluence     float32 // Доля крена от сил шин: 1 - силы в пятне контакта, 0 - на высоте центра масс
+
+	gear       int
+	rpm        float32
+	shiftTimer float32
+	filter     QueryFilter
+}
+
+// NewVehicle создает машину на динамическом теле chassis с двигателем и
+// автоматической коробкой легкового автомобиля. Колеса добавляются через AddWheel,
+// машина - в мир через PhysicsWorld.AddVehicle; кузов добавляется в мир отдельно
+func NewVehicle(chassis *RigidBody) *Vehicle {
+	v := &Vehicle{
+		Chassis: chassis,
+		Engine: Engine{
+			TorqueCurve: []TorquePoint{
+				{RPM: 1000, Torque: 200},
+				{RPM: 3000, Torque: 300},
+				{RPM: 5000, Torque: 320},
+				{RPM: 7000, Torque: 250},
+			},
+			IdleRPM: 900,
+			MaxRPM:  7000,
+		},
+		Gearbox: Gearbox{
+			Ratios:       []float32{3.6, 2.2, 1.5, 1.1, 0.9},
+			Reverse:      3.4,
+			FinalDrive:   3.7,
+			Efficiency:   0.85,
+			Automatic:    true,
+			ShiftUpRPM:   6000,
+			ShiftDownRPM: 2500,
+			ShiftTime:    0.2,
+		},
+		MaxSteerAngle:     mgl32.DegToRad(35),
+		BrakeTorque:       1500,
+		HandbrakeTorque:   3000,
+		HandbrakeGrip:     0.5,
+		LongitudinalCurve: DefaultLongitudinalCurve(),
+		LateralCurve:      DefaultLateralCurve(),
+		Drag:              0.4,
+		RollingResistance: 0.015,
+		RollInfluence:     0.1,
+		rpm:               900,
+	}
+	v.filter = func(body *RigidBody) bool {
+		return body != v.Chassis && !body.IsTrigger && v.Chassis.CanCollideWith(body)
+	}
+	return v
+}
+
+// AddWheel добавляет колесо к машине
+func (v *Vehicle) AddWheel(wheel *Wheel) *Wheel {
+	v.Wheels = append(v.Wheels, wheel)
+	return wheel
+}
+
+// GetGear возвращает текущую передачу: -1 задняя, 0 нейтраль
+func (v *Vehicle) GetGear() int {
+	return v.gear
+}
+
+// SetGear включает передачу; автомат продолжит переключать от нее
+func (v *Vehicle) SetGear(gear int) {
+	v.gear = max(-1, min(gear, len(v.Gearbox.Ratios)))
+	v.shiftTimer = v.Gearbox.ShiftTime
+}
+
+// ShiftUp включает следующую передачу
+func (v *Vehicle) ShiftUp() {
+	v.SetGear(v.gear + 1)
+}
+
+// ShiftDown включает предыдущую передачу
+func (v *Vehicle) ShiftDown() {
+	v.SetGear(v.gear - 1)
+}
+
+// GetRPM возвращает обороты двигателя
+func (v *Vehicle) GetRPM() float32 {
+	return v.rpm
+}
+
+// GetForward возвращает направление вперед кузова в мире
+func (v *Vehicle) GetForward() mgl32.Vec3 {
+	return v.Chassis.Rotation.Rotate(mgl32.Vec3{0, 0, -1})
+}
+
+// GetSpeed возвращает скорость кузова вдоль направления вперед, м/с
+func (v *Vehicle) GetSpeed() float32 {
+	return v.Chassis.Velocity.Dot(v.GetForward())
+}
+
+// GetHeading возвращает угол направления вперед в плоскости XZ от оси X к оси Z,
+// в радианах. Удобен для игр с видом сверху
+func (v *Vehicle) GetHeading() float32 {
+	forward := v.GetForward()
+	return float32(math.Atan2(float64(forward.Z()), float64(forward.X())))
+}
+
+// GetWheelTransform возвращает матрицу модели колеса: центр на подвеске,
+// поворот руля и вращение вокруг оси колеса
+func (v *Vehicle) GetWheelTransform(wheel *Wheel) mgl32.Mat4 {
+	chassis := v.Chassis.GetModelMatrix()
+	center := wheel.Position.Sub(mgl32.Vec3{0, wheel.RestLength - wheel.Compression, 0})
+	local := mgl32.Translate3D(center.X(), center.Y(), center.Z()).
+		Mul4(mgl32.HomogRotate3DY(-wheel.SteerAngle)).
+		Mul4(mgl32.HomogRotate3DX(-wheel.SpinAngle))
+	return chassis.Mul4(local)
+}
+
+// hasInput сообщает, управляет ли игрок машиной
+func (v *Vehicle) hasInput() bool {
+	return v.Throttle > 0 || v.Brake > 0 || v.Steering != 0 || v.Handbrake
+}
+
+// update применяет силы подвески, шин и сопротивления к кузову за шаг dt.
+// Вызывается из PhysicsWorld.Step до интегрирования скоростей
+func (v *Vehicle) update(w *PhysicsWorld, dt float32) {
+	chassis := v.Chassis
+	if chassis.Type != Dynamic {
+		return
+	}
+	if chassis.sleeping {
+		if !v.hasInput() {
+			return
+		}
+		w.wakeBody(chassis)
+	}
+
+	rotation := chassis.Rotation.Normalize()
+	up := rotation.Rotate(mgl32.Vec3{0, 1, 0})
+	forward := rotation.Rotate(mgl32.Vec3{0, 0, -1})
+	speed := chassis.Velocity.Dot(forward)
+
+	// Ведущие колеса: средние скорость вращения и радиус
+	driven := 0
+	var wheelSpeed, radius float32
+	for _, wheel := range v.Wheels {
+		if wheel.Drive {
+			driven++
+			wheelSpeed += wheel.SpinSpeed
+			radius += wheel.Radius
+		}
+	}
+	if driven > 0 {
+		wheelSpeed /= float32(driven)
+		radius /= float32(driven)
+	}
+
+	throttle, brake := v.updateGearbox(speed, radius, dt)
+
+	// Подвеска: сила пружины и демпфера вдоль нормали опоры
+	grounded := 0
+	for _, wheel := range v.Wheels {
+		if v.suspension(w, wheel, rotation, up, dt) {
+			grounded++
+		}
+	}
+
+	// Момент двигателя делится поровну между ведущими колесами
+	ratio := v.Gearbox.ratio(v.gear)
+	var driveTorque float32
+	if driven > 0 {
+		v.rpm = max(abs32(wheelSpeed*ratio)*radPerSecToRPM, v.Engine.IdleRPM)
+		if ratio != 0 && v.shiftTimer <= 0 {
+			driveTorque = v.Engine.Torque(v.rpm) * throttle * ratio * v.Gearbox.Efficiency / float32(driven)
+		}
+	}
+	if ratio == 0 {
+		// Без передачи двигатель свободно раскручивается газом
+		v.rpm = v.Engine.IdleRPM + (v.Engine.MaxRPM-v.Engine.IdleRPM)*throttle
+	}
+
+	for _, wheel := range v.Wheels {
+		steer := float32(0)
+		if wheel.Steer {
+			steer = clamp32(v.Steering, -1, 1) * v.MaxSteerAngle
+		}
+		wheel.SteerAngle = steer
+
+		torque := float32(0)
+		if wheel.Drive {
+			torque = driveTorque
+		}
+		v.tire(wheel, forward, up, torque, brake, grounded, dt)
+	}
+
+	// Сопротивление воздуха
+	if v.Drag > 0 {
+		velocity := chassis.Velocity
+		drag := velocity.Mul(-v.Drag * velocity.Len() * dt)
+		// Сопротивление не разворачивает скорость
+		if drag.LenSqr()*chassis.inverseMass()*chassis.inverseMass() > velocity.LenSqr() {
+			drag = velocity.Mul(-chassis.Mass)
+		}
+		chassis.applyImpulse(drag)
+	}
+}
+
+// updateGearbox переключает передачи автомата и возвращает газ и тормоз
+// с учетом задней передачи. Автомат смотрит на обороты по скорости машины
+// (radius - радиус ведущих колес), чтобы буксование не переключало передачи
+func (v *Vehicle) updateGearbox(speed, radius, dt float32) (float32, float32) {
+	throttle, brake := clamp32(v.Throttle, 0, 1), clamp32(v.Brake, 0, 1)
+	if v.shiftTimer > 0 {
+		v.shiftTimer -= dt
+	}
+
+	gearbox := &v.Gearbox
+	if gearbox.Automatic {
+		var rpm float32
+		if radius > 0 {
+			rpm = abs32(speed*gearbox.ratio(v.gear)/radius) * radPerSecToRPM
+		}
+		switch {
+		case v.gear >= 0 && brake > 0 && throttle == 0 && speed < vehicleGearSpeed:
+			v.gear = -1
+		case v.gear <= 0 && throttle > 0 && speed > -vehicleGearSpeed:
+			v.gear = 1
+		case v.gear >= 1 && v.gear < len(gearbox.Ratios) && v.shiftTimer <= 0 && rpm > gearbox.ShiftUpRPM:
+			v.SetGear(v.gear + 1)
+		case v.gear > 1 && v.shiftTimer <= 0 && rpm < gearbox.ShiftDownRPM:
+			v.SetGear(v.gear - 1)
+		}
+		if v.gear < 0 {
+			// На задней педали меняются ролями
+			throttle, brake = brake, throttle
+		}
+	}
+	return throttle, brake
+}
+
+// suspension ищет опору колеса лучом и прикладывает силу подвески.
+// Возвращает true, если колесо стоит на опоре
+func (v *Vehicle) suspension(w *PhysicsWorld, wheel *Wheel, rotation mgl32.Quat, up mgl32.Vec3, dt float32) bool {
+	chassis := v.Chassis
+	anchor := chassis.Position.Add(rotation.Rotate(wheel.Position))
+	down := up.Mul(-1)
+
+	wheel.Grounded = false
+	wheel.Load = 0
+	wheel.GroundBody = nil
+
+	length := wheel.RestLength + wheel.Radius
+	hit, ok := w.Raycast(customMath.Ray{Origin: anchor, Direction: down}, length, v.filter)
+	if !ok || hit.Normal.Dot(up) <= 0 {
+		wheel.Compression = 0
+		return false
+	}
+
+	wheel.Grounded = true
+	wheel.Compression = length - hit.Distance
+	wheel.ContactPoint = hit.Point
+	wheel.ContactNormal = hit.Normal
+	wheel.GroundBody = hit.Body
+
+	relative := chassis.GetPointVelocity(hit.Point).Sub(hit.Body.GetPointVelocity(hit.Point))
+	closing := -relative.Dot(hit.Normal)
+	wheel.Load = max(wheel.Stiffness*wheel.Compression+wheel.Damping*closing, 0)
+
+	impulse := hit.Normal.Mul(wheel.Load * dt)
+	chassis.applyImpulseAtPoint(impulse, hit.Point)
+	hit.Body.ApplyImpulseAtPoint(impulse.Mul(-1), hit.Point)
+	return true
+}
+
+// tire вращает колесо моментами двигателя и тормозов и передает кузову силы шины.
+// Силы ограничены эллипсом трения и импульсом, который погасил бы проскальзывание
+// за шаг, поэтому модель устойчива на малых скоростях
+func (v *Vehicle) tire(wheel *Wheel, forward, up mgl32.Vec3, driveTorque, brake float32, grounded int, dt float32) {
+	chassis := v.Chassis
+	inertia := max(wheel.Inertia, 1e-3)
+	wheel.SpinSpeed += driveTorque / inertia * dt
+
+	brakeTorque := v.BrakeTorque * brake
+	grip := wheel.Friction
+	if wheel.Handbrake && v.Handbrake {
+		brakeTorque += v.HandbrakeTorque
+	}
+
+	if wheel.Grounded {
+		normal := wheel.ContactNormal
+		// Направление качения колеса с учетом руля, в плоскости опоры
+		heading := mgl32.QuatRotate(-wheel.SteerAngle, up).Rotate(forward)
+		rolling := safeNormalize(heading.Sub(normal.Mul(heading.Dot(normal))))
+		side := rolling.Cross(normal)
+
+		point := wheel.ContactPoint
+		relative := chassis.GetPointVelocity(point).Sub(wheel.GroundBody.GetPointVelocity(point))
+		longitudinal := relative.Dot(rolling)
+		lateral := relative.Dot(side)
+
+		reference := max(abs32(longitudinal), vehicleLowSpeed)
+		slip := wheel.SpinSpeed*wheel.Radius - longitudinal
+		wheel.SlipRatio = slip / reference
+		wheel.SlipAngle = float32(math.Atan2(float64(lateral), float64(reference)))
+
+		lateralGrip := grip
+		if wheel.Handbrake && v.Handbrake {
+			lateralGrip *= v.HandbrakeGrip
+		}
+		fx := v.LongitudinalCurve.Evaluate(wheel.SlipRatio) * grip * wheel.Load
+		fy := -v.LateralCurve.Evaluate(wheel.SlipAngle) * lateralGrip * wheel.Load
+
+		// Эллипс трения: суммарная сила шины не больше сцепления
+		limit := grip * wheel.Load
+		if total := float32(math.Hypot(float64(fx), float64(fy))); total > limit && total > 0 {
+			fx *= limit / total
+			fy *= limit / total
+		}
+
+		// Сила не должна развернуть проскальзывание за шаг
+		share := float32(max(grounded, 1))
+		sideMass := chassis.pointMass(point, side) / share
+		fy = clamp32(fy, -abs32(lateral)*sideMass/dt, abs32(lateral)*sideMass/dt)
+		rollMass := chassis.pointMass(point, rolling) / share
+		denominator := dt * (wheel.Radius*wheel.Radius/inertia + 1/max(rollMass, 1e-6))
+		fx = clamp32(fx, -abs32(slip)/denominator, abs32(slip)/denominator)
+
+		// Силы шины поднимаются к центру масс, чтобы машина не опрокидывалась в поворотах
+		impulse := rolling.Mul(fx * dt).Add(side.Mul(fy * dt))
+		height := chassis.Position.Sub(point).Dot(up) * (1 - v.RollInfluence)
+		chassis.applyImpulseAtPoint(impulse, point.Add(up.Mul(height)))
+		wheel.GroundBody.ApplyImpulseAtPoint(impulse.Mul(-1), point)
+
+		wheel.SpinSpeed -= fx * wheel.Radius / inertia * dt
+		brakeTorque += v.RollingResistance * wheel.Load * wheel.Radius
+	} else {
+		wheel.SlipRatio = 0
+		wheel.SlipAngle = 0
+	}
+
+	// Тормоз замедляет вращение, но не раскручивает колесо в обратную сторону
+	stop := brakeTorque / inertia * dt
+	if abs32(wheel.SpinSpeed) <= stop {
+		wheel.SpinSpeed = 0
+	} else if wheel.SpinSpeed > 0 {
+		wheel.SpinSpeed -= stop
+	} else {
+		wheel.SpinSpeed += stop
+	}
+
+	wheel.SpinAngle = float32(math.Mod(float64(wheel.SpinAngle+wheel.SpinSpeed*dt), 2*math.Pi))
+}
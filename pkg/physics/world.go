@@ -4,274 +4,400 @@ import (
 	"math"
 
 	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
 )
 
+// groundCosine минимальный косинус угла между нормалью опоры и направлением
+// против гравитации, при котором тело считается стоящим (около 45°)
+const groundCosine = 0.7
+
 // PhysicsWorld физический мир
 type PhysicsWorld struct {
-	Gravity       mgl32.Vec3
-	Bodies        []*RigidBody
-	nextID        int
-	GroundPlaneY  float32 // Y координата земли
-	EnableDebug   bool
+	Gravity     mgl32.Vec3
+	Bodies      []*RigidBody
+	nextID      int
+	EnableDebug bool
+
+	// Настройки решателя
+	VelocityIterations int  // Итерации по скоростям (точность контактов и трения)
+	PositionIterations int  // Итерации коррекции проникновения
+	WarmStarting       bool // Начинать с импульсов прошлого шага (устойчивость стопок)
+
+	// Сон: острова тел, покоящиеся дольше SleepTime, не интегрируются
+	AllowSleep           bool
+	SleepLinearVelocity  float32 // Порог линейной скорости покоя, м/с
+	SleepAngularVelocity float32 // Порог угловой скорости покоя, рад/с
+	SleepTime            float32 // Время покоя до засыпания, с
+
+	// Детерминированный режим: Step копит время и делает только целые шаги
+	// FixedTimeStep, поэтому результат зависит лишь от числа шагов (StateHash)
+	Deterministic bool
+	FixedTimeStep float32 // Шаг детерминированного режима, с
+	MaxSubSteps   int     // Предел шагов за один вызов Step (0 - без предела)
+	accumulator   float32 // Время, не вошедшее в целый шаг
+
+	solver         *constraintSolver
+	joints         []Joint
+	vehicles       []*Vehicle
+	fluids         []*FluidSystem
+	jointPairs     map[contactKey]bool
+	broadPhase     BroadPhase
+	broadPhaseType BroadPhaseType
+	queryDirty     bool // Широкая фаза отстает от тел - обновить перед запросом
+	contacts       []ContactManifold
+	triggers       []ContactManifold // Пересечения с датчиками
+	islands        islandSet
+	awakeJoints    []Joint
+	bodyEvents     []*event.Event // События сна, отправляемые после шага
+
+	// Непрерывное определение столкновений
+	speculative      []ContactManifold // Спекулятивные контакты быстрых тел
+	speculativePairs map[contactKey]bool
+	allContacts      []ContactManifold
+
+	// События коллизий: касания текущего и прошлого шага
+	eventBus         *event.EventBus
+	touching         []touchingPair
+	touchingKeys     map[contactKey]bool
+	previousTouching []touchingPair
+	previousKeys     map[contactKey]bool
 }
 
 // NewPhysicsWorld создает новый физический мир
 func NewPhysicsWorld() *PhysicsWorld {
 	return &PhysicsWorld{
-		Gravity:      mgl32.Vec3{0, -9.81, 0},
-		Bodies:       make([]*RigidBody, 0),
-		nextID:       0,
-		GroundPlaneY: 0.0,
-		EnableDebug:  false,
+		Gravity:     mgl32.Vec3{0, -9.81, 0},
+		Bodies:      make([]*RigidBody, 0),
+		nextID:      0,
+		EnableDebug: false,
+
+		VelocityIterations: 10,
+		PositionIterations: 4,
+		WarmStarting:       true,
+
+		AllowSleep:           true,
+		SleepLinearVelocity:  defaultSleepLinearVelocity,
+		SleepAngularVelocity: defaultSleepAngularVelocity,
+		SleepTime:            defaultSleepTime,
+
+		FixedTimeStep: 1.0 / 60.0,
+		MaxSubSteps:   8,
+
+		solver:         newConstraintSolver(),
+		jointPairs:     make(map[contactKey]bool),
+		broadPhase:     NewBroadPhase(BroadPhaseAABBTree),
+		broadPhaseType: BroadPhaseAABBTree,
+		queryDirty:     true,
+		touchingKeys:   make(map[contactKey]bool),
+		previousKeys:   make(map[contactKey]bool),
 	}
 }
 
+// SetBroadPhase выбирает алгоритм широкой фазы
+func (w *PhysicsWorld) SetBroadPhase(kind BroadPhaseType) {
+	w.broadPhase = NewBroadPhase(kind)
+	w.broadPhaseType = kind
+	w.queryDirty = true
+}
+
+// GetBroadPhase возвращает текущую широкую фазу
+func (w *PhysicsWorld) GetBroadPhase() BroadPhase {
+	return w.broadPhase
+}
+
+// GetBroadPhaseType возвращает тип текущей широкой фазы
+func (w *PhysicsWorld) GetBroadPhaseType() BroadPhaseType {
+	return w.broadPhaseType
+}
+
 // AddBody добавляет тело в мир
 func (w *PhysicsWorld) AddBody(body *RigidBody) *RigidBody {
 	body.ID = w.nextID
 	w.nextID++
 	w.Bodies = append(w.Bodies, body)
+	w.queryDirty = true
 	return body
 }
 
-// RemoveBody удаляет тело из мира
+// RemoveBody удаляет тело из мира, сохраняя порядок остальных тел: от порядка
+// зависят пары широкой фазы и решатель. Спящие соседи тела просыпаются.
+// Соединения с этим телом удаляются из мира вместе с ним, второе тело
+// соединения просыпается
 func (w *PhysicsWorld) RemoveBody(body *RigidBody) {
 	for i, b := range w.Bodies {
 		if b.ID == body.ID {
+			w.wakeTouching(body)
+			w.removeBodyJoints(body)
+			body.sleeping = false
+			body.wokeUp = false
+			body.islandIndex = -1
 			w.Bodies = append(w.Bodies[:i], w.Bodies[i+1:]...)
+			w.queryDirty = true
 			return
 		}
 	}
 }
 
-// Step делает шаг симуляции
-func (w *PhysicsWorld) Step(dt float32) {
-	// Интегрируем физику для всех динамических тел
-	for _, body := range w.Bodies {
-		if body.Type != Dynamic {
-			continue
-		}
-
-		// Применяем гравитацию
-		if body.UseGravity {
-			body.ApplyForce(w.Gravity.Mul(body.Mass * dt))
-		}
-
-		// Интегрируем скорость
-		body.Position = body.Position.Add(body.Velocity.Mul(dt))
-
-		// Применяем трение воздуха
-		airResistance := float32(0.99)
-		body.Velocity = body.Velocity.Mul(airResistance)
+// AddJoint добавляет соединение в мир и будит соединенные тела.
+// RemoveBody любого из тел удаляет и соединение
+func (w *PhysicsWorld) AddJoint(joint Joint) Joint {
+	joint.GetBodyA().WakeUp()
+	joint.GetBodyB().WakeUp()
+	w.joints = append(w.joints, joint)
+	return joint
+}
 
-		// Интегрируем угловую скорость
-		angle := body.AngularVelocity.Len() * dt
-		if angle > 0.0001 {
-			axis := body.AngularVelocity.Normalize()
-			rotation := mgl32.QuatRotate(angle, axis)
-			body.Rotation = rotation.Mul(body.Rotation).Normalize()
+// RemoveJoint удаляет соединение из мира и будит соединенные тела
+func (w *PhysicsWorld) RemoveJoint(joint Joint) {
+	for i, j := range w.joints {
+		if j == joint {
+			joint.GetBodyA().WakeUp()
+			joint.GetBodyB().WakeUp()
+			w.joints = append(w.joints[:i], w.joints[i+1:]...)
+			return
 		}
-
-		// Проверяем коллизию с землей
-		w.checkGroundCollision(body)
 	}
-
-	// Проверяем коллизии между телами
-	w.checkCollisions()
 }
 
-// checkGroundCollision проверяет столкновение с землей
-func (w *PhysicsWorld) checkGroundCollision(body *RigidBody) {
-	var bottomY float32
-
-	switch body.Shape {
-	case BoxShape:
-		bottomY = body.Position.Y() - body.Dimensions.Y()*body.Scale.Y()/2
-	case SphereShape:
-		bottomY = body.Position.Y() - body.Dimensions.X()*body.Scale.X()
-	case CapsuleShape:
-		bottomY = body.Position.Y() - (body.Dimensions.Y()*body.Scale.Y()/2 + body.Dimensions.X()*body.Scale.X())
-	case LiquidShape:
-		// Жидкость - как сфера
-		bottomY = body.Position.Y() - body.Dimensions.X()*body.Scale.X()
-	}
-
-	if bottomY <= w.GroundPlaneY {
-		// Столкновение с землей
-		body.IsGrounded = true
-
-		// Корректируем позицию
-		switch body.Shape {
-		case BoxShape:
-			body.Position[1] = w.GroundPlaneY + body.Dimensions.Y()*body.Scale.Y()/2
-		case SphereShape:
-			body.Position[1] = w.GroundPlaneY + body.Dimensions.X()*body.Scale.X()
-		case CapsuleShape:
-			body.Position[1] = w.GroundPlaneY + body.Dimensions.Y()*body.Scale.Y()/2 + body.Dimensions.X()*body.Scale.X()
-		case LiquidShape:
-			body.Position[1] = w.GroundPlaneY + body.Dimensions.X()*body.Scale.X()
+// removeBodyJoints удаляет соединения, в которых участвует тело, и будит
+// вторые тела этих соединений
+func (w *PhysicsWorld) removeBodyJoints(body *RigidBody) {
+	joints := w.joints[:0]
+	for _, joint := range w.joints {
+		if joint.GetBodyA() == body || joint.GetBodyB() == body {
+			joint.GetBodyA().WakeUp()
+			joint.GetBodyB().WakeUp()
+			continue
 		}
+		joints = append(joints, joint)
+	}
+	clear(w.joints[len(joints):])
+	w.joints = joints
+}
 
-		// Применяем отскок
-		if body.Velocity.Y() < 0 {
-			body.Velocity[1] = -body.Velocity.Y() * body.Restitution
+// GetJoints возвращает соединения мира, включая разрушенные
+func (w *PhysicsWorld) GetJoints() []Joint {
+	return w.joints
+}
 
-			// Если скорость мала, останавливаем
-			if math.Abs(float64(body.Velocity.Y())) < 0.1 {
-				body.Velocity[1] = 0
-			}
-		}
+// AddVehicle добавляет машину в мир: ее силы применяются в начале каждого шага.
+// Кузов добавляется через AddBody отдельно
+func (w *PhysicsWorld) AddVehicle(vehicle *Vehicle) *Vehicle {
+	vehicle.Chassis.WakeUp()
+	w.vehicles = append(w.vehicles, vehicle)
+	return vehicle
+}
 
-		// Применяем трение
-		horizontalVel := mgl32.Vec3{body.Velocity.X(), 0, body.Velocity.Z()}
-		if horizontalVel.Len() > 0 {
-			friction := horizontalVel.Normalize().Mul(-body.Friction * 5.0)
-			body.Velocity = body.Velocity.Add(friction.Mul(1.0 / 60.0)) // Предполагаем 60 FPS
+// RemoveVehicle удаляет машину из мира; кузов остается обычным телом
+func (w *PhysicsWorld) RemoveVehicle(vehicle *Vehicle) {
+	for i, v := range w.vehicles {
+		if v == vehicle {
+			w.vehicles = append(w.vehicles[:i], w.vehicles[i+1:]...)
+			return
 		}
-
-		// Замедляем вращение при контакте с землей
-		body.AngularVelocity = body.AngularVelocity.Mul(0.95)
-	} else {
-		body.IsGrounded = false
 	}
 }
 
-// checkCollisions проверяет столкновения между телами
-func (w *PhysicsWorld) checkCollisions() {
-	for i := 0; i < len(w.Bodies); i++ {
-		for j := i + 1; j < len(w.Bodies); j++ {
-			bodyA := w.Bodies[i]
-			bodyB := w.Bodies[j]
-
-			// Пропускаем если оба статичные
-			if bodyA.Type == Static && bodyB.Type == Static {
-				continue
-			}
+// GetVehicles возвращает машины мира
+func (w *PhysicsWorld) GetVehicles() []*Vehicle {
+	return w.vehicles
+}
 
-			// Простая AABB проверка
-			if w.checkAABBCollision(bodyA, bodyB) {
-				w.resolveCollision(bodyA, bodyB)
+// Step делает шаг симуляции. В детерминированном режиме dt копится, и мир
+// делает столько шагов FixedTimeStep, сколько в нем помещается, но не больше
+// MaxSubSteps. Если долгий кадр не укладывается в предел, лишние целые шаги
+// отбрасываются и остается только дробный остаток: симуляция отстает от
+// реального времени, но не уходит в лавину шагов
+func (w *PhysicsWorld) Step(dt float32) {
+	if dt <= 0 {
+		return
+	}
+	if w.Deterministic && w.FixedTimeStep > 0 {
+		w.accumulator += dt
+		for steps := 0; w.accumulator >= w.FixedTimeStep; steps++ {
+			if w.MaxSubSteps > 0 && steps == w.MaxSubSteps {
+				w.accumulator = float32(math.Mod(float64(w.accumulator), float64(w.FixedTimeStep)))
+				break
 			}
+			w.accumulator -= w.FixedTimeStep
+			w.step(w.FixedTimeStep)
 		}
+		return
 	}
+	w.step(dt)
 }
 
-// checkAABBCollision проверяет столкновение AABB
-func (w *PhysicsWorld) checkAABBCollision(a, b *RigidBody) bool {
-	// Получаем размеры AABB
-	aMin, aMax := w.getAABB(a)
-	bMin, bMax := w.getAABB(b)
+// step делает один шаг длительностью dt
+func (w *PhysicsWorld) step(dt float32) {
+	w.collectManualWakes()
+	w.cacheInertia(true)
 
-	// Проверяем пересечение по всем осям
-	return (aMin.X() <= bMax.X() && aMax.X() >= bMin.X()) &&
-		(aMin.Y() <= bMax.Y() && aMax.Y() >= bMin.Y()) &&
-		(aMin.Z() <= bMax.Z() && aMax.Z() >= bMin.Z())
-}
+	// Машины: подвеска и шины толкают кузова до интегрирования
+	for _, vehicle := range w.vehicles {
+		vehicle.update(w, dt)
+	}
 
-// getAABB возвращает AABB для тела
-func (w *PhysicsWorld) getAABB(body *RigidBody) (mgl32.Vec3, mgl32.Vec3) {
-	var halfExtents mgl32.Vec3
+	// Жидкости: частицы толкают тела, погруженные тела всплывают
+	for _, fluid := range w.fluids {
+		fluid.update(w, dt)
+	}
 
-	switch body.Shape {
-	case BoxShape:
-		halfExtents = mgl32.Vec3{
-			body.Dimensions.X() * body.Scale.X() / 2,
-			body.Dimensions.Y() * body.Scale.Y() / 2,
-			body.Dimensions.Z() * body.Scale.Z() / 2,
+	// Интегрируем скорости: гравитация и затухание. Спящие тела пропускаем
+	for _, body := range w.Bodies {
+		if body.Type != Dynamic || body.sleeping {
+			continue
 		}
-	case SphereShape:
-		r := body.Dimensions.X() * body.Scale.X()
-		halfExtents = mgl32.Vec3{r, r, r}
-	case CapsuleShape:
-		r := body.Dimensions.X() * body.Scale.X()
-		h := body.Dimensions.Y() * body.Scale.Y() / 2
-		halfExtents = mgl32.Vec3{r, h + r, r}
-	case LiquidShape:
-		// Жидкость как сфера, но немного меньше
-		r := body.Dimensions.X() * body.Scale.X() * 0.8
-		halfExtents = mgl32.Vec3{r, r, r}
+
+		if body.UseGravity {
+			body.Velocity = body.Velocity.Add(w.Gravity.Mul(dt))
+		}
+		body.Velocity = body.Velocity.Mul(1 / (1 + dt*body.LinearDamping))
+		body.AngularVelocity = body.AngularVelocity.Mul(1 / (1 + dt*body.AngularDamping))
 	}
 
-	min := body.Position.Sub(halfExtents)
-	max := body.Position.Add(halfExtents)
-	return min, max
-}
+	// Находим контакты, будим задетые острова и решаем контакты импульсами
+	w.islands.reset(w.Bodies)
+	w.checkCollisions()
+	w.addSpeculativeContacts(dt)
+	w.wakeIslands()
+	w.solver.solve(w.solverContacts(), w.activeJoints(), dt, w.VelocityIterations, w.PositionIterations, w.WarmStarting)
 
-// resolveCollision разрешает столкновение
-func (w *PhysicsWorld) resolveCollision(a, b *RigidBody) {
-	// Вычисляем направление столкновения
-	direction := b.Position.Sub(a.Position)
-	distance := direction.Len()
+	// Интегрируем положение с учетом псевдоскоростей коррекции
+	for _, body := range w.Bodies {
+		if body.Type != Dynamic || body.sleeping {
+			continue
+		}
 
-	if distance < 0.0001 {
-		return // Избегаем деления на ноль
-	}
+		start, startRotation := body.Position, body.Rotation
+		velocity := body.Velocity.Add(body.pseudoVelocity)
+		body.Position = body.Position.Add(velocity.Mul(dt))
 
-	normal := direction.Normalize()
+		angular := body.AngularVelocity.Add(body.pseudoAngular)
+		angle := angular.Len() * dt
+		if angle > 0.0001 {
+			axis := angular.Normalize()
+			rotation := mgl32.QuatRotate(angle, axis)
+			body.Rotation = rotation.Mul(body.Rotation).Normalize()
+		}
 
-	// Вычисляем глубину проникновения
-	aMin, aMax := w.getAABB(a)
-	bMin, bMax := w.getAABB(b)
+		body.pseudoVelocity = mgl32.Vec3{}
+		body.pseudoAngular = mgl32.Vec3{}
 
-	overlap := mgl32.Vec3{
-		float32(math.Min(float64(aMax.X()-bMin.X()), float64(bMax.X()-aMin.X()))),
-		float32(math.Min(float64(aMax.Y()-bMin.Y()), float64(bMax.Y()-aMin.Y()))),
-		float32(math.Min(float64(aMax.Z()-bMin.Z()), float64(bMax.Z()-aMin.Z()))),
+		if body.CCD {
+			w.advanceContinuous(body, start, startRotation, dt)
+		}
 	}
 
-	// Находим минимальную ось проникновения
-	penetrationDepth := float32(math.Min(math.Min(float64(overlap.X()), float64(overlap.Y())), float64(overlap.Z())))
+	w.updateSleep(dt)
+	w.cacheInertia(false)
 
-	// Разделяем тела
-	separation := normal.Mul(penetrationDepth / 2)
+	// Тела сместились после обновления широкой фазы
+	w.queryDirty = true
 
-	if a.Type == Dynamic {
-		a.Position = a.Position.Sub(separation)
-	}
-	if b.Type == Dynamic {
-		b.Position = b.Position.Add(separation)
-	}
+	w.emitCollisionEvents()
+	w.emitBodyEvents()
+}
 
-	// Вычисляем относительную скорость
-	relativeVel := b.Velocity.Sub(a.Velocity)
-	velAlongNormal := relativeVel.Dot(normal)
+// cacheInertia запоминает обратные моменты инерции динамических тел на время
+// шага: решатель, связи, машины и жидкости только поворачивают их в мировые оси.
+// После шага кэш сбрасывается, чтобы изменения формы и массы между шагами
+// учитывались сразу
+func (w *PhysicsWorld) cacheInertia(enable bool) {
+	for _, body := range w.Bodies {
+		if body.Type != Dynamic {
+			continue
+		}
+		if enable {
+			body.invInertiaLocal = body.localInverseInertia()
+		}
+		body.inertiaCached = enable
+	}
+}
 
-	// Не разрешаем столкновение если тела расходятся
-	if velAlongNormal > 0 {
-		return
+// checkCollisions проверяет столкновения между телами.
+// Кандидатов отбирает широкая фаза, затем узкая фаза строит манифолды контактов.
+// Пары с датчиками попадают в triggers и не решаются
+func (w *PhysicsWorld) checkCollisions() {
+	w.contacts = w.contacts[:0]
+	w.triggers = w.triggers[:0]
+
+	// Пары тел, соединенных без CollideConnected, не сталкиваются
+	clear(w.jointPairs)
+	for _, joint := range w.joints {
+		base := joint.base()
+		if !base.CollideConnected && !base.broken {
+			w.jointPairs[pairKey(base.bodyA, base.bodyB)] = true
+		}
 	}
 
-	// Вычисляем импульс
-	restitution := float32(math.Min(float64(a.Restitution), float64(b.Restitution)))
-	invMassA := float32(0.0)
-	invMassB := float32(0.0)
+	w.broadPhase.Update(w.Bodies)
+	w.broadPhase.Pairs(func(bodyA, bodyB *RigidBody) {
+		// Пропускаем если оба статичные
+		if bodyA.Type == Static && bodyB.Type == Static {
+			return
+		}
+		if !bodyA.CanCollideWith(bodyB) {
+			return
+		}
+		if len(w.jointPairs) > 0 && w.jointPairs[pairKey(bodyA, bodyB)] {
+			return
+		}
 
-	if a.Type == Dynamic {
-		invMassA = 1.0 / a.Mass
-	}
-	if b.Type == Dynamic {
-		invMassB = 1.0 / b.Mass
-	}
+		// Спящую пару без бодрствующих тел не проверяем: спящие тела сохраняют
+		// связь острова по пересечению AABB
+		if isDormantPair(bodyA, bodyB) {
+			if !bodyA.IsTrigger && !bodyB.IsTrigger {
+				w.islands.union(bodyA, bodyB)
+			}
+			return
+		}
 
-	j := -(1 + restitution) * velAlongNormal
-	j /= invMassA + invMassB
+		if bodyA.IsTrigger || bodyB.IsTrigger {
+			w.triggers = collideBodies(bodyA, bodyB, w.triggers)
+			return
+		}
+		w.contacts = collideBodies(bodyA, bodyB, w.contacts)
+	})
 
-	impulse := normal.Mul(j)
+	w.updateGrounded()
+}
 
-	if a.Type == Dynamic {
-		a.Velocity = a.Velocity.Sub(impulse.Mul(invMassA))
-	}
-	if b.Type == Dynamic {
-		b.Velocity = b.Velocity.Add(impulse.Mul(invMassB))
+// updateGrounded выставляет IsGrounded телам, которые опираются на контакт
+// с нормалью против гравитации. Более крутые поверхности считаются стенами
+func (w *PhysicsWorld) updateGrounded() {
+	up := mgl32.Vec3{0, 1, 0}
+	if w.Gravity.LenSqr() > 0 {
+		up = w.Gravity.Normalize().Mul(-1)
 	}
 
-	// Применяем небольшое вращение для реалистичности
-	if a.Type == Dynamic && distance > 0.1 {
-		torque := normal.Cross(mgl32.Vec3{1, 0, 0}).Mul(velAlongNormal * 0.1)
-		a.AngularVelocity = a.AngularVelocity.Add(torque)
+	// Спящие тела сохраняют опору с момента засыпания
+	for _, body := range w.Bodies {
+		if !body.sleeping {
+			body.IsGrounded = false
+		}
 	}
-	if b.Type == Dynamic && distance > 0.1 {
-		torque := normal.Cross(mgl32.Vec3{1, 0, 0}).Mul(-velAlongNormal * 0.1)
-		b.AngularVelocity = b.AngularVelocity.Add(torque)
+	for i := range w.contacts {
+		manifold := &w.contacts[i]
+		// Нормаль направлена от A к B: вверх - B стоит на A, вниз - наоборот
+		slope := manifold.Normal.Dot(up)
+		if slope >= groundCosine {
+			manifold.BodyB.IsGrounded = true
+		} else if slope <= -groundCosine {
+			manifold.BodyA.IsGrounded = true
+		}
+	}
+}
+
+// GetContacts возвращает контакты, найденные на последнем шаге
+func (w *PhysicsWorld) GetContacts() []ContactManifold {
+	return w.contacts
+}
+
+// pairKey возвращает ключ пары тел, не зависящий от порядка
+func pairKey(a, b *RigidBody) contactKey {
+	if a.ID > b.ID {
+		a, b = b, a
 	}
+	return contactKey{a: a.ID, b: b.ID}
 }
@@ -0,0 +1,241 @@
+// Package physics2d физика твердых тел в плоскости для игр с видом сверху
+// и платформеров: круги, выпуклые многоугольники, отрезки и тайловые карты.
+// Устроена так же, как трехмерный пакет physics: тела тех же типов,
+// сущность ECS в теле и события коллизий через event.EventBus
+package physics2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// BodyType тип тела
+type BodyType int
+
+const (
+	Static    BodyType = iota // Статичное (не двигается)
+	Dynamic                   // Динамическое (подвержено физике)
+	Kinematic                 // Кинематическое: движется со своей скоростью, но не реагирует на удары
+)
+
+// Слои столкновений: тела сталкиваются, если слой каждого входит в маску другого
+const (
+	DefaultLayer uint32 = 1 << 0     // Слой новых тел
+	AllLayers    uint32 = 0xFFFFFFFF // Маска, пропускающая все слои
+)
+
+// Body тело в плоскости. Положение - точка отсчета локальных координат формы
+// и центр вращения
+type Body struct {
+	// Трансформация
+	Position mgl32.Vec2
+	Angle    float32 // Поворот против часовой стрелки, рад
+
+	// Физические свойства
+	Velocity        mgl32.Vec2
+	AngularVelocity float32 // рад/с
+	Mass            float32
+	Restitution     float32 // Коэффициент отскока (0-1)
+	Friction        float32 // Коэффициент трения (0-1)
+	LinearDamping   float32 // Затухание линейной скорости, 1/с
+	AngularDamping  float32 // Затухание угловой скорости, 1/с
+	GravityScale    float32 // Множитель гравитации мира; 0 - для вида сверху
+	FixedRotation   bool    // Тело не вращается от ударов
+
+	// Тип и форма
+	Type  BodyType
+	Shape Shape
+
+	// Фильтрация столкновений
+	Layer     uint32 // Слои, к которым относится тело
+	Mask      uint32 // Слои, с которыми тело сталкивается
+	IsTrigger bool   // Датчик: сообщает о пересечениях, но не участвует в столкновениях
+
+	// Флаги
+	IsGrounded bool // Стоит на опоре: контакт с нормалью против гравитации на последнем шаге
+
+	// Сущность ECS, связанная с телом (передается в событиях коллизий)
+	EntityID uint64
+
+	// Для отладки
+	ID   int
+	Name string
+
+	// Силы, накопленные до следующего шага
+	force  mgl32.Vec2
+	torque float32
+
+	// Псевдоскорости коррекции проникновения (split impulse), живут один шаг
+	pseudoVelocity mgl32.Vec2
+	pseudoAngular  float32
+
+	collider collider // Форма в мировых координатах на текущем шаге
+}
+
+// NewBody создает тело с формой shape
+func NewBody(bodyType BodyType, shape Shape) *Body {
+	return &Body{
+		Mass:         1.0,
+		Restitution:  0.2,
+		Friction:     0.5,
+		GravityScale: 1,
+		Type:         bodyType,
+		Shape:        shape,
+		Layer:        DefaultLayer,
+		Mask:         AllLayers,
+	}
+}
+
+// CanCollideWith проверяет слои и маски пары тел
+func (b *Body) CanCollideWith(other *Body) bool {
+	return b.Layer&other.Mask != 0 && other.Layer&b.Mask != 0
+}
+
+// ApplyForce прикладывает силу к центру тела до следующего шага
+func (b *Body) ApplyForce(force mgl32.Vec2) {
+	if b.Type != Dynamic {
+		return
+	}
+	b.force = b.force.Add(force)
+}
+
+// ApplyForceAtPoint прикладывает силу в точке мира до следующего шага
+func (b *Body) ApplyForceAtPoint(force, point mgl32.Vec2) {
+	if b.Type != Dynamic {
+		return
+	}
+	b.force = b.force.Add(force)
+	b.torque += cross(point.Sub(b.Position), force)
+}
+
+// ApplyTorque прикладывает момент до следующего шага
+func (b *Body) ApplyTorque(torque float32) {
+	if b.Type != Dynamic {
+		return
+	}
+	b.torque += torque
+}
+
+// ApplyImpulse сразу меняет скорость тела импульсом
+func (b *Body) ApplyImpulse(impulse mgl32.Vec2) {
+	b.Velocity = b.Velocity.Add(impulse.Mul(b.inverseMass()))
+}
+
+// ApplyImpulseAtPoint применяет импульс в точке мира, меняя и линейную,
+// и угловую скорость
+func (b *Body) ApplyImpulseAtPoint(impulse, point mgl32.Vec2) {
+	b.Velocity = b.Velocity.Add(impulse.Mul(b.inverseMass()))
+	b.AngularVelocity += b.inverseInertia() * cross(point.Sub(b.Position), impulse)
+}
+
+// GetPointVelocity возвращает скорость точки тела в мировых координатах
+func (b *Body) GetPointVelocity(point mgl32.Vec2) mgl32.Vec2 {
+	return b.Velocity.Add(crossSV(b.AngularVelocity, point.Sub(b.Position)))
+}
+
+// GetInertia возвращает момент инерции тела относительно Position.
+// Зависит от формы и массы
+func (b *Body) GetInertia() float32 {
+	if b.Shape == nil {
+		return 0
+	}
+	return b.Shape.inertia(b.Mass)
+}
+
+// GetBounds возвращает AABB тела в мировых координатах
+func (b *Body) GetBounds() AABB {
+	if b.Shape == nil {
+		return AABB{Min: b.Position, Max: b.Position}
+	}
+	return b.Shape.bounds(b.Position, newRotation(b.Angle))
+}
+
+// ToWorld переводит точку из локальных координат тела в мировые
+func (b *Body) ToWorld(local mgl32.Vec2) mgl32.Vec2 {
+	return b.Position.Add(newRotation(b.Angle).apply(local))
+}
+
+// GetModelMatrix возвращает матрицу модели для рендеринга в плоскости XY
+func (b *Body) GetModelMatrix() mgl32.Mat4 {
+	return mgl32.Translate3D(b.Position.X(), b.Position.Y(), 0).Mul4(mgl32.HomogRotate3DZ(b.Angle))
+}
+
+// inverseMass возвращает обратную массу; у статических и кинематических тел она нулевая
+func (b *Body) inverseMass() float32 {
+	if b.Type != Dynamic || b.Mass <= 0 {
+		return 0
+	}
+	return 1 / b.Mass
+}
+
+// inverseInertia возвращает обратный момент инерции
+func (b *Body) inverseInertia() float32 {
+	if b.Type != Dynamic || b.FixedRotation {
+		return 0
+	}
+	inertia := b.GetInertia()
+	if inertia <= 1e-12 {
+		return 0
+	}
+	return 1 / inertia
+}
+
+// rotation поворот в плоскости: косинус и синус угла
+type rotation struct {
+	c, s float32
+}
+
+// newRotation возвращает поворот на угол angle
+func newRotation(angle float32) rotation {
+	sin, cos := math.Sincos(float64(angle))
+	return rotation{c: float32(cos), s: float32(sin)}
+}
+
+// apply поворачивает вектор
+func (r rotation) apply(v mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{r.c*v.X() - r.s*v.Y(), r.s*v.X() + r.c*v.Y()}
+}
+
+// cross псевдоскалярное произведение векторов
+func cross(a, b mgl32.Vec2) float32 {
+	return a.X()*b.Y() - a.Y()*b.X()
+}
+
+// crossSV произведение угловой скорости на вектор: ω × r
+func crossSV(s float32, v mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{-s * v.Y(), s * v.X()}
+}
+
+// perp возвращает вектор, повернутый на 90° по часовой стрелке:
+// внешняя нормаль ребра многоугольника с обходом против часовой
+func perp(v mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{v.Y(), -v.X()}
+}
+
+// safeNormalize нормализует вектор, возвращая ось X для нулевого
+func safeNormalize(v mgl32.Vec2) mgl32.Vec2 {
+	length := v.Len()
+	if length < 1e-8 {
+		return mgl32.Vec2{1, 0}
+	}
+	return v.Mul(1 / length)
+}
+
+// abs32 модуль float32
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// clamp32 ограничивает значение отрезком
+func clamp32(x, lo, hi float32) float32 {
+	return max(lo, min(x, hi))
+}
+
+// sqrt32 квадратный корень float32
+func sqrt32(x float32) float32 {
+	return float32(math.Sqrt(float64(x)))
+}
@@ -0,0 +1,58 @@
+package physics2d
+
+import (
+	"sort"
+)
+
+// sapEntry тело и его AABB на текущем шаге
+type sapEntry struct {
+	body  *Body
+	index int // Порядок тела в мире для воспроизводимости пар
+	box   AABB
+}
+
+// sweepAndPrune широкая фаза: тела сортируются по левой границе AABB, пары
+// ищутся проходом вдоль оси X. В плоских уровнях тел немного, и этого хватает
+type sweepAndPrune struct {
+	entries []sapEntry
+}
+
+// update пересчитывает AABB тел с формой и сортирует их по X
+func (s *sweepAndPrune) update(bodies []*Body) {
+	s.entries = s.entries[:0]
+	for i, body := range bodies {
+		if body.Shape == nil {
+			continue
+		}
+		s.entries = append(s.entries, sapEntry{body: body, index: i, box: body.GetBounds()})
+	}
+
+	sort.SliceStable(s.entries, func(i, j int) bool {
+		return s.entries[i].box.Min.X() < s.entries[j].box.Min.X()
+	})
+}
+
+// pairs вызывает callback для пар с пересекающимися AABB.
+// Тело с меньшим индексом в мире передается первым
+func (s *sweepAndPrune) pairs(callback func(a, b *Body)) {
+	for i := range s.entries {
+		a := &s.entries[i]
+		for j := i + 1; j < len(s.entries); j++ {
+			b := &s.entries[j]
+			if b.box.Min.X() > a.box.Max.X() {
+				break
+			}
+			if a.body.Type == Static && b.body.Type == Static {
+				continue
+			}
+			if !a.box.Overlaps(b.box) {
+				continue
+			}
+			if a.index < b.index {
+				callback(a.body, b.body)
+			} else {
+				callback(b.body, a.body)
+			}
+		}
+	}
+}
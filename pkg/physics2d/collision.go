@@ -0,0 +1,319 @@
+package physics2d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Параметры узкой фазы
+const (
+	linearSlop    = 0.005 // Допустимое проникновение, м
+	contactMargin = 0.01  // Контакты строятся чуть раньше касания, чтобы решатель видел их заранее
+	noFeature     = -1    // feature манифолдов без тайлов
+)
+
+// ContactPoint точка контакта
+type ContactPoint struct {
+	Position mgl32.Vec2
+	Depth    float32 // Глубина проникновения; отрицательная - зазор до касания
+	id       uint32  // Пара ребер, образовавших точку, для прогрева
+}
+
+// Manifold контакт двух тел: нормаль от A к B и до двух точек
+type Manifold struct {
+	BodyA, BodyB *Body
+	Normal       mgl32.Vec2
+	Points       []ContactPoint
+	feature      int32 // Тайл карты; разные тайлы одной пары - разные манифолды
+}
+
+// collideBodies строит манифолды пары тел и добавляет их в out.
+// Пары упорядочиваются так, чтобы форма A была не сложнее формы B
+func collideBodies(a, b *Body, out []Manifold) []Manifold {
+	ca, cb := &a.collider, &b.collider
+	if ca.kind > cb.kind {
+		ca, cb = cb, ca
+	}
+
+	if cb.kind == kindTilemap {
+		if ca.kind == kindTilemap {
+			return out
+		}
+		return collideTilemap(cb, ca, out)
+	}
+
+	manifold, ok := collide(ca, cb)
+	if !ok {
+		return out
+	}
+	manifold.feature = noFeature
+	return append(out, manifold)
+}
+
+// collide проверяет пару выпуклых коллайдеров; kind a не больше kind b
+func collide(a, b *collider) (Manifold, bool) {
+	switch {
+	case a.kind == kindCircle && b.kind == kindCircle:
+		return collideCircles(a, b)
+	case a.kind == kindCircle:
+		manifold, ok := collidePolygonCircle(b.body, b.vertices, b.normals, a)
+		return manifold.flip(), ok
+	}
+	return collidePolygons(a.body, a.vertices, a.normals, b.body, b.vertices, b.normals)
+}
+
+// flip меняет тела манифолда местами
+func (m Manifold) flip() Manifold {
+	m.BodyA, m.BodyB = m.BodyB, m.BodyA
+	m.Normal = m.Normal.Mul(-1)
+	return m
+}
+
+// collideCircles круг-круг
+func collideCircles(a, b *collider) (Manifold, bool) {
+	delta := b.center.Sub(a.center)
+	radius := a.radius + b.radius
+	distanceSq := delta.Dot(delta)
+	if distanceSq > (radius+contactMargin)*(radius+contactMargin) {
+		return Manifold{}, false
+	}
+
+	distance := float32(0)
+	normal := mgl32.Vec2{0, 1}
+	if distanceSq > 1e-12 {
+		distance = sqrt32(distanceSq)
+		normal = delta.Mul(1 / distance)
+	}
+	// Точка посередине между поверхностями
+	point := a.center.Add(normal.Mul(a.radius - (radius-distance)/2))
+	return Manifold{
+		BodyA:  a.body,
+		BodyB:  b.body,
+		Normal: normal,
+		Points: []ContactPoint{{Position: point, Depth: radius - distance}},
+	}, true
+}
+
+// collidePolygonCircle многоугольник (или отрезок) - круг. Нормаль от многоугольника к кругу
+func collidePolygonCircle(body *Body, vertices, normals []mgl32.Vec2, circle *collider) (Manifold, bool) {
+	// Ребро с наибольшим отделением центра
+	separation := float32(-1e30)
+	edge := 0
+	for i, n := range normals {
+		s := n.Dot(circle.center.Sub(vertices[i]))
+		if s > circle.radius+contactMargin {
+			return Manifold{}, false
+		}
+		if s > separation {
+			separation = s
+			edge = i
+		}
+	}
+
+	v1 := vertices[edge]
+	v2 := vertices[(edge+1)%len(vertices)]
+	normal := normals[edge]
+
+	// Центр внутри многоугольника или напротив ребра - нормаль ребра,
+	// в области вершины - направление от вершины к центру
+	point := circle.center.Sub(normal.Mul(separation))
+	if separation > 1e-6 {
+		vertex, inRegion := v1, circle.center.Sub(v1).Dot(v2.Sub(v1)) <= 0
+		if !inRegion {
+			vertex, inRegion = v2, circle.center.Sub(v2).Dot(v1.Sub(v2)) <= 0
+		}
+		if inRegion {
+			delta := circle.center.Sub(vertex)
+			separation = delta.Len()
+			if separation > circle.radius+contactMargin {
+				return Manifold{}, false
+			}
+			normal = safeNormalize(delta)
+			point = vertex
+		}
+	}
+
+	return Manifold{
+		BodyA:  body,
+		BodyB:  circle.body,
+		Normal: normal,
+		Points: []ContactPoint{{
+			Position: point,
+			Depth:    circle.radius - separation,
+			id:       uint32(edge),
+		}},
+	}, true
+}
+
+// collidePolygons многоугольник-многоугольник: SAT по нормалям ребер и отсечение
+// ребра-инцидента боковыми плоскостями опорного ребра, как в Box2D
+func collidePolygons(bodyA *Body, verticesA, normalsA []mgl32.Vec2, bodyB *Body, verticesB, normalsB []mgl32.Vec2) (Manifold, bool) {
+	edgeA, separationA := maxSeparation(verticesA, normalsA, verticesB)
+	if separationA > contactMargin {
+		return Manifold{}, false
+	}
+	edgeB, separationB := maxSeparation(verticesB, normalsB, verticesA)
+	if separationB > contactMargin {
+		return Manifold{}, false
+	}
+
+	// Опорное ребро выбирается с небольшим допуском в пользу A, чтобы нормаль не прыгала
+	refVertices, refNormals, incVertices, incNormals := verticesA, normalsA, verticesB, normalsB
+	edge := edgeA
+	flip := false
+	if separationB > 0.98*separationA+0.1*linearSlop {
+		refVertices, refNormals, incVertices, incNormals = verticesB, normalsB, verticesA, normalsA
+		edge = edgeB
+		flip = true
+	}
+	normal := refNormals[edge]
+
+	// Ребро-инцидент: нормаль наиболее противоположна опорной
+	incident := 0
+	best := float32(1e30)
+	for i, n := range incNormals {
+		if d := n.Dot(normal); d < best {
+			best = d
+			incident = i
+		}
+	}
+	clip := [2]clipVertex{
+		{point: incVertices[incident], id: uint32(incident)},
+		{point: incVertices[(incident+1)%len(incVertices)], id: uint32((incident + 1) % len(incVertices))},
+	}
+
+	v1 := refVertices[edge]
+	v2 := refVertices[(edge+1)%len(refVertices)]
+	tangent := safeNormalize(v2.Sub(v1))
+
+	// Отсечение боковыми плоскостями опорного ребра
+	var ok bool
+	if clip, ok = clipSegment(clip, tangent.Mul(-1), -tangent.Dot(v1), uint32(edge)); !ok {
+		return Manifold{}, false
+	}
+	if clip, ok = clipSegment(clip, tangent, tangent.Dot(v2), uint32(edge)); !ok {
+		return Manifold{}, false
+	}
+
+	manifold := Manifold{BodyA: bodyA, BodyB: bodyB, Normal: normal}
+	offset := normal.Dot(v1)
+	for _, cv := range clip {
+		separation := normal.Dot(cv.point) - offset
+		if separation > contactMargin {
+			continue
+		}
+		// Точка посередине между поверхностями
+		manifold.Points = append(manifold.Points, ContactPoint{
+			Position: cv.point.Sub(normal.Mul(separation / 2)),
+			Depth:    -separation,
+			id:       uint32(edge)<<16 | cv.id,
+		})
+	}
+	if len(manifold.Points) == 0 {
+		return Manifold{}, false
+	}
+	if flip {
+		manifold.Normal = manifold.Normal.Mul(-1)
+	}
+	return manifold, true
+}
+
+// maxSeparation возвращает ребро многоугольника с наибольшим отделением другого многоугольника
+func maxSeparation(vertices, normals, other []mgl32.Vec2) (int, float32) {
+	best := 0
+	separation := float32(-1e30)
+	for i, n := range normals {
+		// Самая глубокая точка другого многоугольника вдоль -n
+		deepest := float32(1e30)
+		for _, v := range other {
+			deepest = min(deepest, n.Dot(v.Sub(vertices[i])))
+		}
+		if deepest > separation {
+			separation = deepest
+			best = i
+		}
+	}
+	return best, separation
+}
+
+// clipVertex вершина отрезка при отсечении
+type clipVertex struct {
+	point mgl32.Vec2
+	id    uint32
+}
+
+// clipSegment оставляет часть отрезка, для которой normal·p <= offset
+func clipSegment(in [2]clipVertex, normal mgl32.Vec2, offset float32, id uint32) ([2]clipVertex, bool) {
+	d0 := normal.Dot(in[0].point) - offset
+	d1 := normal.Dot(in[1].point) - offset
+
+	var out [2]clipVertex
+	count := 0
+	if d0 <= 0 {
+		out[count] = in[0]
+		count++
+	}
+	if d1 <= 0 {
+		out[count] = in[1]
+		count++
+	}
+	if d0*d1 < 0 && count < 2 {
+		t := d0 / (d0 - d1)
+		out[count] = clipVertex{
+			point: in[0].point.Add(in[1].point.Sub(in[0].point).Mul(t)),
+			id:    id | 0x8000,
+		}
+		count++
+	}
+	return out, count == 2
+}
+
+// collideTilemap строит манифолды тела с тайлами карты под его AABB.
+// Нормали, смотрящие в соседний сплошной тайл, отбрасываются: внутренние
+// стороны сетки не должны цеплять скользящие тела
+func collideTilemap(tiles, other *collider, out []Manifold) []Manifold {
+	tilemap := tiles.tilemap
+	box := other.body.GetBounds()
+	margin := mgl32.Vec2{contactMargin, contactMargin}
+	box.Min, box.Max = box.Min.Sub(margin), box.Max.Add(margin)
+	x0, y0, x1, y1 := tilemap.tileRange(tiles.origin, box)
+
+	var vertices, normals []mgl32.Vec2
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if !tilemap.IsSolid(x, y) {
+				continue
+			}
+			vertices, normals = tilemap.tileBox(tiles.origin, x, y, vertices, normals)
+
+			var manifold Manifold
+			var ok bool
+			if other.kind == kindCircle {
+				manifold, ok = collidePolygonCircle(tiles.body, vertices, normals, other)
+			} else {
+				manifold, ok = collidePolygons(tiles.body, vertices, normals, other.body, other.vertices, other.normals)
+			}
+			if !ok || internalNormal(tilemap, x, y, manifold.Normal) {
+				continue
+			}
+			manifold.feature = int32(y*tilemap.Columns + x)
+			out = append(out, manifold)
+		}
+	}
+	return out
+}
+
+// internalNormal сообщает, что нормаль от тайла (x, y) смотрит в соседний сплошной тайл
+func internalNormal(tilemap *Tilemap, x, y int, normal mgl32.Vec2) bool {
+	const axis = 0.7
+	switch {
+	case normal.X() > axis:
+		return tilemap.IsSolid(x+1, y)
+	case normal.X() < -axis:
+		return tilemap.IsSolid(x-1, y)
+	case normal.Y() > axis:
+		return tilemap.IsSolid(x, y+1)
+	case normal.Y() < -axis:
+		return tilemap.IsSolid(x, y-1)
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package physics2d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
+)
+
+// touchingPair пара касающихся тел на шаге и данные для ее событий
+type touchingPair struct {
+	key  contactKey
+	data *event.CollisionData
+}
+
+// SetEventBus задает шину, в которую мир публикует EventCollisionEnter,
+// EventCollisionStay и EventCollisionExit. nil отключает события.
+// Point и Normal событий лежат в плоскости XY с нулевой Z
+func (w *World) SetEventBus(bus *event.EventBus) {
+	w.eventBus = bus
+	w.touching = w.touching[:0]
+	clear(w.touchingKeys)
+}
+
+// GetEventBus возвращает шину событий коллизий
+func (w *World) GetEventBus() *event.EventBus {
+	return w.eventBus
+}
+
+// GetTriggerOverlaps возвращает пересечения с датчиками на последнем шаге
+func (w *World) GetTriggerOverlaps() []Manifold {
+	return w.triggers
+}
+
+// emitCollisionEvents сравнивает касания с прошлым шагом: новые пары получают
+// enter, сохранившиеся - stay, пропавшие - exit. События отправляются синхронно
+// после шага, поэтому обработчики могут менять тела и мир
+func (w *World) emitCollisionEvents() {
+	if w.eventBus == nil {
+		return
+	}
+
+	previous, previousKeys := w.touching, w.touchingKeys
+	w.touching, w.touchingKeys = w.previousTouching[:0], w.previousKeys
+	clear(w.touchingKeys)
+
+	w.collectTouching(w.contacts, false)
+	w.collectTouching(w.triggers, true)
+
+	var pending []*event.Event
+	for _, pair := range w.touching {
+		if previousKeys[pair.key] {
+			pending = append(pending, event.NewEvent(event.EventCollisionStay, pair.data))
+		} else {
+			pending = append(pending, event.NewEvent(event.EventCollisionEnter, pair.data))
+		}
+	}
+	for _, pair := range previous {
+		if !w.touchingKeys[pair.key] {
+			pending = append(pending, event.NewEvent(event.EventCollisionExit, pair.data))
+		}
+	}
+
+	w.previousTouching, w.previousKeys = previous, previousKeys
+
+	for _, e := range pending {
+		w.eventBus.EmitSync(e)
+	}
+}
+
+// collectTouching добавляет пары из манифолдов; несколько манифолдов пары
+// (тайлы одной карты) дают одно событие. Контакты с зазором касанием не считаются
+func (w *World) collectTouching(manifolds []Manifold, trigger bool) {
+	for i := range manifolds {
+		manifold := &manifolds[i]
+		key := pairKey(manifold.BodyA, manifold.BodyB)
+		if w.touchingKeys[key] {
+			continue
+		}
+
+		// Самая глубокая точка контакта
+		var point ContactPoint
+		for j, p := range manifold.Points {
+			if j == 0 || p.Depth > point.Depth {
+				point = p
+			}
+		}
+		if point.Depth < 0 {
+			continue
+		}
+		w.touchingKeys[key] = true
+
+		w.touching = append(w.touching, touchingPair{
+			key: key,
+			data: &event.CollisionData{
+				EntityA:   manifold.BodyA.EntityID,
+				EntityB:   manifold.BodyB.EntityID,
+				BodyA:     manifold.BodyA,
+				BodyB:     manifold.BodyB,
+				Point:     mgl32.Vec3{point.Position.X(), point.Position.Y(), 0},
+				Normal:    mgl32.Vec3{manifold.Normal.X(), manifold.Normal.Y(), 0},
+				IsTrigger: trigger,
+			},
+		})
+	}
+}
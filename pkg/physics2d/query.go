@@ -0,0 +1,242 @@
+package physics2d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// QueryFilter отбирает тела для запросов к миру. nil - подходят все тела
+type QueryFilter func(body *Body) bool
+
+// LayerFilter возвращает фильтр тел, входящих хотя бы в один слой маски
+func LayerFilter(mask uint32) QueryFilter {
+	return func(body *Body) bool {
+		return body.Layer&mask != 0
+	}
+}
+
+// RaycastHit попадание луча в тело
+type RaycastHit struct {
+	Body     *Body
+	Point    mgl32.Vec2 // Точка на поверхности тела
+	Normal   mgl32.Vec2 // Нормаль поверхности тела в точке попадания
+	Distance float32    // Расстояние вдоль луча до попадания
+}
+
+// Raycast находит ближайшее тело на луче из origin в направлении direction
+// не дальше maxDistance. Тела, внутри которых начинается луч, не учитываются
+func (w *World) Raycast(origin, direction mgl32.Vec2, maxDistance float32, filter QueryFilter) (RaycastHit, bool) {
+	var closest RaycastHit
+	found := false
+
+	w.raycast(origin, direction, maxDistance, filter, func(hit RaycastHit) {
+		if !found || hit.Distance < closest.Distance {
+			closest = hit
+			found = true
+		}
+	})
+	return closest, found
+}
+
+// RaycastAll находит все тела на луче не дальше maxDistance, отсортированные по расстоянию
+func (w *World) RaycastAll(origin, direction mgl32.Vec2, maxDistance float32, filter QueryFilter) []RaycastHit {
+	var hits []RaycastHit
+
+	w.raycast(origin, direction, maxDistance, filter, func(hit RaycastHit) {
+		hits = append(hits, hit)
+	})
+
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+	return hits
+}
+
+// QueryAABB возвращает тела, AABB которых пересекает box
+func (w *World) QueryAABB(box AABB, filter QueryFilter) []*Body {
+	var bodies []*Body
+	for _, body := range w.Bodies {
+		if body.Shape == nil || (filter != nil && !filter(body)) {
+			continue
+		}
+		if body.GetBounds().Overlaps(box) {
+			bodies = append(bodies, body)
+		}
+	}
+	return bodies
+}
+
+// raycast проверяет луч с телами, AABB которых пересекает отрезок луча,
+// и передает точные попадания в report
+func (w *World) raycast(origin, direction mgl32.Vec2, maxDistance float32, filter QueryFilter, report func(hit RaycastHit)) {
+	length := direction.Len()
+	if length < 1e-8 || maxDistance <= 0 {
+		return
+	}
+	direction = direction.Mul(1 / length)
+
+	end := origin.Add(direction.Mul(maxDistance))
+	segment := AABB{Min: origin, Max: origin}.Merge(AABB{Min: end, Max: end})
+
+	for _, body := range w.Bodies {
+		if body.Shape == nil || (filter != nil && !filter(body)) {
+			continue
+		}
+		if !body.GetBounds().Overlaps(segment) {
+			continue
+		}
+
+		body.collider.update(body)
+		normal, distance, ok := body.collider.raycast(origin, direction, maxDistance)
+		if !ok {
+			continue
+		}
+		report(RaycastHit{
+			Body:     body,
+			Point:    origin.Add(direction.Mul(distance)),
+			Normal:   normal,
+			Distance: distance,
+		})
+	}
+}
+
+// raycast пересекает луч с формой в мировых координатах. direction нормализован
+func (c *collider) raycast(origin, direction mgl32.Vec2, maxDistance float32) (mgl32.Vec2, float32, bool) {
+	switch {
+	case c.kind == kindCircle:
+		return raycastCircle(c.center, c.radius, origin, direction, maxDistance)
+	case c.kind == kindTilemap:
+		return raycastTilemap(c.tilemap, c.origin, origin, direction, maxDistance)
+	case len(c.vertices) == 2:
+		return raycastSegment(c.vertices[0], c.vertices[1], origin, direction, maxDistance)
+	}
+	return raycastPolygon(c.vertices, c.normals, origin, direction, maxDistance)
+}
+
+// raycastCircle луч-круг
+func raycastCircle(center mgl32.Vec2, radius float32, origin, direction mgl32.Vec2, maxDistance float32) (mgl32.Vec2, float32, bool) {
+	offset := origin.Sub(center)
+	c := offset.Dot(offset) - radius*radius
+	if c <= 0 {
+		return mgl32.Vec2{}, 0, false
+	}
+	b := offset.Dot(direction)
+	discriminant := b*b - c
+	if b > 0 || discriminant < 0 {
+		return mgl32.Vec2{}, 0, false
+	}
+
+	t := -b - sqrt32(discriminant)
+	if t > maxDistance {
+		return mgl32.Vec2{}, 0, false
+	}
+	normal := safeNormalize(offset.Add(direction.Mul(t)))
+	return normal, t, true
+}
+
+// raycastPolygon луч - выпуклый многоугольник: отсечение луча полуплоскостями ребер
+func raycastPolygon(vertices, normals []mgl32.Vec2, origin, direction mgl32.Vec2, maxDistance float32) (mgl32.Vec2, float32, bool) {
+	lower, upper := float32(0), maxDistance
+	entry := -1
+	for i, n := range normals {
+		numerator := n.Dot(vertices[i].Sub(origin))
+		denominator := n.Dot(direction)
+
+		if denominator == 0 {
+			if numerator < 0 {
+				return mgl32.Vec2{}, 0, false
+			}
+			continue
+		}
+		t := numerator / denominator
+		if denominator < 0 && t > lower {
+			lower = t
+			entry = i
+		} else if denominator > 0 && t < upper {
+			upper = t
+		}
+		if upper < lower {
+			return mgl32.Vec2{}, 0, false
+		}
+	}
+
+	// entry < 0: луч начинается внутри
+	if entry < 0 {
+		return mgl32.Vec2{}, 0, false
+	}
+	return normals[entry], lower, true
+}
+
+// raycastSegment луч - двусторонний отрезок. Нормаль смотрит навстречу лучу
+func raycastSegment(a, b, origin, direction mgl32.Vec2, maxDistance float32) (mgl32.Vec2, float32, bool) {
+	edge := b.Sub(a)
+	denominator := cross(direction, edge)
+	if abs32(denominator) < 1e-8 {
+		return mgl32.Vec2{}, 0, false
+	}
+
+	offset := a.Sub(origin)
+	t := cross(offset, edge) / denominator
+	s := cross(offset, direction) / denominator
+	if t < 0 || t > maxDistance || s < 0 || s > 1 {
+		return mgl32.Vec2{}, 0, false
+	}
+
+	normal := safeNormalize(perp(edge))
+	if normal.Dot(direction) > 0 {
+		normal = normal.Mul(-1)
+	}
+	return normal, t, true
+}
+
+// raycastTilemap идет лучом по клеткам карты (DDA) до первого сплошного тайла.
+// Тайлы, в которых начинается луч, пропускаются до выхода в пустую клетку
+func raycastTilemap(tilemap *Tilemap, mapOrigin, origin, direction mgl32.Vec2, maxDistance float32) (mgl32.Vec2, float32, bool) {
+	size := tilemap.TileSize
+	local := origin.Sub(mapOrigin)
+	x := int(math.Floor(float64(local.X() / size)))
+	y := int(math.Floor(float64(local.Y() / size)))
+
+	stepX, nextX, deltaX := ddaAxis(local.X(), direction.X(), size, x)
+	stepY, nextY, deltaY := ddaAxis(local.Y(), direction.Y(), size, y)
+
+	inside := tilemap.IsSolid(x, y)
+	for {
+		var t float32
+		var normal mgl32.Vec2
+		if nextX < nextY {
+			t = nextX
+			x += stepX
+			nextX += deltaX
+			normal = mgl32.Vec2{float32(-stepX), 0}
+		} else {
+			t = nextY
+			y += stepY
+			nextY += deltaY
+			normal = mgl32.Vec2{0, float32(-stepY)}
+		}
+		if t > maxDistance {
+			return mgl32.Vec2{}, 0, false
+		}
+
+		solid := tilemap.IsSolid(x, y)
+		if solid && !inside {
+			return normal, t, true
+		}
+		inside = solid
+	}
+}
+
+// ddaAxis возвращает шаг по клеткам, расстояние вдоль луча до первой границы
+// клетки и расстояние между границами для одной оси
+func ddaAxis(position, direction, size float32, cell int) (step int, next, delta float32) {
+	switch {
+	case direction > 0:
+		return 1, (float32(cell+1)*size - position) / direction, size / direction
+	case direction < 0:
+		return -1, (float32(cell)*size - position) / direction, -size / direction
+	}
+	return 0, float32(math.Inf(1)), float32(math.Inf(1))
+}
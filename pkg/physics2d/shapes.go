@@ -0,0 +1,286 @@
+package physics2d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AABB прямоугольник, выровненный по осям
+type AABB struct {
+	Min mgl32.Vec2
+	Max mgl32.Vec2
+}
+
+// Overlaps проверяет пересечение с другим AABB
+func (a AABB) Overlaps(other AABB) bool {
+	return a.Min.X() <= other.Max.X() && a.Max.X() >= other.Min.X() &&
+		a.Min.Y() <= other.Max.Y() && a.Max.Y() >= other.Min.Y()
+}
+
+// Merge возвращает AABB, охватывающий оба прямоугольника
+func (a AABB) Merge(other AABB) AABB {
+	return AABB{
+		Min: mgl32.Vec2{min(a.Min.X(), other.Min.X()), min(a.Min.Y(), other.Min.Y())},
+		Max: mgl32.Vec2{max(a.Max.X(), other.Max.X()), max(a.Max.Y(), other.Max.Y())},
+	}
+}
+
+// Shape форма тела в его локальных координатах. Реализации: Circle, Polygon, Edge, Tilemap
+type Shape interface {
+	bounds(position mgl32.Vec2, rot rotation) AABB
+	inertia(mass float32) float32
+}
+
+// shapeKind вид формы для узкой фазы.
+// Порядок важен: пары упорядочиваются по возрастанию вида
+type shapeKind int
+
+const (
+	kindCircle shapeKind = iota
+	kindPolygon
+	kindTilemap
+)
+
+// Circle круг радиуса Radius с центром в Offset
+type Circle struct {
+	Radius float32
+	Offset mgl32.Vec2
+}
+
+// NewCircle создает круг с центром в начале координат тела
+func NewCircle(radius float32) *Circle {
+	return &Circle{Radius: radius}
+}
+
+func (c *Circle) bounds(position mgl32.Vec2, rot rotation) AABB {
+	center := position.Add(rot.apply(c.Offset))
+	half := mgl32.Vec2{c.Radius, c.Radius}
+	return AABB{Min: center.Sub(half), Max: center.Add(half)}
+}
+
+func (c *Circle) inertia(mass float32) float32 {
+	return mass * (c.Radius*c.Radius/2 + c.Offset.Dot(c.Offset))
+}
+
+// Polygon выпуклый многоугольник; вершины хранятся против часовой стрелки
+type Polygon struct {
+	vertices []mgl32.Vec2
+	normals  []mgl32.Vec2
+}
+
+// NewPolygon строит выпуклую оболочку точек. Нужно не меньше трех точек не на одной прямой.
+// Начало координат тела - центр масс, поэтому точки задаются вокруг него
+func NewPolygon(points []mgl32.Vec2) *Polygon {
+	return newPolygon(convexHull(points))
+}
+
+// NewBox создает прямоугольник width x height с центром в начале координат тела
+func NewBox(width, height float32) *Polygon {
+	w, h := width/2, height/2
+	return newPolygon([]mgl32.Vec2{{-w, -h}, {w, -h}, {w, h}, {-w, h}})
+}
+
+// newPolygon создает многоугольник по вершинам против часовой стрелки
+func newPolygon(vertices []mgl32.Vec2) *Polygon {
+	p := &Polygon{vertices: vertices}
+	p.normals = edgeNormals(vertices, make([]mgl32.Vec2, 0, len(vertices)))
+	return p
+}
+
+// GetVertices возвращает вершины многоугольника в локальных координатах тела
+func (p *Polygon) GetVertices() []mgl32.Vec2 {
+	return p.vertices
+}
+
+func (p *Polygon) bounds(position mgl32.Vec2, rot rotation) AABB {
+	return pointsBounds(p.vertices, position, rot)
+}
+
+func (p *Polygon) inertia(mass float32) float32 {
+	// Сумма треугольников (0, a, b) относительно начала координат тела
+	var area, moment float32
+	for i, a := range p.vertices {
+		b := p.vertices[(i+1)%len(p.vertices)]
+		c := cross(a, b)
+		area += c
+		moment += c * (a.Dot(a) + a.Dot(b) + b.Dot(b))
+	}
+	if area <= 1e-12 {
+		return 0
+	}
+	return mass * moment / (6 * area)
+}
+
+// Edge двусторонний отрезок от A до B. Подходит для земли, платформ и стен уровня
+type Edge struct {
+	A, B mgl32.Vec2
+}
+
+// NewEdge создает отрезок
+func NewEdge(a, b mgl32.Vec2) *Edge {
+	return &Edge{A: a, B: b}
+}
+
+func (e *Edge) bounds(position mgl32.Vec2, rot rotation) AABB {
+	return pointsBounds([]mgl32.Vec2{e.A, e.B}, position, rot)
+}
+
+func (e *Edge) inertia(mass float32) float32 {
+	// Тонкий стержень относительно начала координат тела
+	return mass / 3 * (e.A.Dot(e.A) + e.A.Dot(e.B) + e.B.Dot(e.B))
+}
+
+// Tilemap сетка сплошных квадратных тайлов для статических тел. Тайл (x, y)
+// занимает [x, x+1]*TileSize по X и [y, y+1]*TileSize по Y от Position тела;
+// поворот тела не учитывается. Внутренние стороны соседних тайлов не дают
+// контактов, поэтому тела скользят по ровному полу без зацепов
+type Tilemap struct {
+	Columns, Rows int
+	TileSize      float32
+	solid         []bool
+}
+
+// NewTilemap создает пустую карту columns x rows
+func NewTilemap(columns, rows int, tileSize float32) *Tilemap {
+	return &Tilemap{
+		Columns:  columns,
+		Rows:     rows,
+		TileSize: tileSize,
+		solid:    make([]bool, columns*rows),
+	}
+}
+
+// SetSolid делает тайл сплошным или пустым. Координаты вне карты игнорируются
+func (t *Tilemap) SetSolid(x, y int, solid bool) {
+	if x >= 0 && y >= 0 && x < t.Columns && y < t.Rows {
+		t.solid[y*t.Columns+x] = solid
+	}
+}
+
+// IsSolid сообщает, сплошной ли тайл. Вне карты тайлы пустые
+func (t *Tilemap) IsSolid(x, y int) bool {
+	return x >= 0 && y >= 0 && x < t.Columns && y < t.Rows && t.solid[y*t.Columns+x]
+}
+
+func (t *Tilemap) bounds(position mgl32.Vec2, _ rotation) AABB {
+	return AABB{
+		Min: position,
+		Max: position.Add(mgl32.Vec2{float32(t.Columns) * t.TileSize, float32(t.Rows) * t.TileSize}),
+	}
+}
+
+func (t *Tilemap) inertia(float32) float32 {
+	return 0
+}
+
+// tileBox возвращает многоугольник тайла в мировых координатах
+func (t *Tilemap) tileBox(origin mgl32.Vec2, x, y int, vertices, normals []mgl32.Vec2) ([]mgl32.Vec2, []mgl32.Vec2) {
+	lo := origin.Add(mgl32.Vec2{float32(x) * t.TileSize, float32(y) * t.TileSize})
+	hi := lo.Add(mgl32.Vec2{t.TileSize, t.TileSize})
+	vertices = append(vertices[:0], lo, mgl32.Vec2{hi.X(), lo.Y()}, hi, mgl32.Vec2{lo.X(), hi.Y()})
+	normals = append(normals[:0], mgl32.Vec2{0, -1}, mgl32.Vec2{1, 0}, mgl32.Vec2{0, 1}, mgl32.Vec2{-1, 0})
+	return vertices, normals
+}
+
+// tileRange возвращает диапазон тайлов, пересекающих AABB
+func (t *Tilemap) tileRange(origin mgl32.Vec2, box AABB) (x0, y0, x1, y1 int) {
+	x0 = max(int(math.Floor(float64((box.Min.X()-origin.X())/t.TileSize))), 0)
+	y0 = max(int(math.Floor(float64((box.Min.Y()-origin.Y())/t.TileSize))), 0)
+	x1 = min(int(math.Floor(float64((box.Max.X()-origin.X())/t.TileSize))), t.Columns-1)
+	y1 = min(int(math.Floor(float64((box.Max.Y()-origin.Y())/t.TileSize))), t.Rows-1)
+	return
+}
+
+// collider форма тела в мировых координатах на текущем шаге
+type collider struct {
+	kind     shapeKind
+	body     *Body
+	center   mgl32.Vec2   // Circle
+	radius   float32      // Circle
+	vertices []mgl32.Vec2 // Polygon, Edge: вершины против часовой стрелки в мире
+	normals  []mgl32.Vec2 // Внешние нормали ребер (для отрезка - две противоположные)
+	tilemap  *Tilemap
+	origin   mgl32.Vec2 // Tilemap: мировое положение угла карты
+}
+
+// update переводит форму тела в мировые координаты, переиспользуя срезы
+func (c *collider) update(body *Body) {
+	c.body = body
+	rot := newRotation(body.Angle)
+
+	switch shape := body.Shape.(type) {
+	case *Circle:
+		c.kind = kindCircle
+		c.center = body.Position.Add(rot.apply(shape.Offset))
+		c.radius = shape.Radius
+	case *Polygon:
+		c.kind = kindPolygon
+		c.vertices = c.vertices[:0]
+		c.normals = c.normals[:0]
+		for i, v := range shape.vertices {
+			c.vertices = append(c.vertices, body.Position.Add(rot.apply(v)))
+			c.normals = append(c.normals, rot.apply(shape.normals[i]))
+		}
+	case *Edge:
+		c.kind = kindPolygon
+		c.vertices = append(c.vertices[:0], body.Position.Add(rot.apply(shape.A)), body.Position.Add(rot.apply(shape.B)))
+		c.normals = edgeNormals(c.vertices, c.normals[:0])
+	case *Tilemap:
+		c.kind = kindTilemap
+		c.tilemap = shape
+		c.origin = body.Position
+	}
+}
+
+// edgeNormals добавляет к normals внешние нормали ребер многоугольника
+func edgeNormals(vertices, normals []mgl32.Vec2) []mgl32.Vec2 {
+	for i, v := range vertices {
+		next := vertices[(i+1)%len(vertices)]
+		normals = append(normals, safeNormalize(perp(next.Sub(v))))
+	}
+	return normals
+}
+
+// pointsBounds возвращает AABB точек после поворота и переноса
+func pointsBounds(points []mgl32.Vec2, position mgl32.Vec2, rot rotation) AABB {
+	first := position.Add(rot.apply(points[0]))
+	box := AABB{Min: first, Max: first}
+	for _, p := range points[1:] {
+		w := position.Add(rot.apply(p))
+		box = box.Merge(AABB{Min: w, Max: w})
+	}
+	return box
+}
+
+// convexHull строит выпуклую оболочку точек против часовой стрелки (монотонная цепочка)
+func convexHull(points []mgl32.Vec2) []mgl32.Vec2 {
+	sorted := append([]mgl32.Vec2(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X() != sorted[j].X() {
+			return sorted[i].X() < sorted[j].X()
+		}
+		return sorted[i].Y() < sorted[j].Y()
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+
+	hull := make([]mgl32.Vec2, 0, 2*len(sorted))
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range sorted {
+			for len(hull) >= start+2 && cross(hull[len(hull)-1].Sub(hull[len(hull)-2]), p.Sub(hull[len(hull)-2])) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		// Последняя точка прохода - первая точка следующего
+		hull = hull[:len(hull)-1]
+		for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+	}
+	return hull
+}
@@ -0,0 +1,374 @@
+package physics2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Параметры решателя контактов
+const (
+	contactBaumgarte     = 0.2 // Доля проникновения, устраняемая за шаг
+	restitutionThreshold = 1.0 // Скорость удара, ниже которой отскок не применяется
+	maxManifoldPoints    = 2
+)
+
+// contactKey идентифицирует манифолд пары тел между шагами
+type contactKey struct {
+	a, b    int
+	feature int32
+}
+
+// cachedImpulse накопленные импульсы точки контакта с прошлого шага
+type cachedImpulse struct {
+	id      uint32
+	normal  float32
+	tangent float32
+}
+
+// cachedManifold импульсы всех точек манифолда пары
+type cachedManifold struct {
+	points [maxManifoldPoints]cachedImpulse
+	count  int
+}
+
+// solverPoint точка контакта, подготовленная для решателя
+type solverPoint struct {
+	rA, rB mgl32.Vec2 // Плечи от центров тел
+	id     uint32
+	depth  float32
+
+	normalMass   float32
+	tangentMass  float32
+	velocityBias float32 // Целевая скорость расхождения при отскоке
+
+	normalImpulse  float32
+	tangentImpulse float32
+	pseudoImpulse  float32
+}
+
+// contactConstraint ограничение непроникновения для пары тел
+type contactConstraint struct {
+	bodyA, bodyB *Body
+	key          contactKey
+
+	invMassA, invMassB       float32
+	invInertiaA, invInertiaB float32
+
+	normal  mgl32.Vec2
+	tangent mgl32.Vec2
+
+	friction    float32
+	restitution float32
+
+	points [maxManifoldPoints]solverPoint
+	count  int
+
+	// Блочный решатель двух точек: матрица K и обратная к ней
+	blockK    [2][2]float32
+	blockMass [2][2]float32
+	useBlock  bool
+}
+
+// constraintSolver решатель контактов последовательными импульсами, как в
+// трехмерном пакете: трение Кулона, отскок, прогрев и коррекция проникновения
+// псевдоскоростями. Точки сопоставляются между шагами по ребрам, которые их образовали
+type constraintSolver struct {
+	constraints []contactConstraint
+	cache       map[contactKey]cachedManifold
+	nextCache   map[contactKey]cachedManifold
+}
+
+// newConstraintSolver создает решатель
+func newConstraintSolver() *constraintSolver {
+	return &constraintSolver{
+		cache:     make(map[contactKey]cachedManifold),
+		nextCache: make(map[contactKey]cachedManifold),
+	}
+}
+
+// solve разрешает контакты шага
+func (s *constraintSolver) solve(contacts []Manifold, dt float32, velocityIterations, positionIterations int, warmStarting bool) {
+	s.prepare(contacts, dt, warmStarting)
+	if warmStarting {
+		s.warmStart()
+	}
+	for i := 0; i < velocityIterations; i++ {
+		s.solveVelocities(i%2 == 1)
+	}
+	for i := 0; i < positionIterations; i++ {
+		s.solvePositions(dt)
+	}
+	s.storeImpulses()
+}
+
+// prepare строит ограничения по манифолдам и подхватывает импульсы прошлого шага
+func (s *constraintSolver) prepare(contacts []Manifold, dt float32, warmStarting bool) {
+	s.constraints = s.constraints[:0]
+
+	for i := range contacts {
+		manifold := &contacts[i]
+		a, b := manifold.BodyA, manifold.BodyB
+		c := contactConstraint{
+			bodyA:       a,
+			bodyB:       b,
+			key:         contactKey{a.ID, b.ID, manifold.feature},
+			invMassA:    a.inverseMass(),
+			invMassB:    b.inverseMass(),
+			invInertiaA: a.inverseInertia(),
+			invInertiaB: b.inverseInertia(),
+			normal:      manifold.Normal,
+			tangent:     perp(manifold.Normal),
+			friction:    float32(math.Sqrt(float64(a.Friction * b.Friction))),
+			restitution: min(a.Restitution, b.Restitution),
+		}
+		if c.invMassA == 0 && c.invMassB == 0 {
+			continue
+		}
+
+		cached, hasCache := s.cache[c.key]
+		for _, point := range manifold.Points {
+			if c.count == maxManifoldPoints {
+				break
+			}
+
+			sp := solverPoint{
+				rA:    point.Position.Sub(a.Position),
+				rB:    point.Position.Sub(b.Position),
+				id:    point.id,
+				depth: point.Depth,
+			}
+			sp.normalMass = c.effectiveMass(sp.rA, sp.rB, c.normal)
+			sp.tangentMass = c.effectiveMass(sp.rA, sp.rB, c.tangent)
+
+			// Точка с зазором разрешает сближение ровно до касания за шаг.
+			// Отскок только для заметных ударов, иначе стопки дрожат
+			approach := c.relativeVelocity(sp.rA, sp.rB).Dot(c.normal)
+			if sp.depth < 0 {
+				sp.velocityBias = sp.depth / dt
+			} else if approach < -restitutionThreshold {
+				sp.velocityBias = -c.restitution * approach
+			}
+
+			if warmStarting && hasCache {
+				for j := 0; j < cached.count; j++ {
+					if cached.points[j].id == sp.id {
+						sp.normalImpulse = cached.points[j].normal
+						sp.tangentImpulse = cached.points[j].tangent
+						break
+					}
+				}
+			}
+
+			c.points[c.count] = sp
+			c.count++
+		}
+		c.prepareBlock()
+
+		s.constraints = append(s.constraints, c)
+	}
+}
+
+// warmStart применяет импульсы, накопленные на прошлом шаге
+func (s *constraintSolver) warmStart() {
+	for i := range s.constraints {
+		c := &s.constraints[i]
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			c.applyImpulse(p.rA, p.rB, c.normal.Mul(p.normalImpulse).Add(c.tangent.Mul(p.tangentImpulse)))
+		}
+	}
+}
+
+// solveVelocities выполняет одну итерацию по скоростям: сначала трение,
+// затем непроникновение. Итерации чередуют направление обхода
+func (s *constraintSolver) solveVelocities(reverse bool) {
+	for k := range s.constraints {
+		i := k
+		if reverse {
+			i = len(s.constraints) - 1 - k
+		}
+		c := &s.constraints[i]
+
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			vt := c.relativeVelocity(p.rA, p.rB).Dot(c.tangent)
+
+			maxFriction := c.friction * p.normalImpulse
+			old := p.tangentImpulse
+			p.tangentImpulse = clamp32(old-vt*p.tangentMass, -maxFriction, maxFriction)
+			c.applyImpulse(p.rA, p.rB, c.tangent.Mul(p.tangentImpulse-old))
+		}
+
+		if c.useBlock {
+			c.solveBlock()
+			continue
+		}
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			vn := c.relativeVelocity(p.rA, p.rB).Dot(c.normal)
+
+			old := p.normalImpulse
+			p.normalImpulse = max(old-p.normalMass*(vn-p.velocityBias), 0)
+			c.applyImpulse(p.rA, p.rB, c.normal.Mul(p.normalImpulse-old))
+		}
+	}
+}
+
+// solvePositions выполняет одну итерацию коррекции проникновения через псевдоскорости
+func (s *constraintSolver) solvePositions(dt float32) {
+	for i := range s.constraints {
+		c := &s.constraints[i]
+
+		if c.useBlock {
+			p1, p2 := &c.points[0], &c.points[1]
+			v1 := c.pseudoVelocity(p1.rA, p1.rB).Dot(c.normal) - positionBias(p1.depth, dt)
+			v2 := c.pseudoVelocity(p2.rA, p2.rB).Dot(c.normal) - positionBias(p2.depth, dt)
+			if x1, x2, ok := c.blockLCP(p1.pseudoImpulse, p2.pseudoImpulse, v1, v2); ok {
+				c.applyPseudoImpulse(p1.rA, p1.rB, c.normal.Mul(x1-p1.pseudoImpulse))
+				c.applyPseudoImpulse(p2.rA, p2.rB, c.normal.Mul(x2-p2.pseudoImpulse))
+				p1.pseudoImpulse, p2.pseudoImpulse = x1, x2
+			}
+			continue
+		}
+
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			if p.depth <= linearSlop {
+				continue
+			}
+
+			vn := c.pseudoVelocity(p.rA, p.rB).Dot(c.normal)
+			old := p.pseudoImpulse
+			p.pseudoImpulse = max(old+p.normalMass*(positionBias(p.depth, dt)-vn), 0)
+			c.applyPseudoImpulse(p.rA, p.rB, c.normal.Mul(p.pseudoImpulse-old))
+		}
+	}
+}
+
+// positionBias возвращает псевдоскорость, устраняющую часть проникновения сверх допуска
+func positionBias(depth, dt float32) float32 {
+	return contactBaumgarte / dt * max(depth-linearSlop, 0)
+}
+
+// storeImpulses сохраняет накопленные импульсы для прогрева на следующем шаге
+func (s *constraintSolver) storeImpulses() {
+	clear(s.nextCache)
+	for i := range s.constraints {
+		c := &s.constraints[i]
+		var cached cachedManifold
+		for j := 0; j < c.count; j++ {
+			p := &c.points[j]
+			cached.points[j] = cachedImpulse{id: p.id, normal: p.normalImpulse, tangent: p.tangentImpulse}
+		}
+		cached.count = c.count
+		s.nextCache[c.key] = cached
+	}
+	s.cache, s.nextCache = s.nextCache, s.cache
+}
+
+// prepareBlock готовит совместное решение двух точек манифолда. Поочередное
+// решение точек плоского контакта дает несимметричный импульс, и стопки
+// раскачиваются. При плохо обусловленной матрице остается поочередное решение
+func (c *contactConstraint) prepareBlock() {
+	c.useBlock = false
+	if c.count != 2 {
+		return
+	}
+
+	p1, p2 := &c.points[0], &c.points[1]
+	rn1A, rn1B := cross(p1.rA, c.normal), cross(p1.rB, c.normal)
+	rn2A, rn2B := cross(p2.rA, c.normal), cross(p2.rB, c.normal)
+	mass := c.invMassA + c.invMassB
+
+	k11 := mass + c.invInertiaA*rn1A*rn1A + c.invInertiaB*rn1B*rn1B
+	k22 := mass + c.invInertiaA*rn2A*rn2A + c.invInertiaB*rn2B*rn2B
+	k12 := mass + c.invInertiaA*rn1A*rn2A + c.invInertiaB*rn1B*rn2B
+
+	det := k11*k22 - k12*k12
+	if k11*k11 >= 1000*det {
+		return
+	}
+	c.blockK = [2][2]float32{{k11, k12}, {k12, k22}}
+	c.blockMass = [2][2]float32{{k22 / det, -k12 / det}, {-k12 / det, k11 / det}}
+	c.useBlock = true
+}
+
+// solveBlock решает непроникновение двух точек совместно
+func (c *contactConstraint) solveBlock() {
+	p1, p2 := &c.points[0], &c.points[1]
+	vn1 := c.relativeVelocity(p1.rA, p1.rB).Dot(c.normal) - p1.velocityBias
+	vn2 := c.relativeVelocity(p2.rA, p2.rB).Dot(c.normal) - p2.velocityBias
+
+	x1, x2, ok := c.blockLCP(p1.normalImpulse, p2.normalImpulse, vn1, vn2)
+	if !ok {
+		return
+	}
+	c.applyImpulse(p1.rA, p1.rB, c.normal.Mul(x1-p1.normalImpulse))
+	c.applyImpulse(p2.rA, p2.rB, c.normal.Mul(x2-p2.normalImpulse))
+	p1.normalImpulse, p2.normalImpulse = x1, x2
+}
+
+// blockLCP решает задачу LCP 2x2 для накопленных импульсов a1, a2 при
+// относительных скоростях v1, v2 перебором случаев: обе точки активны,
+// активна одна из них, ни одна
+func (c *contactConstraint) blockLCP(a1, a2, v1, v2 float32) (float32, float32, bool) {
+	k, inv := &c.blockK, &c.blockMass
+	b1 := v1 - (k[0][0]*a1 + k[0][1]*a2)
+	b2 := v2 - (k[1][0]*a1 + k[1][1]*a2)
+
+	if x1, x2 := -(inv[0][0]*b1 + inv[0][1]*b2), -(inv[1][0]*b1 + inv[1][1]*b2); x1 >= 0 && x2 >= 0 {
+		return x1, x2, true
+	}
+	if x1 := -b1 / k[0][0]; x1 >= 0 && k[1][0]*x1+b2 >= 0 {
+		return x1, 0, true
+	}
+	if x2 := -b2 / k[1][1]; x2 >= 0 && k[0][1]*x2+b1 >= 0 {
+		return 0, x2, true
+	}
+	if b1 >= 0 && b2 >= 0 {
+		return 0, 0, true
+	}
+	return 0, 0, false
+}
+
+// relativeVelocity возвращает скорость точки тела B относительно тела A
+func (c *contactConstraint) relativeVelocity(rA, rB mgl32.Vec2) mgl32.Vec2 {
+	va := c.bodyA.Velocity.Add(crossSV(c.bodyA.AngularVelocity, rA))
+	vb := c.bodyB.Velocity.Add(crossSV(c.bodyB.AngularVelocity, rB))
+	return vb.Sub(va)
+}
+
+// effectiveMass возвращает эффективную массу пары тел вдоль направления dir
+func (c *contactConstraint) effectiveMass(rA, rB, dir mgl32.Vec2) float32 {
+	ra := cross(rA, dir)
+	rb := cross(rB, dir)
+	k := c.invMassA + c.invMassB + c.invInertiaA*ra*ra + c.invInertiaB*rb*rb
+	if k <= 1e-12 {
+		return 0
+	}
+	return 1 / k
+}
+
+// pseudoVelocity возвращает псевдоскорость точки тела B относительно тела A
+func (c *contactConstraint) pseudoVelocity(rA, rB mgl32.Vec2) mgl32.Vec2 {
+	va := c.bodyA.pseudoVelocity.Add(crossSV(c.bodyA.pseudoAngular, rA))
+	vb := c.bodyB.pseudoVelocity.Add(crossSV(c.bodyB.pseudoAngular, rB))
+	return vb.Sub(va)
+}
+
+// applyPseudoImpulse прикладывает импульс коррекции к B и противоположный к A
+func (c *contactConstraint) applyPseudoImpulse(rA, rB, impulse mgl32.Vec2) {
+	c.bodyA.pseudoVelocity = c.bodyA.pseudoVelocity.Sub(impulse.Mul(c.invMassA))
+	c.bodyA.pseudoAngular -= c.invInertiaA * cross(rA, impulse)
+	c.bodyB.pseudoVelocity = c.bodyB.pseudoVelocity.Add(impulse.Mul(c.invMassB))
+	c.bodyB.pseudoAngular += c.invInertiaB * cross(rB, impulse)
+}
+
+// applyImpulse прикладывает импульс к B и противоположный к A
+func (c *contactConstraint) applyImpulse(rA, rB, impulse mgl32.Vec2) {
+	c.bodyA.Velocity = c.bodyA.Velocity.Sub(impulse.Mul(c.invMassA))
+	c.bodyA.AngularVelocity -= c.invInertiaA * cross(rA, impulse)
+	c.bodyB.Velocity = c.bodyB.Velocity.Add(impulse.Mul(c.invMassB))
+	c.bodyB.AngularVelocity += c.invInertiaB * cross(rB, impulse)
+}
@@ -0,0 +1,193 @@
+package physics2d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/Salamander5876/AnimoEngine/pkg/core/event"
+)
+
+// groundCosine минимальный косинус угла между нормалью опоры и направлением
+// против гравитации, при котором тело считается стоящим (около 45°)
+const groundCosine = 0.7
+
+// World физический мир в плоскости
+type World struct {
+	Gravity mgl32.Vec2
+	Bodies  []*Body
+	nextID  int
+
+	// Настройки решателя
+	VelocityIterations int  // Итерации по скоростям (точность контактов и трения)
+	PositionIterations int  // Итерации коррекции проникновения
+	WarmStarting       bool // Начинать с импульсов прошлого шага (устойчивость стопок)
+
+	solver     *constraintSolver
+	broadPhase sweepAndPrune
+	contacts   []Manifold
+	triggers   []Manifold // Пересечения с датчиками
+
+	// События коллизий: касания текущего и прошлого шага
+	eventBus         *event.EventBus
+	touching         []touchingPair
+	touchingKeys     map[contactKey]bool
+	previousTouching []touchingPair
+	previousKeys     map[contactKey]bool
+}
+
+// NewWorld создает мир с гравитацией платформера. Для вида сверху
+// задайте нулевую Gravity
+func NewWorld() *World {
+	return &World{
+		Gravity: mgl32.Vec2{0, -9.81},
+		Bodies:  make([]*Body, 0),
+
+		VelocityIterations: 8,
+		PositionIterations: 3,
+		WarmStarting:       true,
+
+		solver:       newConstraintSolver(),
+		touchingKeys: make(map[contactKey]bool),
+		previousKeys: make(map[contactKey]bool),
+	}
+}
+
+// AddBody добавляет тело в мир
+func (w *World) AddBody(body *Body) *Body {
+	body.ID = w.nextID
+	w.nextID++
+	w.Bodies = append(w.Bodies, body)
+	return body
+}
+
+// RemoveBody удаляет тело из мира
+func (w *World) RemoveBody(body *Body) {
+	for i, b := range w.Bodies {
+		if b.ID == body.ID {
+			w.Bodies = append(w.Bodies[:i], w.Bodies[i+1:]...)
+			return
+		}
+	}
+}
+
+// Step делает шаг симуляции
+func (w *World) Step(dt float32) {
+	if dt <= 0 {
+		return
+	}
+
+	// Интегрируем скорости: силы, гравитация и затухание
+	for _, body := range w.Bodies {
+		if body.Type != Dynamic {
+			continue
+		}
+
+		acceleration := body.force.Mul(body.inverseMass()).Add(w.Gravity.Mul(body.GravityScale))
+		body.Velocity = body.Velocity.Add(acceleration.Mul(dt))
+		body.AngularVelocity += body.torque * body.inverseInertia() * dt
+
+		body.Velocity = body.Velocity.Mul(1 / (1 + dt*body.LinearDamping))
+		body.AngularVelocity *= 1 / (1 + dt*body.AngularDamping)
+
+		body.force = mgl32.Vec2{}
+		body.torque = 0
+	}
+
+	// Находим контакты и решаем их импульсами
+	w.checkCollisions()
+	w.solver.solve(w.contacts, dt, w.VelocityIterations, w.PositionIterations, w.WarmStarting)
+
+	// Интегрируем положение с учетом псевдоскоростей коррекции.
+	// Кинематические тела движутся со своей скоростью
+	for _, body := range w.Bodies {
+		if body.Type == Static {
+			continue
+		}
+
+		body.Position = body.Position.Add(body.Velocity.Add(body.pseudoVelocity).Mul(dt))
+		body.Angle += (body.AngularVelocity + body.pseudoAngular) * dt
+
+		body.pseudoVelocity = mgl32.Vec2{}
+		body.pseudoAngular = 0
+	}
+
+	w.emitCollisionEvents()
+}
+
+// checkCollisions проверяет столкновения между телами.
+// Кандидатов отбирает широкая фаза, затем узкая фаза строит манифолды контактов.
+// Пары с датчиками попадают в triggers и не решаются
+func (w *World) checkCollisions() {
+	w.contacts = w.contacts[:0]
+	w.triggers = w.triggers[:0]
+
+	// Формы в мировых координатах
+	for _, body := range w.Bodies {
+		if body.Shape != nil {
+			body.collider.update(body)
+		}
+	}
+
+	w.broadPhase.update(w.Bodies)
+	w.broadPhase.pairs(func(bodyA, bodyB *Body) {
+		// Тела, которые не могут сдвинуть друг друга, не проверяем
+		if bodyA.Type != Dynamic && bodyB.Type != Dynamic && !bodyA.IsTrigger && !bodyB.IsTrigger {
+			return
+		}
+		if !bodyA.CanCollideWith(bodyB) {
+			return
+		}
+
+		if bodyA.IsTrigger || bodyB.IsTrigger {
+			w.triggers = collideBodies(bodyA, bodyB, w.triggers)
+			return
+		}
+		w.contacts = collideBodies(bodyA, bodyB, w.contacts)
+	})
+
+	w.updateGrounded()
+}
+
+// updateGrounded выставляет IsGrounded телам, которые опираются на контакт
+// с нормалью против гравитации. Более крутые поверхности считаются стенами
+func (w *World) updateGrounded() {
+	up := mgl32.Vec2{0, 1}
+	if w.Gravity.LenSqr() > 0 {
+		up = w.Gravity.Normalize().Mul(-1)
+	}
+
+	for _, body := range w.Bodies {
+		body.IsGrounded = false
+	}
+	for i := range w.contacts {
+		manifold := &w.contacts[i]
+		// Зазор до касания - еще не опора
+		touching := false
+		for _, p := range manifold.Points {
+			touching = touching || p.Depth >= -linearSlop
+		}
+		if !touching {
+			continue
+		}
+
+		// Нормаль направлена от A к B: вверх - B стоит на A, вниз - наоборот
+		slope := manifold.Normal.Dot(up)
+		if slope >= groundCosine {
+			manifold.BodyB.IsGrounded = true
+		} else if slope <= -groundCosine {
+			manifold.BodyA.IsGrounded = true
+		}
+	}
+}
+
+// GetContacts возвращает контакты, найденные на последнем шаге
+func (w *World) GetContacts() []Manifold {
+	return w.contacts
+}
+
+// pairKey возвращает ключ пары тел, не зависящий от порядка
+func pairKey(a, b *Body) contactKey {
+	if a.ID > b.ID {
+		a, b = b, a
+	}
+	return contactKey{a: a.ID, b: b.ID}
+}